@@ -0,0 +1,36 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+/*
+This is an autogenerated file by autobindings
+*/
+
+import (
+	"github.com/mholt/binding"
+)
+
+func (a *AccessToken) FieldMap() binding.FieldMap {
+	return binding.FieldMap{
+		&a.Name:  "name",
+		&a.Scope: "scope",
+	}
+}
+
+func (a *AccessToken) Validate(req *http.Request, errs binding.Errors) binding.Errors {
+	// The checkboxes of the form send each scope as a value of its own.
+	if scopes := req.PostForm["scope"]; len(scopes) > 1 {
+		a.Scope = strings.Join(scopes, " ")
+	}
+	errs = limitLength(errs, "name", a.Name, maxNameLength, "Name is too long.")
+	for _, scope := range strings.Fields(a.Scope) {
+		if !validScope(scope) {
+			errs.Add([]string{"scope"}, ErrInvalidScope, "Scope must be some of "+strings.Join(tokenScopes, ", ")+", separated by spaces.")
+			break
+		}
+	}
+    return errs
+}
@@ -0,0 +1,1003 @@
+// Activitypub.go lets the fediverse, such as Mastodon, follow the authors of the blog. Every author is an
+// ActivityPub actor at /activitypub/authors/:id, found by WebFinger as @name@host where name is made of the
+// name of the author. Followers are sent Create, Update and Delete activities of posts as they are published,
+// edited and deleted, and public replies to posts become comments under them. Requests between servers are
+// signed with HTTP Signatures, with a key pair per author. See https://www.w3.org/TR/activitypub/.
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gosimple/slug"
+	"github.com/jinzhu/gorm"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Types of activities sent to followers.
+const (
+	ActivityCreate = "Create"
+	ActivityUpdate = "Update"
+	ActivityDelete = "Delete"
+)
+
+const (
+	activityContentType = "application/activity+json"
+	activityStreams     = "https://www.w3.org/ns/activitystreams"
+	publicAudience      = activityStreams + "#Public"
+)
+
+// Requests to other servers are made with activityClient, reading at most maxActivityBody bytes of responses.
+// Signed requests are accepted within maxSignatureAge of their date, and the outbox lists outboxSize posts.
+var (
+	activityClient  = &http.Client{Timeout: 10 * time.Second}
+	maxActivityBody = int64(1 << 20)
+	maxSignatureAge = 12 * time.Hour
+	outboxSize      = 20
+)
+
+// ActorKey is the key pair with which requests of the author are signed. It is made when first needed.
+type ActorKey struct {
+	ID         int64  `json:"id" gorm:"primary_key:yes"`
+	Author     int64  `json:"author"`
+	PrivateKey string `json:"-" sql:"type:text"`
+	PublicKey  string `json:"public_key" sql:"type:text"`
+}
+
+// Follower is an actor of another server, Actor being its ID, who follows the author. Activities are delivered
+// to Inbox, the shared inbox of its server if it has one. Follow is the ID of the Follow activity.
+type Follower struct {
+	ID     int64  `json:"id" gorm:"primary_key:yes"`
+	Author int64  `json:"author"`
+	Actor  string `json:"actor"`
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Inbox  string `json:"-"`
+	Follow string `json:"-"`
+	Date   int64  `json:"date"`
+}
+
+// audience is a property which may be a single ID or a list of them, such as to and cc.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*a = audience{one}
+		return nil
+	}
+	var many []interface{}
+	if err := json.Unmarshal(data, &many); err != nil {
+		return nil
+	}
+	for _, item := range many {
+		if id, ok := item.(string); ok {
+			*a = append(*a, id)
+		}
+	}
+	return nil
+}
+
+// remoteActor is an actor of another server, or its key when the key is a document of its own.
+type remoteActor struct {
+	ID                string          `json:"id"`
+	Type              string          `json:"type"`
+	Name              string          `json:"name"`
+	PreferredUsername string          `json:"preferredUsername"`
+	URL               json.RawMessage `json:"url"`
+	Inbox             string          `json:"inbox"`
+	Endpoints         struct {
+		SharedInbox string `json:"sharedInbox"`
+	} `json:"endpoints"`
+	PublicKey struct {
+		ID           string `json:"id"`
+		Owner        string `json:"owner"`
+		PublicKeyPem string `json:"publicKeyPem"`
+	} `json:"publicKey"`
+	Owner        string `json:"owner"`
+	PublicKeyPem string `json:"publicKeyPem"`
+}
+
+// DisplayName or actor.DisplayName returns the name of the actor, or its username if it has none.
+func (actor remoteActor) DisplayName() string {
+	if name := strings.TrimSpace(actor.Name); name != "" {
+		return name
+	}
+	if actor.PreferredUsername != "" {
+		return actor.PreferredUsername
+	}
+	return actor.ID
+}
+
+// Profile or actor.Profile returns the URL of the profile page of the actor, or its ID if it has none.
+func (actor remoteActor) Profile() string {
+	var profile string
+	if err := json.Unmarshal(actor.URL, &profile); err == nil && validHTTPURL(profile) {
+		return profile
+	}
+	return actor.ID
+}
+
+// SharedInbox or actor.SharedInbox returns the inbox to deliver activities to, shared by the actors of its server if possible.
+func (actor remoteActor) SharedInbox() string {
+	if actor.Endpoints.SharedInbox != "" {
+		return actor.Endpoints.SharedInbox
+	}
+	return actor.Inbox
+}
+
+// activity is an activity received in an inbox, or the object of one.
+type activity struct {
+	ID           string          `json:"id"`
+	Type         string          `json:"type"`
+	Actor        string          `json:"actor"`
+	Object       json.RawMessage `json:"object"`
+	AttributedTo audience        `json:"attributedTo"`
+	InReplyTo    json.RawMessage `json:"inReplyTo"`
+	Content      string          `json:"content"`
+	To           audience        `json:"to"`
+	Cc           audience        `json:"cc"`
+}
+
+// Public or object.Public reports whether the object is addressed to everyone.
+func (object activity) Public() bool {
+	for _, id := range append(object.To, object.Cc...) {
+		if id == publicAudience || id == "as:Public" || id == "Public" {
+			return true
+		}
+	}
+	return false
+}
+
+// objectID returns the ID of an object given as its ID or embedded, or an empty string if it has none.
+func objectID(raw json.RawMessage) string {
+	var id string
+	if err := json.Unmarshal(raw, &id); err == nil {
+		return id
+	}
+	var object struct {
+		ID string `json:"id"`
+	}
+	json.Unmarshal(raw, &object)
+	return object.ID
+}
+
+// sameOrigin reports whether URLs a and b are on the same server, as an actor may only act on its own objects.
+func sameOrigin(a, b string) bool {
+	ua, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+	ub, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+	return ua.Scheme == ub.Scheme && ua.Host == ub.Host
+}
+
+// actorID returns the ID of the actor of the author with ID id.
+func actorID(id int64) string {
+	return urlHost() + "/activitypub/authors/" + strconv.FormatInt(id, 10)
+}
+
+// postObjectID returns the ID of the post with ID id as an ActivityPub object.
+func postObjectID(id int64) string {
+	return urlHost() + "/activitypub/posts/" + strconv.FormatInt(id, 10)
+}
+
+// actorHost returns the host of the blog, as in the handles of its authors.
+func actorHost() string {
+	u, err := url.Parse(urlHost())
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// actorNames returns the usernames of all authors by their ID. A username is made of the name of the author,
+// and of their ID as well if an earlier author has the same name.
+func actorNames() (map[int64]string, error) {
+	names := make(map[int64]string)
+	var users []User
+	query := db.Order("id asc").Find(&users)
+	if query.Error != nil && query.Error != gorm.RecordNotFound {
+		return names, query.Error
+	}
+	taken := make(map[string]bool)
+	for _, user := range users {
+		name := slug.Make(user.Name)
+		if name == "" {
+			name = "author"
+		}
+		if taken[name] {
+			name += "-" + strconv.FormatInt(user.ID, 10)
+		}
+		taken[name] = true
+		names[user.ID] = name
+	}
+	return names, nil
+}
+
+// actorKey returns the key pair of the author with ID id, making it if the author has none yet.
+func actorKey(id int64) (ActorKey, error) {
+	var key ActorKey
+	query := db.Where("author = ?", id).First(&key)
+	if query.Error == nil {
+		return key, nil
+	}
+	if query.Error != gorm.RecordNotFound {
+		return key, query.Error
+	}
+	private, public, err := generateKeyPair()
+	if err != nil {
+		return key, err
+	}
+	key = ActorKey{Author: id, PrivateKey: private, PublicKey: public}
+	if err := db.Create(&key).Error; err != nil {
+		// Another request made the key first.
+		if db.Where("author = ?", id).First(&key).Error == nil {
+			return key, nil
+		}
+		return key, err
+	}
+	return key, nil
+}
+
+// requestedActor returns the author of mux parameter "id" with their key pair.
+// Returns "not found" if there is no such author.
+func requestedActor(r *http.Request) (User, ActorKey, error) {
+	var user User
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		return user, ActorKey{}, errors.New("not found")
+	}
+	query := db.Where("id = ?", id).First(&user)
+	if query.Error != nil {
+		if query.Error == gorm.RecordNotFound {
+			return user, ActorKey{}, errors.New("not found")
+		}
+		return user, ActorKey{}, query.Error
+	}
+	key, err := actorKey(user.ID)
+	return user, key, err
+}
+
+// activityError writes the response for an error returned by ActivityPub functions.
+func activityError(w http.ResponseWriter, err error) {
+	switch err.Error() {
+	case "not found":
+		rend.JSON(w, http.StatusNotFound, NotFound())
+	default:
+		log.Println("activitypub: ", err)
+		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
+	}
+}
+
+// writeActivity writes v as JSON with the content type of ActivityPub.
+func writeActivity(w http.ResponseWriter, contentType string, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		activityError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", contentType+"; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// authorActor returns the actor of user, known as name, with the public key of key.
+func authorActor(user User, name string, key ActorKey) map[string]interface{} {
+	id := actorID(user.ID)
+	actor := map[string]interface{}{
+		"@context":          []string{activityStreams, "https://w3id.org/security/v1"},
+		"id":                id,
+		"type":              "Person",
+		"preferredUsername": name,
+		"name":              user.Name,
+		"url":               urlHost(),
+		"inbox":             id + "/inbox",
+		"outbox":            id + "/outbox",
+		"followers":         id + "/followers",
+		"publicKey": map[string]interface{}{
+			"id":           id + "#main-key",
+			"owner":        id,
+			"publicKeyPem": key.PublicKey,
+		},
+	}
+	if Settings != nil && Settings.Description != "" {
+		actor["summary"] = Settings.Description
+	}
+	if user.Avatar != "" {
+		actor["icon"] = map[string]interface{}{"type": "Image", "url": user.Avatar}
+	}
+	return actor
+}
+
+// postObject returns post as an ActivityPub Article.
+func postObject(post Post) (map[string]interface{}, error) {
+	path, err := post.Path()
+	if err != nil {
+		return nil, err
+	}
+	author := actorID(post.Author)
+	return map[string]interface{}{
+		"id":           postObjectID(post.ID),
+		"type":         "Article",
+		"attributedTo": author,
+		"name":         post.Title,
+		"content":      post.Content,
+		"url":          urlHost() + path,
+		"published":    time.Unix(post.Date, 0).UTC().Format(time.RFC3339),
+		"to":           []string{publicAudience},
+		"cc":           []string{author + "/followers"},
+	}, nil
+}
+
+// newActivity returns an activity of kind by the author with ID author on object.
+func newActivity(kind string, author int64, object interface{}) map[string]interface{} {
+	id := actorID(author)
+	return map[string]interface{}{
+		"@context": activityStreams,
+		"id":       fmt.Sprintf("%s/activities/%s-%d", id, strings.ToLower(kind), time.Now().UnixNano()),
+		"type":     kind,
+		"actor":    id,
+		"object":   object,
+		"to":       []string{publicAudience},
+		"cc":       []string{id + "/followers"},
+	}
+}
+
+// signingString returns the string which the signature of r with headers covers.
+func signingString(r *http.Request, headers []string) (string, error) {
+	lines := make([]string, 0, len(headers))
+	for _, header := range headers {
+		var value string
+		switch header {
+		case "(request-target)":
+			value = strings.ToLower(r.Method) + " " + r.URL.RequestURI()
+		case "host":
+			value = r.Host
+		default:
+			value = r.Header.Get(header)
+		}
+		if value == "" {
+			return "", errors.New("signed header " + header + " is missing")
+		}
+		lines = append(lines, header+": "+value)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// bodyDigest returns the value of header Digest for body.
+func bodyDigest(body []byte) string {
+	digest := sha256.Sum256(body)
+	return "SHA-256=" + base64.StdEncoding.EncodeToString(digest[:])
+}
+
+// signRequest signs r, whose body is body, with private key key known as keyID.
+// Requests without body, such as GET, are signed without header Digest.
+func signRequest(r *http.Request, body []byte, keyID string, key *rsa.PrivateKey) error {
+	r.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	headers := []string{"(request-target)", "host", "date"}
+	if body != nil {
+		r.Header.Set("Digest", bodyDigest(body))
+		headers = append(headers, "digest")
+	}
+	signed, err := signingString(r, headers)
+	if err != nil {
+		return err
+	}
+	hash := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(nil, key, crypto.SHA256, hash[:])
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Signature", fmt.Sprintf(`keyId="%s",algorithm="rsa-sha256",headers="%s",signature="%s"`,
+		keyID, strings.Join(headers, " "), base64.StdEncoding.EncodeToString(signature)))
+	return nil
+}
+
+var signatureParameter = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// verifyRequest checks the signature of r, whose body is body, and returns the actor who signed it.
+// Keys of actors are fetched with signer, as some servers only answer signed requests.
+func verifyRequest(r *http.Request, body []byte, signer ActorKey) (remoteActor, error) {
+	var actor remoteActor
+	parameters := make(map[string]string)
+	for _, match := range signatureParameter.FindAllStringSubmatch(r.Header.Get("Signature"), -1) {
+		parameters[match[1]] = match[2]
+	}
+	keyID := parameters["keyId"]
+	if keyID == "" || parameters["signature"] == "" {
+		return actor, errors.New("request is not signed")
+	}
+	headers := strings.Fields(strings.ToLower(parameters["headers"]))
+	required := []string{"(request-target)", "host", "date"}
+	if r.Method == "POST" {
+		required = append(required, "digest")
+	}
+	for _, header := range required {
+		if !containsField(headers, header) {
+			return actor, errors.New("signature does not cover " + header)
+		}
+	}
+	date, err := http.ParseTime(r.Header.Get("Date"))
+	if err != nil || time.Since(date) > maxSignatureAge || time.Until(date) > maxSignatureAge {
+		return actor, errors.New("signature has expired")
+	}
+	if r.Method == "POST" && r.Header.Get("Digest") != bodyDigest(body) {
+		return actor, errors.New("digest does not match the body")
+	}
+	signature, err := base64.StdEncoding.DecodeString(parameters["signature"])
+	if err != nil {
+		return actor, err
+	}
+	signed, err := signingString(r, headers)
+	if err != nil {
+		return actor, err
+	}
+
+	actor, err = fetchActor(keyID, signer)
+	if err != nil {
+		return actor, err
+	}
+	pem := actor.PublicKey.PublicKeyPem
+	if actor.Inbox == "" && actor.Owner != "" {
+		// The key is a document of its own, whose owner is the actor.
+		pem = actor.PublicKeyPem
+		if actor, err = fetchActor(actor.Owner, signer); err != nil {
+			return actor, err
+		}
+	}
+	if actor.PublicKey.ID != keyID {
+		return actor, errors.New("key does not belong to the actor")
+	}
+	public, err := parsePublicKey(pem)
+	if err != nil {
+		return actor, err
+	}
+	hash := sha256.Sum256([]byte(signed))
+	if err := rsa.VerifyPKCS1v15(public, crypto.SHA256, hash[:], signature); err != nil {
+		return actor, errors.New("invalid signature")
+	}
+	return actor, nil
+}
+
+// fetchActor returns the actor or key with ID id, requested with a signature by signer.
+func fetchActor(id string, signer ActorKey) (remoteActor, error) {
+	var actor remoteActor
+	if i := strings.Index(id, "#"); i >= 0 {
+		id = id[:i]
+	}
+	if !validHTTPURL(id) {
+		return actor, errors.New("invalid actor " + id)
+	}
+	request, err := http.NewRequest("GET", id, nil)
+	if err != nil {
+		return actor, err
+	}
+	request.Header.Set("Accept", activityContentType)
+	key, err := parsePrivateKey(signer.PrivateKey)
+	if err != nil {
+		return actor, err
+	}
+	if err := signRequest(request, nil, actorID(signer.Author)+"#main-key", key); err != nil {
+		return actor, err
+	}
+	response, err := activityClient.Do(request)
+	if err != nil {
+		return actor, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return actor, errors.New(id + " answered " + response.Status)
+	}
+	err = json.NewDecoder(io.LimitReader(response.Body, maxActivityBody)).Decode(&actor)
+	return actor, err
+}
+
+// deliver posts activity to inbox with the signature of signer.
+func deliver(inbox string, activity interface{}, signer ActorKey) error {
+	body, err := json.Marshal(activity)
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequest("POST", inbox, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", activityContentType)
+	key, err := parsePrivateKey(signer.PrivateKey)
+	if err != nil {
+		return err
+	}
+	if err := signRequest(request, body, actorID(signer.Author)+"#main-key", key); err != nil {
+		return err
+	}
+	response, err := activityClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(response.Body, maxActivityBody))
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return errors.New(inbox + " answered " + response.Status)
+	}
+	return nil
+}
+
+// Federate delivers an activity of kind ActivityCreate, ActivityUpdate or ActivityDelete of post to the
+// followers of its author, once per server. Pages are not federated.
+// Returns immediately, delivering in the background.
+func Federate(kind string, post Post) {
+	if post.Kind != KindPost {
+		return
+	}
+	deliveries.Add(1)
+	go func() {
+		defer deliveries.Done()
+		var followers []Follower
+		query := db.Where("author = ?", post.Author).Find(&followers)
+		if query.Error != nil && query.Error != gorm.RecordNotFound {
+			log.Println("federate: ", query.Error)
+			return
+		}
+		if len(followers) == 0 {
+			return
+		}
+		key, err := actorKey(post.Author)
+		if err != nil {
+			log.Println("federate: ", err)
+			return
+		}
+		var object interface{} = map[string]interface{}{"id": postObjectID(post.ID), "type": "Tombstone"}
+		if kind != ActivityDelete {
+			if object, err = postObject(post); err != nil {
+				log.Println("federate: ", err)
+				return
+			}
+		}
+		sent := newActivity(kind, post.Author, object)
+		delivered := make(map[string]bool)
+		for _, follower := range followers {
+			if delivered[follower.Inbox] {
+				continue
+			}
+			delivered[follower.Inbox] = true
+			if err := deliver(follower.Inbox, sent, key); err != nil {
+				log.Println("federate: ", err)
+			}
+		}
+	}()
+}
+
+// ReadWebFinger is the WebFinger endpoint, which finds the actor of an author by resource
+// acct:name@host or by the ID of the actor.
+func ReadWebFinger(w http.ResponseWriter, r *http.Request) {
+	resource := r.URL.Query().Get("resource")
+	names, err := actorNames()
+	if err != nil {
+		activityError(w, err)
+		return
+	}
+	for id, name := range names {
+		if resource != "acct:"+name+"@"+actorHost() && resource != actorID(id) {
+			continue
+		}
+		writeActivity(w, "application/jrd+json", map[string]interface{}{
+			"subject": "acct:" + name + "@" + actorHost(),
+			"aliases": []string{actorID(id)},
+			"links": []map[string]string{
+				{"rel": "self", "type": activityContentType, "href": actorID(id)},
+				{"rel": "http://webfinger.net/rel/profile-page", "type": "text/html", "href": urlHost()},
+			},
+		})
+		return
+	}
+	rend.JSON(w, http.StatusNotFound, NotFound())
+}
+
+// ReadActor is a route which returns the actor of the author "id".
+func ReadActor(w http.ResponseWriter, r *http.Request) {
+	user, key, err := requestedActor(r)
+	if err != nil {
+		activityError(w, err)
+		return
+	}
+	names, err := actorNames()
+	if err != nil {
+		activityError(w, err)
+		return
+	}
+	writeActivity(w, activityContentType, authorActor(user, names[user.ID], key))
+}
+
+// ReadOutbox is a route which returns the latest published posts of the author "id" as Create activities.
+func ReadOutbox(w http.ResponseWriter, r *http.Request) {
+	user, _, err := requestedActor(r)
+	if err != nil {
+		activityError(w, err)
+		return
+	}
+	var posts []Post
+	query := db.Order("date desc").Limit(outboxSize).Where("author = ? AND published = ? AND kind = ?", user.ID, true, KindPost).Find(&posts)
+	if query.Error != nil && query.Error != gorm.RecordNotFound {
+		activityError(w, query.Error)
+		return
+	}
+	var total int
+	if err := db.Model(Post{}).Where("author = ? AND published = ? AND kind = ?", user.ID, true, KindPost).Count(&total).Error; err != nil {
+		activityError(w, err)
+		return
+	}
+	items := make([]interface{}, 0, len(posts))
+	for _, post := range posts {
+		object, err := postObject(post)
+		if err != nil {
+			activityError(w, err)
+			return
+		}
+		create := newActivity(ActivityCreate, user.ID, object)
+		create["id"] = postObjectID(post.ID) + "/activity"
+		delete(create, "@context")
+		items = append(items, create)
+	}
+	writeActivity(w, activityContentType, map[string]interface{}{
+		"@context":     activityStreams,
+		"id":           actorID(user.ID) + "/outbox",
+		"type":         "OrderedCollection",
+		"totalItems":   total,
+		"orderedItems": items,
+	})
+}
+
+// ReadFollowerCollection is a route which returns the number of followers of the author "id".
+// The followers themselves are only shown to the author.
+func ReadFollowerCollection(w http.ResponseWriter, r *http.Request) {
+	user, _, err := requestedActor(r)
+	if err != nil {
+		activityError(w, err)
+		return
+	}
+	var total int
+	if err := db.Model(Follower{}).Where("author = ?", user.ID).Count(&total).Error; err != nil {
+		activityError(w, err)
+		return
+	}
+	writeActivity(w, activityContentType, map[string]interface{}{
+		"@context":   activityStreams,
+		"id":         actorID(user.ID) + "/followers",
+		"type":       "OrderedCollection",
+		"totalItems": total,
+	})
+}
+
+// ReadPostObject is a route which returns the published post "id" as an ActivityPub object.
+func ReadPostObject(w http.ResponseWriter, r *http.Request) {
+	var post Post
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		activityError(w, errors.New("not found"))
+		return
+	}
+	query := db.Where("id = ? AND published = ? AND kind = ?", id, true, KindPost).First(&post)
+	if query.Error != nil {
+		if query.Error == gorm.RecordNotFound {
+			activityError(w, errors.New("not found"))
+			return
+		}
+		activityError(w, query.Error)
+		return
+	}
+	object, err := postObject(post)
+	if err != nil {
+		activityError(w, err)
+		return
+	}
+	object["@context"] = activityStreams
+	writeActivity(w, activityContentType, object)
+}
+
+// ReceiveActivity is the inbox of the author "id". Accepts activities signed by their actor: Follow and Undo of it,
+// Create, Update and Delete of public replies to posts, which become comments, and Delete of actors, who then
+// stop following. Answers 202 Accepted, also to activities which are ignored.
+func ReceiveActivity(w http.ResponseWriter, r *http.Request) {
+	user, key, err := requestedActor(r)
+	if err != nil {
+		activityError(w, err)
+		return
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxActivityBody))
+	if err != nil {
+		rend.JSON(w, http.StatusBadRequest, BadRequest("invalid_activity", "The activity could not be read."))
+		return
+	}
+	sender, err := verifyRequest(r, body, key)
+	if err != nil {
+		log.Println("activitypub inbox: ", err)
+		rend.JSON(w, http.StatusUnauthorized, Unauthorized())
+		return
+	}
+	var received activity
+	if err := json.Unmarshal(body, &received); err != nil {
+		rend.JSON(w, http.StatusBadRequest, BadRequest("invalid_activity", "The activity is not valid JSON."))
+		return
+	}
+	if received.Actor != sender.ID {
+		rend.JSON(w, http.StatusForbidden, Forbidden())
+		return
+	}
+
+	switch received.Type {
+	case "Follow":
+		err = receiveFollow(user, key, sender, received, body)
+	case "Undo":
+		var undone activity
+		json.Unmarshal(received.Object, &undone)
+		if undone.Type == "Follow" {
+			err = db.Where("author = ? AND actor = ?", user.ID, sender.ID).Delete(Follower{}).Error
+		}
+	case "Create", "Update":
+		err = receiveReply(r, sender, received)
+	case "Delete":
+		id := objectID(received.Object)
+		if id == sender.ID {
+			err = db.Where("actor = ?", sender.ID).Delete(Follower{}).Error
+		} else if id != "" {
+			// Actors of the same server share its origin, so the comment has to be by the sender itself.
+			err = db.Where("remote = ? AND actor = ?", id, sender.ID).Delete(Comment{}).Error
+		}
+	}
+	if err != nil {
+		activityError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// receiveFollow makes sender a follower of user and accepts the Follow activity follow, whose JSON is body.
+func receiveFollow(user User, key ActorKey, sender remoteActor, follow activity, body []byte) error {
+	if objectID(follow.Object) != actorID(user.ID) {
+		return nil
+	}
+	if sender.SharedInbox() == "" || !validHTTPURL(sender.SharedInbox()) {
+		return nil
+	}
+	var follower Follower
+	query := db.Where("author = ? AND actor = ?", user.ID, sender.ID).First(&follower)
+	if query.Error != nil && query.Error != gorm.RecordNotFound {
+		return query.Error
+	}
+	follower.Author = user.ID
+	follower.Actor = sender.ID
+	follower.Name = sender.DisplayName()
+	follower.URL = sender.Profile()
+	follower.Inbox = sender.SharedInbox()
+	follower.Follow = follow.ID
+	follower.Date = time.Now().Unix()
+	if err := db.Save(&follower).Error; err != nil {
+		return err
+	}
+	accept := newActivity("Accept", user.ID, json.RawMessage(body))
+	accept["to"] = []string{sender.ID}
+	delete(accept, "cc")
+	go func() {
+		if err := deliver(sender.Inbox, accept, key); err != nil {
+			log.Println("activitypub accept: ", err)
+		}
+	}()
+	return nil
+}
+
+// receiveReply saves the object of received, a Create or Update by sender, as a comment if it is a public reply
+// to a post attributed to sender. Comments await approval, also when an Update changes one received before.
+func receiveReply(r *http.Request, sender remoteActor, received activity) error {
+	var object activity
+	if err := json.Unmarshal(received.Object, &object); err != nil || object.ID == "" {
+		return nil
+	}
+	if !sameOrigin(object.ID, sender.ID) || !object.Public() {
+		return nil
+	}
+	if len(object.AttributedTo) == 0 || object.AttributedTo[0] != sender.ID {
+		return nil
+	}
+	content := strings.Join(strings.Fields(activityText(object.Content)), " ")
+	if content == "" {
+		return nil
+	}
+
+	var comment Comment
+	query := db.Where("remote = ?", object.ID).First(&comment)
+	if query.Error != nil && query.Error != gorm.RecordNotFound {
+		return query.Error
+	}
+	if query.Error == nil {
+		if comment.Actor != sender.ID {
+			return nil
+		}
+		return db.Model(&comment).UpdateColumns(map[string]interface{}{"content": content, "approved": false}).Error
+	}
+	if received.Type != "Create" {
+		return nil
+	}
+	post, err := repliedPost(r, objectID(object.InReplyTo))
+	if err != nil {
+		return nil
+	}
+	_, err = Comment{
+		Post:    post.ID,
+		Author:  sender.DisplayName(),
+		URL:     sender.Profile(),
+		Content: content,
+		Date:    time.Now().Unix(),
+		Remote:  object.ID,
+		Actor:   sender.ID,
+	}.Insert()
+	return err
+}
+
+// repliedPost returns the published post which id, its ActivityPub ID or its URL, refers to.
+func repliedPost(r *http.Request, id string) (Post, error) {
+	var post Post
+	prefix := urlHost() + "/activitypub/posts/"
+	if strings.HasPrefix(id, prefix) {
+		n, err := strconv.ParseInt(strings.TrimPrefix(id, prefix), 10, 64)
+		if err != nil {
+			return post, errors.New("not found")
+		}
+		if err := db.Where("id = ?", n).First(&post).Error; err != nil {
+			return post, errors.New("not found")
+		}
+	} else if id != "" && sameOrigin(id, urlHost()) {
+		var err error
+		if post, err = postAtURL(r, id); err != nil {
+			return post, err
+		}
+	} else {
+		return post, errors.New("not found")
+	}
+	if !post.Published {
+		return post, errors.New("not found")
+	}
+	return post, nil
+}
+
+// activityText returns the text of the HTML content of an object, as comments are plain text.
+// Paragraphs and line breaks are kept apart by spaces.
+func activityText(content string) string {
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return ""
+	}
+	var text bytes.Buffer
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			text.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+		if n.Type == html.ElementNode && (n.DataAtom == atom.P || n.DataAtom == atom.Br || n.DataAtom == atom.Div || n.DataAtom == atom.Li) {
+			text.WriteString(" ")
+		}
+	}
+	walk(doc)
+	return text.String()
+}
+
+// followerError writes the response for an error returned by follower functions.
+func followerError(w http.ResponseWriter, err error) {
+	switch err.Error() {
+	case "not found":
+		rend.JSON(w, http.StatusNotFound, NotFound())
+	case "invalid id":
+		rend.JSON(w, http.StatusBadRequest, BadRequest("invalid_id", "The follower ID could not be parsed from the request URL."))
+	default:
+		log.Println("followers: ", err)
+		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
+	}
+}
+
+// ReadFollowers is a route which lists the fediverse followers of the current user, newest first.
+// Requires session cookie.
+// JSON request returns the followers, frontend call displays them with the handle of the user.
+func ReadFollowers(w http.ResponseWriter, r *http.Request) {
+	var user User
+	user, err := user.Session(r)
+	if err != nil {
+		rend.JSON(w, http.StatusUnauthorized, Unauthorized())
+		return
+	}
+	followers := make([]Follower, 0)
+	query := db.Order("id desc").Where("author = ?", user.ID).Find(&followers)
+	if query.Error != nil && query.Error != gorm.RecordNotFound {
+		followerError(w, query.Error)
+		return
+	}
+	switch root(r) {
+	case "api":
+		rend.JSON(w, http.StatusOK, followers)
+		return
+	case "user":
+		names, err := actorNames()
+		if err != nil {
+			followerError(w, err)
+			return
+		}
+		data := map[string]interface{}{"Followers": followers, "Handle": "@" + names[user.ID] + "@" + actorHost()}
+		rend.HTML(w, http.StatusOK, "user/followers", data)
+		return
+	}
+}
+
+// RemoveFollower is a route which removes the follower "id" of the current user and tells their server,
+// which rejects their Follow. Requires session cookie.
+// JSON request returns `HTTP 200 {"success": "Follower removed"}`, frontend call redirects back to the followers page.
+func RemoveFollower(w http.ResponseWriter, r *http.Request) {
+	var user User
+	user, err := user.Session(r)
+	if err != nil {
+		rend.JSON(w, http.StatusUnauthorized, Unauthorized())
+		return
+	}
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		followerError(w, errors.New("invalid id"))
+		return
+	}
+	var follower Follower
+	query := db.Where("id = ? AND author = ?", id, user.ID).First(&follower)
+	if query.Error != nil {
+		if query.Error == gorm.RecordNotFound {
+			followerError(w, errors.New("not found"))
+			return
+		}
+		followerError(w, query.Error)
+		return
+	}
+	if err := db.Delete(&follower).Error; err != nil {
+		followerError(w, err)
+		return
+	}
+	go func() {
+		key, err := actorKey(user.ID)
+		if err != nil {
+			log.Println("remove follower: ", err)
+			return
+		}
+		follow := map[string]interface{}{"id": follower.Follow, "type": "Follow", "actor": follower.Actor, "object": actorID(user.ID)}
+		reject := newActivity("Reject", user.ID, follow)
+		reject["to"] = []string{follower.Actor}
+		delete(reject, "cc")
+		if err := deliver(follower.Inbox, reject, key); err != nil {
+			log.Println("remove follower: ", err)
+		}
+	}()
+	switch root(r) {
+	case "api":
+		rend.JSON(w, http.StatusOK, map[string]interface{}{"success": "Follower removed"})
+		return
+	case "user":
+		http.Redirect(w, r, "/user/followers", http.StatusFound)
+		return
+	}
+}
@@ -0,0 +1,129 @@
+// Api.go contains what is shared by the routes of the JSON API: the error every failed request
+// responds with, and the headers which tell clients of the unversioned routes under /api/ to move
+// to the ones under /api/v1/.
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/mholt/binding"
+)
+
+// APIError is the body of every error response of the JSON API. Message describes the error
+// to humans and Code names it for programs. Codes never change once published.
+// Requests with invalid fields list them in Fields.
+type APIError struct {
+	Message string       `json:"error"`
+	Code    string       `json:"code"`
+	Fields  []FieldError `json:"fields,omitempty"`
+}
+
+// FieldError tells what is wrong with a single field of a request.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// Codes of APIError shared by many routes. Errors of a single route have codes of their own,
+// such as "email_taken".
+const (
+	ErrValidation           = "validation_failed"
+	ErrUnauthorized         = "unauthorized"
+	ErrForbidden            = "forbidden"
+	ErrNotFound             = "not_found"
+	ErrUnsupportedMediaType = "unsupported_media_type"
+	ErrInternal             = "internal_error"
+)
+
+func (e APIError) Error() string {
+	return e.Message
+}
+
+// NotFound is a shorthand JSON response for HTTP 404 errors.
+func NotFound() APIError {
+	return APIError{Message: http.StatusText(http.StatusNotFound), Code: ErrNotFound}
+}
+
+// Unauthorized is a shorthand JSON response for HTTP 401 errors.
+func Unauthorized() APIError {
+	return APIError{Message: "Unauthorized", Code: ErrUnauthorized}
+}
+
+// Forbidden is a shorthand JSON response for HTTP 403 errors.
+func Forbidden() APIError {
+	return APIError{Message: "Forbidden", Code: ErrForbidden}
+}
+
+// InternalServerError is a shorthand JSON response for HTTP 500 errors.
+// The error itself is logged, never shown to the client.
+func InternalServerError() APIError {
+	return APIError{Message: "Internal server error", Code: ErrInternal}
+}
+
+// BadRequest is a shorthand JSON response for HTTP 400 errors with the given code and message.
+func BadRequest(code, message string) APIError {
+	return APIError{Message: message, Code: code}
+}
+
+// ValidationError is the JSON response for a request which could not be bound, with a FieldError
+// for every field binding complained about.
+func ValidationError(errs binding.Errors) APIError {
+	apierr := APIError{Message: "The request has invalid fields.", Code: ErrValidation}
+	for _, err := range errs {
+		// Errors of the whole request, such as a body which is not JSON, name no fields.
+		if len(err.FieldNames) == 0 {
+			apierr.Message = err.Message
+			continue
+		}
+		for _, field := range err.FieldNames {
+			apierr.Fields = append(apierr.Fields, FieldError{Field: field, Code: fieldErrorCode(err.Classification), Message: err.Message})
+		}
+	}
+	return apierr
+}
+
+// fieldErrorCode returns the code of FieldError for a classification of binding.Error.
+func fieldErrorCode(classification string) string {
+	switch classification {
+	case binding.RequiredError:
+		return "required"
+	case binding.TypeError:
+		return "invalid_type"
+	case binding.DeserializationError:
+		return "invalid_body"
+	case binding.ContentTypeError:
+		return ErrUnsupportedMediaType
+	}
+	return strings.ToLower(classification)
+}
+
+// apiVersion returns "v1" for requests to /api/v1/ and an empty string for the unversioned routes.
+func apiVersion(r *http.Request) string {
+	if r.URL.Path == "/api/v1" || strings.HasPrefix(r.URL.Path, "/api/v1/") {
+		return "v1"
+	}
+	return ""
+}
+
+// apiPostURL returns the URL of the post with slug in the API version of the request.
+func apiPostURL(r *http.Request, slug string) string {
+	if apiVersion(r) == "v1" {
+		return "/api/v1/posts/" + slug
+	}
+	return "/api/post/" + slug
+}
+
+// DeprecatedAPI marks responses of the unversioned routes under /api/ deprecated, pointing clients
+// to /api/v1/ which replaces them. The routes keep working as they did. The index page and
+// the OpenAPI document describe every version, and the GraphQL endpoint has no versions, so they are not deprecated.
+func DeprecatedAPI(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/api/") && apiVersion(r) == "" && r.URL.Path != "/api/" && r.URL.Path != "/api/openapi.json" && r.URL.Path != "/api/graphql" {
+			w.Header().Set("Deprecation", "true")
+			w.Header().Set("Link", `</api/v1/>; rel="successor-version"`)
+		}
+		h.ServeHTTP(w, r)
+	})
+}
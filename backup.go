@@ -0,0 +1,595 @@
+// Backup.go contains full backups of blog content. A backup is a zip archive with the
+// following files:
+//
+//	manifest.json     format version, creation time and record counts
+//	users.json        users including their password digests
+//	posts.json        posts, tags are stored on each post
+//	comments.json     comments of all posts, since version 2
+//	redirects.json    old slugs of renamed posts, since version 3
+//	series.json       series of posts, since version 4
+//	webhooks.json     webhooks including their secrets, since version 5
+//	webmentions.json  received webmentions, since version 6
+//	actorkeys.json    ActivityPub keys of authors including private keys, since version 7
+//	followers.json    ActivityPub followers of authors, since version 7
+//	settings.json     settings without CookieHash
+//	uploads/...       every file in the uploads directory
+//
+// Records are stored as JSON instead of SQL, so a backup taken from one database driver can be
+// restored into any other.
+//
+// Webhook deliveries are a log of past requests and are not backed up. Neither are access tokens,
+// so that no token survives a restore, nor posts deleted with Micropub, which are kept for undelete and
+// could collide with the restored posts. Restoring clears all three, and tokens have to be created again.
+//
+// Restoring replaces the contents of the uploads directory with the uploads of the backup, so files
+// uploaded after the backup was taken are removed along with the posts which linked to them.
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// BackupVersion is the format version of archives written by WriteBackup.
+// RestoreBackup refuses archives with a newer version.
+const BackupVersion = 7
+
+// BackupManifest describes the contents of a backup archive.
+type BackupManifest struct {
+	Version int            `json:"version"`
+	Created int64          `json:"created"`
+	Driver  string         `json:"driver"`
+	Counts  map[string]int `json:"counts"`
+}
+
+// backupUser is User with the fields which are hidden from the JSON API.
+type backupUser struct {
+	User
+	Digest   []byte `json:"digest"`
+	Password string `json:"-"`
+	Posts    []Post `json:"-"`
+}
+
+// backupPost is Post with the fields which are hidden from the JSON API.
+type backupPost struct {
+	Post
+	Published bool `json:"published"`
+}
+
+// backupComment is Comment with the fields which are hidden from the JSON API.
+type backupComment struct {
+	Comment
+	Email    string `json:"email"`
+	Approved bool   `json:"approved"`
+}
+
+// backupActorKey is ActorKey with the fields which are hidden from the JSON API.
+type backupActorKey struct {
+	ActorKey
+	PrivateKey string `json:"private_key"`
+}
+
+// backupFollower is Follower with the fields which are hidden from the JSON API.
+type backupFollower struct {
+	Follower
+	Inbox  string `json:"inbox"`
+	Follow string `json:"follow"`
+}
+
+// backupRecords are the database records of a backup archive.
+type backupRecords struct {
+	Users       []backupUser
+	Posts       []backupPost
+	Comments    []backupComment
+	Redirects   []Redirect
+	Series      []Series
+	Webhooks    []Webhook
+	Webmentions []Webmention
+	ActorKeys   []backupActorKey
+	Followers   []backupFollower
+}
+
+// backupSettings returns current settings without the values generated by the application.
+func backupSettings() Vertigo {
+	settings := *Settings
+	settings.CookieHash = ""
+	settings.Firstrun = false
+	return settings
+}
+
+// WriteBackup writes a backup archive of the whole blog to w.
+func WriteBackup(w io.Writer) (BackupManifest, error) {
+	manifest := BackupManifest{Version: BackupVersion, Created: time.Now().Unix(), Driver: Config.Driver, Counts: make(map[string]int)}
+	archive := zip.NewWriter(w)
+
+	var users []User
+	if err := db.Order("id").Find(&users).Error; err != nil && err != gorm.RecordNotFound {
+		return manifest, err
+	}
+	records := make([]backupUser, 0, len(users))
+	for _, user := range users {
+		records = append(records, backupUser{User: user, Digest: user.Digest})
+	}
+	if err := writeBackupJSON(archive, "users.json", records); err != nil {
+		return manifest, err
+	}
+	manifest.Counts["users"] = len(records)
+
+	var posts []Post
+	if err := db.Order("id").Find(&posts).Error; err != nil && err != gorm.RecordNotFound {
+		return manifest, err
+	}
+	postRecords := make([]backupPost, 0, len(posts))
+	for _, post := range posts {
+		postRecords = append(postRecords, backupPost{Post: post, Published: post.Published})
+	}
+	if err := writeBackupJSON(archive, "posts.json", postRecords); err != nil {
+		return manifest, err
+	}
+	manifest.Counts["posts"] = len(postRecords)
+
+	var comments []Comment
+	if err := db.Order("id").Find(&comments).Error; err != nil && err != gorm.RecordNotFound {
+		return manifest, err
+	}
+	commentRecords := make([]backupComment, 0, len(comments))
+	for _, comment := range comments {
+		commentRecords = append(commentRecords, backupComment{Comment: comment, Email: comment.Email, Approved: comment.Approved})
+	}
+	if err := writeBackupJSON(archive, "comments.json", commentRecords); err != nil {
+		return manifest, err
+	}
+	manifest.Counts["comments"] = len(commentRecords)
+
+	redirects := make([]Redirect, 0)
+	if err := db.Order("id").Find(&redirects).Error; err != nil && err != gorm.RecordNotFound {
+		return manifest, err
+	}
+	if err := writeBackupJSON(archive, "redirects.json", redirects); err != nil {
+		return manifest, err
+	}
+	manifest.Counts["redirects"] = len(redirects)
+
+	series := make([]Series, 0)
+	if err := db.Order("id").Find(&series).Error; err != nil && err != gorm.RecordNotFound {
+		return manifest, err
+	}
+	if err := writeBackupJSON(archive, "series.json", series); err != nil {
+		return manifest, err
+	}
+	manifest.Counts["series"] = len(series)
+
+	webhooks := make([]Webhook, 0)
+	if err := db.Order("id").Find(&webhooks).Error; err != nil && err != gorm.RecordNotFound {
+		return manifest, err
+	}
+	if err := writeBackupJSON(archive, "webhooks.json", webhooks); err != nil {
+		return manifest, err
+	}
+	manifest.Counts["webhooks"] = len(webhooks)
+
+	webmentions := make([]Webmention, 0)
+	if err := db.Order("id").Find(&webmentions).Error; err != nil && err != gorm.RecordNotFound {
+		return manifest, err
+	}
+	if err := writeBackupJSON(archive, "webmentions.json", webmentions); err != nil {
+		return manifest, err
+	}
+	manifest.Counts["webmentions"] = len(webmentions)
+
+	var keys []ActorKey
+	if err := db.Order("id").Find(&keys).Error; err != nil && err != gorm.RecordNotFound {
+		return manifest, err
+	}
+	keyRecords := make([]backupActorKey, 0, len(keys))
+	for _, key := range keys {
+		keyRecords = append(keyRecords, backupActorKey{ActorKey: key, PrivateKey: key.PrivateKey})
+	}
+	if err := writeBackupJSON(archive, "actorkeys.json", keyRecords); err != nil {
+		return manifest, err
+	}
+	manifest.Counts["actorkeys"] = len(keyRecords)
+
+	var followers []Follower
+	if err := db.Order("id").Find(&followers).Error; err != nil && err != gorm.RecordNotFound {
+		return manifest, err
+	}
+	followerRecords := make([]backupFollower, 0, len(followers))
+	for _, follower := range followers {
+		followerRecords = append(followerRecords, backupFollower{Follower: follower, Inbox: follower.Inbox, Follow: follower.Follow})
+	}
+	if err := writeBackupJSON(archive, "followers.json", followerRecords); err != nil {
+		return manifest, err
+	}
+	manifest.Counts["followers"] = len(followerRecords)
+
+	if err := writeBackupJSON(archive, "settings.json", backupSettings()); err != nil {
+		return manifest, err
+	}
+
+	files, err := writeBackupUploads(archive)
+	if err != nil {
+		return manifest, err
+	}
+	manifest.Counts["uploads"] = files
+
+	if err := writeBackupJSON(archive, "manifest.json", manifest); err != nil {
+		return manifest, err
+	}
+	return manifest, archive.Close()
+}
+
+func writeBackupJSON(archive *zip.Writer, name string, v interface{}) error {
+	f, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(f).Encode(v)
+}
+
+// writeBackupUploads adds every file of Config.Uploads under uploads/ in the archive.
+func writeBackupUploads(archive *zip.Writer) (int, error) {
+	count := 0
+	err := filepath.Walk(Config.Uploads, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(Config.Uploads, path)
+		if err != nil {
+			return err
+		}
+		src, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		dst, err := archive.Create("uploads/" + filepath.ToSlash(rel))
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(dst, src); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	return count, err
+}
+
+// RestoreBackup replaces all users, posts, settings and uploads with the contents of the
+// backup archive in r. Database changes are made in a single transaction.
+func RestoreBackup(r io.ReaderAt, size int64) (BackupManifest, error) {
+	var manifest BackupManifest
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return manifest, err
+	}
+	files := make(map[string]*zip.File)
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+
+	if err := readBackupJSON(files, "manifest.json", &manifest); err != nil {
+		return manifest, err
+	}
+	if manifest.Version > BackupVersion {
+		return manifest, fmt.Errorf("backup format version %d is newer than the supported version %d", manifest.Version, BackupVersion)
+	}
+	var records backupRecords
+	if err := readBackupJSON(files, "users.json", &records.Users); err != nil {
+		return manifest, err
+	}
+	if err := readBackupJSON(files, "posts.json", &records.Posts); err != nil {
+		return manifest, err
+	}
+	if manifest.Version >= 2 {
+		if err := readBackupJSON(files, "comments.json", &records.Comments); err != nil {
+			return manifest, err
+		}
+	}
+	if manifest.Version >= 3 {
+		if err := readBackupJSON(files, "redirects.json", &records.Redirects); err != nil {
+			return manifest, err
+		}
+	}
+	if manifest.Version >= 4 {
+		if err := readBackupJSON(files, "series.json", &records.Series); err != nil {
+			return manifest, err
+		}
+	}
+	if manifest.Version >= 5 {
+		if err := readBackupJSON(files, "webhooks.json", &records.Webhooks); err != nil {
+			return manifest, err
+		}
+	}
+	if manifest.Version >= 6 {
+		if err := readBackupJSON(files, "webmentions.json", &records.Webmentions); err != nil {
+			return manifest, err
+		}
+	}
+	if manifest.Version >= 7 {
+		if err := readBackupJSON(files, "actorkeys.json", &records.ActorKeys); err != nil {
+			return manifest, err
+		}
+		if err := readBackupJSON(files, "followers.json", &records.Followers); err != nil {
+			return manifest, err
+		}
+	}
+	var settings Vertigo
+	if err := readBackupJSON(files, "settings.json", &settings); err != nil {
+		return manifest, err
+	}
+	uploads := make(map[string]*zip.File)
+	for name, f := range files {
+		if !strings.HasPrefix(name, "uploads/") || strings.HasSuffix(name, "/") {
+			continue
+		}
+		path, err := uploadPath(strings.TrimPrefix(name, "uploads/"))
+		if err != nil {
+			return manifest, err
+		}
+		uploads[path] = f
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		return manifest, tx.Error
+	}
+	if err := restoreRecords(tx, records); err != nil {
+		tx.Rollback()
+		return manifest, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return manifest, err
+	}
+
+	settings.CookieHash = Settings.CookieHash
+	settings.Firstrun = false
+	if err := settings.Save(); err != nil {
+		return manifest, err
+	}
+
+	if err := clearUploads(); err != nil {
+		return manifest, err
+	}
+	for path, f := range uploads {
+		if err := restoreUpload(path, f); err != nil {
+			return manifest, err
+		}
+	}
+	return manifest, nil
+}
+
+func readBackupJSON(files map[string]*zip.File, name string, v interface{}) error {
+	f, exists := files[name]
+	if !exists {
+		return errors.New("backup is missing " + name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err := json.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	return nil
+}
+
+// restoreRecords deletes current users, posts, comments, webmentions, redirects, series, webhooks, actor keys, followers,
+// drafts, preview links, access tokens and deleted posts and inserts the ones from the backup, keeping their original IDs so that post authors, comment posts and series parts still match.
+func restoreRecords(tx *gorm.DB, records backupRecords) error {
+	// Drafts and preview links are not backed up, and those left would point at the wrong posts.
+	if err := tx.Exec("DELETE FROM drafts").Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("DELETE FROM previews").Error; err != nil {
+		return err
+	}
+	// Access tokens are not backed up either, and those left would act for whoever has the owner's ID now.
+	if err := tx.Exec("DELETE FROM access_tokens").Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("DELETE FROM deleted_posts").Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("DELETE FROM webmentions").Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("DELETE FROM followers").Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("DELETE FROM actor_keys").Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("DELETE FROM webhook_deliveries").Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("DELETE FROM webhooks").Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("DELETE FROM redirects").Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("DELETE FROM series").Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("DELETE FROM comments").Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("DELETE FROM posts").Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("DELETE FROM users").Error; err != nil {
+		return err
+	}
+	for _, record := range records.Users {
+		user := record.User
+		user.Digest = record.Digest
+		user.Posts = nil
+		if err := tx.Create(&user).Error; err != nil {
+			return fmt.Errorf("user %d: %v", user.ID, err)
+		}
+	}
+	for _, record := range records.Posts {
+		post := record.Post
+		post.Published = record.Published
+		// Posts of backups taken before posts had a format were Markdown if they have Markdown source.
+		if post.Format == "" {
+			post.Format = FormatHTML
+			if post.Markdown != "" {
+				post.Format = FormatMarkdown
+			}
+		}
+		// Backups taken before pages existed only have posts.
+		if post.Kind == "" {
+			post.Kind = KindPost
+		}
+		// Backups taken before posts had a reading time get it estimated like migration 7 does.
+		if post.ReadingTime == 0 {
+			post.ReadingTime = readingTime(post.Content)
+		}
+		if err := tx.Create(&post).Error; err != nil {
+			return fmt.Errorf("post %d: %v", post.ID, err)
+		}
+	}
+	for _, record := range records.Comments {
+		comment := record.Comment
+		comment.Email = record.Email
+		comment.Approved = record.Approved
+		if err := tx.Create(&comment).Error; err != nil {
+			return fmt.Errorf("comment %d: %v", comment.ID, err)
+		}
+	}
+	for _, redirect := range records.Redirects {
+		if err := tx.Create(&redirect).Error; err != nil {
+			return fmt.Errorf("redirect %s: %v", redirect.Slug, err)
+		}
+	}
+	for _, record := range records.Series {
+		record.Posts = nil
+		if err := tx.Create(&record).Error; err != nil {
+			return fmt.Errorf("series %s: %v", record.Slug, err)
+		}
+	}
+	for _, webhook := range records.Webhooks {
+		if err := tx.Create(&webhook).Error; err != nil {
+			return fmt.Errorf("webhook %d: %v", webhook.ID, err)
+		}
+	}
+	for _, webmention := range records.Webmentions {
+		if err := tx.Create(&webmention).Error; err != nil {
+			return fmt.Errorf("webmention %d: %v", webmention.ID, err)
+		}
+	}
+	for _, record := range records.ActorKeys {
+		key := record.ActorKey
+		key.PrivateKey = record.PrivateKey
+		if err := tx.Create(&key).Error; err != nil {
+			return fmt.Errorf("actor key %d: %v", key.ID, err)
+		}
+	}
+	for _, record := range records.Followers {
+		follower := record.Follower
+		follower.Inbox = record.Inbox
+		follower.Follow = record.Follow
+		if err := tx.Create(&follower).Error; err != nil {
+			return fmt.Errorf("follower %d: %v", follower.ID, err)
+		}
+	}
+	return resetSequences(tx, "users", "posts", "comments", "webmentions", "redirects", "series", "webhooks", "webhook_deliveries", "actor_keys", "followers")
+}
+
+// resetSequences moves PostgreSQL ID sequences past the restored rows.
+// SQLite and MySQL continue from the largest ID on their own.
+func resetSequences(tx *gorm.DB, tables ...string) error {
+	if Config.Driver != "postgres" {
+		return nil
+	}
+	for _, table := range tables {
+		query := tx.Exec("SELECT setval(pg_get_serial_sequence('" + table + "', 'id'), COALESCE((SELECT MAX(id) FROM " + table + "), 0) + 1, false)")
+		if query.Error != nil {
+			return query.Error
+		}
+	}
+	return nil
+}
+
+// uploadPath returns the path in the uploads directory of the upload called name in a backup.
+func uploadPath(name string) (string, error) {
+	path := filepath.Join(Config.Uploads, filepath.FromSlash(name))
+	// Refuse names such as "../../etc/passwd" which would escape the uploads directory.
+	if !strings.HasPrefix(path, filepath.Clean(Config.Uploads)+string(filepath.Separator)) {
+		return "", errors.New("backup contains an invalid upload path " + name)
+	}
+	return path, nil
+}
+
+// clearUploads removes everything in the uploads directory, but keeps the directory itself,
+// which may be a mount point.
+func clearUploads() error {
+	entries, err := ioutil.ReadDir(Config.Uploads)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(Config.Uploads, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreUpload writes a single file from the archive to path in Config.Uploads.
+func restoreUpload(path string, f *zip.File) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	src, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+// ReadBackup is a route which downloads a backup archive of the whole blog.
+// Only available to admins.
+func ReadBackup(w http.ResponseWriter, r *http.Request) {
+	filename := "vertigo-backup-" + time.Now().Format("20060102-150405") + ".zip"
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	// The archive is streamed, so an error halfway through can only be logged.
+	if _, err := WriteBackup(w); err != nil {
+		log.Println("readbackup: ", err)
+	}
+}
@@ -0,0 +1,815 @@
+// Cli.go contains the administrative subcommands of the vertigo binary. They operate directly
+// on the database and the settings file, so they work even when nobody can log in anymore.
+//
+//	vertigo user create|list|reset-password|set-role
+//	vertigo post list|publish|unpublish|delete
+//	vertigo settings get|set
+//	vertigo migrate up|down|status
+//	vertigo backup|restore
+//	vertigo import wordpress|markdown
+//	vertigo export markdown
+//
+// Every subcommand accepts -json for machine readable output. Exit code is 0 on success,
+// 1 when the command failed and 2 when it was used incorrectly.
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Exit codes returned by Command.
+const (
+	ExitOK    = 0
+	ExitError = 1
+	ExitUsage = 2
+)
+
+// commandDeliveryTimeout is how long runCommand waits for deliveries to webhooks and followers before exiting.
+// Deliveries which are retried after failing take longer, and are given up.
+const commandDeliveryTimeout = 15 * time.Second
+
+// Subcommand is a single administrative action, such as `user create`.
+// Commands without a second word, such as `backup`, are stored under the empty name.
+// Run receives the arguments left after flag parsing.
+type Subcommand struct {
+	Usage string
+	Flags func(fs *flag.FlagSet)
+	Run   func(c *Cli, args []string) error
+}
+
+// Cli holds the state of a single command invocation.
+type Cli struct {
+	Stdout io.Writer
+	Stderr io.Writer
+	JSON   bool
+	fs     *flag.FlagSet
+}
+
+// errUsage is returned by subcommands which were called with missing or malformed arguments.
+var errUsage = errors.New("usage")
+
+var commands = map[string]map[string]Subcommand{
+	"user": {
+		"create": {
+			Usage: "user create -email EMAIL [-name NAME] [-password PASSWORD] [-role admin|author]",
+			Flags: func(fs *flag.FlagSet) {
+				fs.String("email", "", "email address of the new user")
+				fs.String("name", "", "display name of the new user")
+				fs.String("password", "", "password of the new user, generated if left empty")
+				fs.String("role", "", "role of the new user, by default admin for the first user and author for others")
+			},
+			Run: cmdUserCreate,
+		},
+		"list": {
+			Usage: "user list",
+			Run:   cmdUserList,
+		},
+		"reset-password": {
+			Usage: "user reset-password -email EMAIL [-password PASSWORD]",
+			Flags: func(fs *flag.FlagSet) {
+				fs.String("email", "", "email address of the user")
+				fs.String("password", "", "new password, generated if left empty")
+			},
+			Run: cmdUserResetPassword,
+		},
+		"set-role": {
+			Usage: "user set-role -email EMAIL -role admin|author",
+			Flags: func(fs *flag.FlagSet) {
+				fs.String("email", "", "email address of the user")
+				fs.String("role", "", "new role of the user")
+			},
+			Run: cmdUserSetRole,
+		},
+	},
+	"post": {
+		"list": {
+			Usage: "post list",
+			Run:   cmdPostList,
+		},
+		"publish": {
+			Usage: "post publish SLUG",
+			Run:   cmdPostPublish(true),
+		},
+		"unpublish": {
+			Usage: "post unpublish SLUG",
+			Run:   cmdPostPublish(false),
+		},
+		"delete": {
+			Usage: "post delete SLUG",
+			Run:   cmdPostDelete,
+		},
+	},
+	"migrate": {
+		"up": {
+			Usage: "migrate up [-to VERSION]",
+			Flags: func(fs *flag.FlagSet) {
+				fs.Int64("to", 0, "apply migrations up to and including this version, by default all")
+			},
+			Run: cmdMigrateUp,
+		},
+		"down": {
+			Usage: "migrate down [-steps N]",
+			Flags: func(fs *flag.FlagSet) {
+				fs.Int("steps", 1, "number of migrations to roll back")
+			},
+			Run: cmdMigrateDown,
+		},
+		"status": {
+			Usage: "migrate status",
+			Run:   cmdMigrateStatus,
+		},
+	},
+	"backup": {
+		"": {
+			Usage: "backup [FILE]",
+			Run:   cmdBackup,
+		},
+	},
+	"restore": {
+		"": {
+			Usage: "restore FILE",
+			Run:   cmdRestore,
+		},
+	},
+	"import": {
+		"wordpress": {
+			Usage: "import wordpress [-author EMAIL] FILE",
+			Flags: func(fs *flag.FlagSet) {
+				fs.String("author", "", "email of the user to assign posts of unknown authors to, by default the first admin")
+			},
+			Run: cmdImportWordPress,
+		},
+		"markdown": {
+			Usage: "import markdown [-sync] [-author EMAIL] DIR",
+			Flags: func(fs *flag.FlagSet) {
+				fs.Bool("sync", false, "update posts which already exist with the same slug instead of skipping them")
+				fs.String("author", "", "email of the user to assign posts of unknown authors to, by default the first admin")
+			},
+			Run: cmdImportMarkdown,
+		},
+	},
+	"export": {
+		"markdown": {
+			Usage: "export markdown DIR",
+			Run:   cmdExportMarkdown,
+		},
+	},
+	"settings": {
+		"get": {
+			Usage: "settings get [KEY]",
+			Run:   cmdSettingsGet,
+		},
+		"set": {
+			Usage: "settings set KEY VALUE",
+			Run:   cmdSettingsSet,
+		},
+	},
+}
+
+// Command runs the subcommand described by args, for example []string{"user", "list"},
+// and returns the exit code the process should exit with.
+func Command(args []string, stdout, stderr io.Writer) int {
+	if len(args) < 1 {
+		usage(stderr)
+		return ExitUsage
+	}
+	group, exists := commands[args[0]]
+	if !exists {
+		fmt.Fprintf(stderr, "vertigo: unknown command %q\n", args[0])
+		usage(stderr)
+		return ExitUsage
+	}
+	name, rest := "", args[1:]
+	if len(args) > 1 {
+		if _, exists := group[args[1]]; exists {
+			name, rest = args[1], args[2:]
+		}
+	}
+	sub, exists := group[name]
+	if !exists {
+		if len(args) > 1 {
+			fmt.Fprintf(stderr, "vertigo: unknown command %q\n", args[0]+" "+args[1])
+		}
+		usage(stderr)
+		return ExitUsage
+	}
+
+	c := &Cli{Stdout: stdout, Stderr: stderr}
+	c.fs = flag.NewFlagSet(strings.TrimSpace(args[0]+" "+name), flag.ContinueOnError)
+	c.fs.SetOutput(stderr)
+	c.fs.BoolVar(&c.JSON, "json", false, "print output as JSON")
+	if sub.Flags != nil {
+		sub.Flags(c.fs)
+	}
+	if err := c.fs.Parse(flagsFirst(c.fs, rest)); err != nil {
+		return ExitUsage
+	}
+
+	// Every command but migrate itself expects the schema to be up to date.
+	if args[0] != "migrate" {
+		if _, err := MigrateUp(0); err != nil {
+			fmt.Fprintln(stderr, "vertigo:", err)
+			return ExitError
+		}
+	}
+
+	err := sub.Run(c, c.fs.Args())
+	if err == errUsage {
+		fmt.Fprintln(stderr, "usage: vertigo "+sub.Usage)
+		return ExitUsage
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "vertigo:", err)
+		return ExitError
+	}
+	return ExitOK
+}
+
+// flagsFirst moves the flags of args ahead of the positional arguments, since flag.Parse stops at
+// the first positional one. This lets flags follow arguments, as in `post publish SLUG -json`.
+// Everything after "--" is left positional.
+func flagsFirst(fs *flag.FlagSet, args []string) []string {
+	var flags, positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			positional = append(positional, args[i+1:]...)
+			break
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			positional = append(positional, arg)
+			continue
+		}
+		flags = append(flags, arg)
+		name := strings.TrimLeft(arg, "-")
+		if strings.Contains(name, "=") {
+			continue
+		}
+		// Flags other than booleans take the next argument as their value.
+		if f := fs.Lookup(name); f != nil && i+1 < len(args) {
+			if b, ok := f.Value.(interface {
+				IsBoolFlag() bool
+			}); !ok || !b.IsBoolFlag() {
+				i++
+				flags = append(flags, args[i])
+			}
+		}
+	}
+	return append(flags, positional...)
+}
+
+func usage(w io.Writer) {
+	var lines []string
+	for _, group := range commands {
+		for _, sub := range group {
+			lines = append(lines, "  vertigo "+sub.Usage)
+		}
+	}
+	sort.Strings(lines)
+	fmt.Fprintln(w, "usage:")
+	fmt.Fprintln(w, strings.Join(lines, "\n"))
+}
+
+// flag returns value of string flag name of the current subcommand.
+func (c *Cli) flag(name string) string {
+	return c.fs.Lookup(name).Value.String()
+}
+
+// print writes v as JSON if -json was given, otherwise it calls text to write a human readable version.
+func (c *Cli) print(v interface{}, text func(w io.Writer)) error {
+	if c.JSON {
+		enc := json.NewEncoder(c.Stdout)
+		return enc.Encode(v)
+	}
+	tw := tabwriter.NewWriter(c.Stdout, 0, 8, 2, ' ', 0)
+	text(tw)
+	return tw.Flush()
+}
+
+// randomPassword generates a password for users created or reset without one.
+func randomPassword() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func validRole(role string) bool {
+	return role == RoleAdmin || role == RoleAuthor
+}
+
+func cmdUserCreate(c *Cli, args []string) error {
+	var user User
+	user.Email = c.flag("email")
+	user.Name = c.flag("name")
+	user.Password = c.flag("password")
+	user.Role = c.flag("role")
+	if user.Email == "" || len(args) > 0 {
+		return errUsage
+	}
+	if user.Role != "" && !validRole(user.Role) {
+		return fmt.Errorf("unknown role %q", user.Role)
+	}
+	generated := user.Password == ""
+	if generated {
+		password, err := randomPassword()
+		if err != nil {
+			return err
+		}
+		user.Password = password
+	}
+	created, err := user.Insert(nil)
+	if err != nil {
+		return err
+	}
+	result := map[string]interface{}{"id": created.ID, "email": created.Email, "name": created.Name, "role": created.Role}
+	if generated {
+		result["password"] = user.Password
+	}
+	return c.print(result, func(w io.Writer) {
+		fmt.Fprintf(w, "created user %d <%s> as %s\n", created.ID, created.Email, created.Role)
+		if generated {
+			fmt.Fprintf(w, "password: %s\n", user.Password)
+		}
+	})
+}
+
+func cmdUserList(c *Cli, args []string) error {
+	var users []User
+	query := db.Order("id").Find(&users)
+	if query.Error != nil {
+		return query.Error
+	}
+	if users == nil {
+		users = make([]User, 0)
+	}
+	list := make([]map[string]interface{}, 0, len(users))
+	for _, user := range users {
+		list = append(list, map[string]interface{}{"id": user.ID, "email": user.Email, "name": user.Name, "role": user.Role})
+	}
+	return c.print(list, func(w io.Writer) {
+		fmt.Fprintln(w, "ID\tEMAIL\tNAME\tROLE")
+		for _, user := range users {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", user.ID, user.Email, user.Name, user.Role)
+		}
+	})
+}
+
+func cmdUserResetPassword(c *Cli, args []string) error {
+	var user User
+	user.Email = c.flag("email")
+	password := c.flag("password")
+	if user.Email == "" || len(args) > 0 {
+		return errUsage
+	}
+	user, err := user.GetByEmail()
+	if err != nil {
+		return err
+	}
+	generated := password == ""
+	if generated {
+		password, err = randomPassword()
+		if err != nil {
+			return err
+		}
+	}
+	digest, err := GenerateHash(password)
+	if err != nil {
+		return err
+	}
+	// Updates with a map writes empty values too, so the pending recovery link is cleared.
+	query := db.Model(&user).Updates(map[string]interface{}{"digest": digest, "recovery": ""})
+	if query.Error != nil {
+		return query.Error
+	}
+	result := map[string]interface{}{"id": user.ID, "email": user.Email}
+	if generated {
+		result["password"] = password
+	}
+	return c.print(result, func(w io.Writer) {
+		fmt.Fprintf(w, "password of %s was reset\n", user.Email)
+		if generated {
+			fmt.Fprintf(w, "password: %s\n", password)
+		}
+	})
+}
+
+func cmdUserSetRole(c *Cli, args []string) error {
+	var user User
+	user.Email = c.flag("email")
+	role := c.flag("role")
+	if user.Email == "" || role == "" || len(args) > 0 {
+		return errUsage
+	}
+	if !validRole(role) {
+		return fmt.Errorf("unknown role %q", role)
+	}
+	user, err := user.GetByEmail()
+	if err != nil {
+		return err
+	}
+	query := db.Model(&user).Update("role", role)
+	if query.Error != nil {
+		return query.Error
+	}
+	return c.print(map[string]interface{}{"id": user.ID, "email": user.Email, "role": role}, func(w io.Writer) {
+		fmt.Fprintf(w, "%s is now %s\n", user.Email, role)
+	})
+}
+
+func cmdPostList(c *Cli, args []string) error {
+	var post Post
+	posts, err := post.GetAll(nil)
+	if err != nil {
+		return err
+	}
+	list := make([]map[string]interface{}, 0, len(posts))
+	for _, post := range posts {
+		list = append(list, map[string]interface{}{"id": post.ID, "slug": post.Slug, "title": post.Title, "author": post.Author, "date": post.Date, "published": post.Published})
+	}
+	return c.print(list, func(w io.Writer) {
+		fmt.Fprintln(w, "ID\tSLUG\tTITLE\tAUTHOR\tPUBLISHED")
+		for _, post := range posts {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%t\n", post.ID, post.Slug, post.Title, post.Author, post.Published)
+		}
+	})
+}
+
+// getPostArg returns the post whose slug is the only positional argument.
+func getPostArg(args []string) (Post, error) {
+	var post Post
+	if len(args) != 1 {
+		return post, errUsage
+	}
+	post.Slug = args[0]
+	post, err := post.Get(nil)
+	if err != nil {
+		return post, fmt.Errorf("post %q: %v", args[0], err)
+	}
+	return post, nil
+}
+
+func cmdPostPublish(published bool) func(c *Cli, args []string) error {
+	return func(c *Cli, args []string) error {
+		post, err := getPostArg(args)
+		if err != nil {
+			return err
+		}
+		query := db.Model(&post).Update("published", published)
+		if query.Error != nil {
+			return query.Error
+		}
+		return c.print(map[string]interface{}{"slug": post.Slug, "published": published}, func(w io.Writer) {
+			if published {
+				fmt.Fprintf(w, "published %s\n", post.Slug)
+				return
+			}
+			fmt.Fprintf(w, "unpublished %s\n", post.Slug)
+		})
+	}
+}
+
+func cmdPostDelete(c *Cli, args []string) error {
+	post, err := getPostArg(args)
+	if err != nil {
+		return err
+	}
+	if err := deletePost(post); err != nil {
+		return err
+	}
+	return c.print(map[string]interface{}{"slug": post.Slug, "deleted": true}, func(w io.Writer) {
+		fmt.Fprintf(w, "deleted %s\n", post.Slug)
+	})
+}
+
+// settingsMap returns the current settings as a generic map keyed by JSON field names.
+// CookieHash is never part of the map.
+func settingsMap() (map[string]interface{}, error) {
+	var m map[string]interface{}
+	data, err := json.Marshal(Settings)
+	if err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, err
+	}
+	delete(m, "cookiehash")
+	return m, nil
+}
+
+// lookupSetting walks m according to a dotted key such as "mailgun.mgdomain".
+// It returns the map holding the last key segment.
+func lookupSetting(m map[string]interface{}, key string) (map[string]interface{}, string, error) {
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := m[part].(map[string]interface{})
+		if !ok {
+			return nil, "", fmt.Errorf("unknown setting %q", key)
+		}
+		m = next
+	}
+	last := parts[len(parts)-1]
+	if _, exists := m[last]; !exists {
+		return nil, "", fmt.Errorf("unknown setting %q", key)
+	}
+	return m, last, nil
+}
+
+func cmdSettingsGet(c *Cli, args []string) error {
+	if len(args) > 1 {
+		return errUsage
+	}
+	m, err := settingsMap()
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return c.print(m, func(w io.Writer) {
+			printSettings(w, "", m)
+		})
+	}
+	parent, key, err := lookupSetting(m, args[0])
+	if err != nil {
+		return err
+	}
+	value := parent[key]
+	return c.print(value, func(w io.Writer) {
+		fmt.Fprintln(w, value)
+	})
+}
+
+func printSettings(w io.Writer, prefix string, m map[string]interface{}) {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if nested, ok := m[key].(map[string]interface{}); ok {
+			printSettings(w, prefix+key+".", nested)
+			continue
+		}
+		fmt.Fprintf(w, "%s%s\t%v\n", prefix, key, m[key])
+	}
+}
+
+func cmdSettingsSet(c *Cli, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	if args[0] == "cookiehash" || args[0] == "firstrun" {
+		return fmt.Errorf("setting %q is managed by vertigo and cannot be changed", args[0])
+	}
+	m, err := settingsMap()
+	if err != nil {
+		return err
+	}
+	parent, key, err := lookupSetting(m, args[0])
+	if err != nil {
+		return err
+	}
+	// Values are parsed as JSON when possible, so that `true` becomes a boolean and
+	// everything else which is not valid JSON is stored as a string.
+	var value interface{}
+	if err := json.Unmarshal([]byte(args[1]), &value); err != nil {
+		value = args[1]
+	}
+	if _, isString := parent[key].(string); isString {
+		value = args[1]
+	}
+	if args[0] == "hostname" {
+		hostname := strings.TrimRight(args[1], "/")
+		if !validHostname(hostname) {
+			return fmt.Errorf("invalid value for %q: must be an absolute URL, such as http://example.com", args[0])
+		}
+		value = hostname
+	}
+	parent[key] = value
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	settings := *Settings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return fmt.Errorf("invalid value for %q: %v", args[0], err)
+	}
+	if err := settings.Save(); err != nil {
+		return err
+	}
+	return c.print(map[string]interface{}{args[0]: value}, func(w io.Writer) {
+		fmt.Fprintf(w, "%s\t%v\n", args[0], value)
+	})
+}
+
+func printMigrations(c *Cli, done []Migration, verb string) error {
+	list := make([]map[string]interface{}, 0, len(done))
+	for _, m := range done {
+		list = append(list, map[string]interface{}{"version": m.Version, "name": m.Name})
+	}
+	return c.print(list, func(w io.Writer) {
+		if len(done) == 0 {
+			fmt.Fprintln(w, "nothing to do")
+		}
+		for _, m := range done {
+			fmt.Fprintf(w, "%s\t%d\t%s\n", verb, m.Version, m.Name)
+		}
+	})
+}
+
+func cmdMigrateUp(c *Cli, args []string) error {
+	if len(args) > 0 {
+		return errUsage
+	}
+	target, err := strconv.ParseInt(c.flag("to"), 10, 64)
+	if err != nil {
+		return errUsage
+	}
+	done, err := MigrateUp(target)
+	if err != nil {
+		return err
+	}
+	return printMigrations(c, done, "applied")
+}
+
+func cmdMigrateDown(c *Cli, args []string) error {
+	if len(args) > 0 {
+		return errUsage
+	}
+	steps, err := strconv.Atoi(c.flag("steps"))
+	if err != nil || steps < 1 {
+		return errUsage
+	}
+	done, err := MigrateDown(steps)
+	if err != nil {
+		return err
+	}
+	return printMigrations(c, done, "rolled back")
+}
+
+func cmdMigrateStatus(c *Cli, args []string) error {
+	if len(args) > 0 {
+		return errUsage
+	}
+	states, err := MigrationStatus()
+	if err != nil {
+		return err
+	}
+	return c.print(states, func(w io.Writer) {
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS")
+		for _, state := range states {
+			status := "pending"
+			if state.Applied {
+				status = "applied " + time.Unix(state.AppliedAt, 0).Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", state.Version, state.Name, status)
+		}
+	})
+}
+
+// cmdBackup writes a backup archive to FILE, or to standard output if FILE is left out or "-".
+func cmdBackup(c *Cli, args []string) error {
+	if len(args) > 1 {
+		return errUsage
+	}
+	out := c.Stdout
+	if len(args) == 1 && args[0] != "-" {
+		f, err := os.Create(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	} else if c.JSON {
+		return errors.New("-json cannot be used when writing the backup to standard output")
+	}
+	manifest, err := WriteBackup(out)
+	if err != nil {
+		return err
+	}
+	if out != c.Stdout {
+		return c.print(manifest, func(w io.Writer) {
+			fmt.Fprintf(w, "backed up %d users, %d posts and %d uploads to %s\n", manifest.Counts["users"], manifest.Counts["posts"], manifest.Counts["uploads"], args[0])
+		})
+	}
+	return nil
+}
+
+// cmdRestore replaces the contents of the blog with the backup archive FILE.
+func cmdRestore(c *Cli, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	manifest, err := RestoreBackup(f, info.Size())
+	if err != nil {
+		return err
+	}
+	return c.print(manifest, func(w io.Writer) {
+		fmt.Fprintf(w, "restored %d users, %d posts and %d uploads from %s\n", manifest.Counts["users"], manifest.Counts["posts"], manifest.Counts["uploads"], args[0])
+	})
+}
+
+// importAuthor returns the user given with -author, or the first admin if it was left out.
+func importAuthor(c *Cli) (User, error) {
+	var user User
+	if email := c.flag("author"); email != "" {
+		user.Email = email
+		user, err := user.GetByEmail()
+		if err != nil {
+			return user, fmt.Errorf("author %s: %v", email, err)
+		}
+		return user, nil
+	}
+	query := db.Order("id").Where(&User{Role: RoleAdmin}).First(&user)
+	if query.Error != nil {
+		return user, errors.New("there are no admins to assign the posts to, create one or use -author")
+	}
+	return user, nil
+}
+
+func cmdImportWordPress(c *Cli, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	author, err := importAuthor(c)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	summary, err := ImportWordPress(f, author.ID)
+	if err != nil {
+		return err
+	}
+	return c.print(summary, summary.Print)
+}
+
+func cmdImportMarkdown(c *Cli, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	author, err := importAuthor(c)
+	if err != nil {
+		return err
+	}
+	summary, err := ImportMarkdown(args[0], c.flag("sync") == "true", author.ID)
+	if err != nil {
+		return err
+	}
+	return c.print(summary, summary.Print)
+}
+
+func cmdExportMarkdown(c *Cli, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	count, err := ExportMarkdown(args[0])
+	if err != nil {
+		return err
+	}
+	return c.print(map[string]interface{}{"exported": count, "directory": args[0]}, func(w io.Writer) {
+		fmt.Fprintf(w, "exported %d posts to %s\n", count, args[0])
+	})
+}
+
+// runCommand is used by main when the binary was started with a subcommand.
+func runCommand(args []string) {
+	code := Command(args, os.Stdout, os.Stderr)
+	// Commands such as post delete tell webhooks and followers in the background, which exiting would cut short.
+	done := make(chan struct{})
+	go func() {
+		deliveries.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(commandDeliveryTimeout):
+		fmt.Fprintln(os.Stderr, "vertigo: gave up waiting for deliveries to webhooks and followers")
+	}
+	os.Exit(code)
+}
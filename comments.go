@@ -0,0 +1,180 @@
+// Comments.go contains comments left on posts. Comments come from imported blogs and from public replies
+// on the fediverse, see activitypub.go; only approved ones are displayed under the post. Replies await
+// the approval of the author of the post, who moderates them on /api/v1/comments.
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+type Comment struct {
+	ID       int64  `json:"id" gorm:"primary_key:yes"`
+	Post     int64  `json:"post"`
+	Parent   int64  `json:"parent"`
+	Author   string `json:"author"`
+	Email    string `json:"-"`
+	URL      string `json:"url"`
+	Content  string `json:"content" sql:"type:text"`
+	Date     int64  `json:"date"`
+	Approved bool   `json:"approved"`
+	Remote   string `json:"remote"`
+	Actor    string `json:"actor"`
+}
+
+// Insert or comment.Insert inserts Comment object into database.
+// Returns Comment and error object.
+func (comment Comment) Insert() (Comment, error) {
+	query := db.Create(&comment)
+	if query.Error != nil {
+		return comment, query.Error
+	}
+	return comment, nil
+}
+
+// Comments or post.Comments returns approved comments of the post, oldest first.
+func (post Post) Comments() ([]Comment, error) {
+	var comments []Comment
+	query := db.Order("date asc").Where("post = ? AND approved = ?", post.ID, true).Find(&comments)
+	if query.Error != nil {
+		if query.Error == gorm.RecordNotFound {
+			return make([]Comment, 0), nil
+		}
+		return comments, query.Error
+	}
+	return comments, nil
+}
+
+// deleteComments deletes all comments of post with ID id.
+func deleteComments(tx *gorm.DB, id int64) error {
+	return tx.Where("post = ?", id).Delete(Comment{}).Error
+}
+
+// userComments returns the comments of the posts of user, newest first.
+func userComments(user User) ([]Comment, error) {
+	comments := make([]Comment, 0)
+	ids := make([]int64, 0, len(user.Posts))
+	for _, post := range user.Posts {
+		ids = append(ids, post.ID)
+	}
+	if len(ids) == 0 {
+		return comments, nil
+	}
+	query := db.Order("id desc").Where("post IN (?)", ids).Find(&comments)
+	if query.Error != nil && query.Error != gorm.RecordNotFound {
+		return comments, query.Error
+	}
+	return comments, nil
+}
+
+// requestedComment returns the comment of mux parameter "id" on a post of the current user.
+// Returns "unauthorized" without session, "not found" if the user has no such comment and "invalid id".
+func requestedComment(r *http.Request) (Comment, error) {
+	var comment Comment
+	var user User
+	user, err := user.Session(r)
+	if err != nil {
+		return comment, errors.New("unauthorized")
+	}
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		return comment, errors.New("invalid id")
+	}
+	query := db.Where("id = ?", id).First(&comment)
+	if query.Error != nil {
+		if query.Error == gorm.RecordNotFound {
+			return comment, errors.New("not found")
+		}
+		return comment, query.Error
+	}
+	for _, post := range user.Posts {
+		if post.ID == comment.Post {
+			return comment, nil
+		}
+	}
+	return comment, errors.New("not found")
+}
+
+// commentError writes the response for an error returned by requestedComment or comment methods.
+func commentError(w http.ResponseWriter, err error) {
+	switch err.Error() {
+	case "unauthorized":
+		rend.JSON(w, http.StatusUnauthorized, Unauthorized())
+	case "not found":
+		rend.JSON(w, http.StatusNotFound, NotFound())
+	case "invalid id":
+		rend.JSON(w, http.StatusBadRequest, BadRequest("invalid_id", "The comment ID could not be parsed from the request URL."))
+	default:
+		log.Println("comment: ", err)
+		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
+	}
+}
+
+// ReadComments is a route which lists the comments of the posts of the current user, newest first.
+// Requires session cookie.
+func ReadComments(w http.ResponseWriter, r *http.Request) {
+	var user User
+	user, err := user.Session(r)
+	if err != nil {
+		commentError(w, errors.New("unauthorized"))
+		return
+	}
+	comments, err := userComments(user)
+	if err != nil {
+		commentError(w, err)
+		return
+	}
+	rend.JSON(w, http.StatusOK, comments)
+}
+
+// setCommentApproved sets whether the comment "id" is shown under its post.
+func setCommentApproved(w http.ResponseWriter, r *http.Request, approved bool) {
+	comment, err := requestedComment(r)
+	if err != nil {
+		commentError(w, err)
+		return
+	}
+	if err := db.Model(&comment).UpdateColumn("approved", approved).Error; err != nil {
+		commentError(w, err)
+		return
+	}
+	message := "Comment approved"
+	if !approved {
+		message = "Comment hidden"
+	}
+	rend.JSON(w, http.StatusOK, map[string]interface{}{"success": message})
+}
+
+// ApproveComment is a route which shows the comment "id" under its post.
+// Only the author of the post is allowed.
+// JSON request returns `HTTP 200 {"success": "Comment approved"}`.
+func ApproveComment(w http.ResponseWriter, r *http.Request) {
+	setCommentApproved(w, r, true)
+}
+
+// HideComment is a route which stops showing the comment "id" under its post.
+// Only the author of the post is allowed.
+// JSON request returns `HTTP 200 {"success": "Comment hidden"}`.
+func HideComment(w http.ResponseWriter, r *http.Request) {
+	setCommentApproved(w, r, false)
+}
+
+// DeleteComment is a route which deletes the comment "id". Only the author of the post is allowed.
+// JSON request returns `HTTP 200 {"success": "Comment deleted"}`.
+func DeleteComment(w http.ResponseWriter, r *http.Request) {
+	comment, err := requestedComment(r)
+	if err != nil {
+		commentError(w, err)
+		return
+	}
+	if err := db.Delete(&comment).Error; err != nil {
+		commentError(w, err)
+		return
+	}
+	rend.JSON(w, http.StatusOK, map[string]interface{}{"success": "Comment deleted"})
+}
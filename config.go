@@ -0,0 +1,140 @@
+// Config.go contains the runtime configuration of Vertigo: which database to use, where to
+// find settings, templates and static files, and which address to listen on.
+//
+// Every value can be given as a command-line flag or as an environment variable.
+// The first one found in the following order wins:
+//
+//  1. command-line flag, e.g. -driver=postgres
+//  2. VERTIGO_* environment variable, e.g. VERTIGO_DRIVER=postgres
+//  3. legacy environment variable, DATABASE_URL for the DSN and PORT for the listen address
+//  4. built-in default
+//
+// DATABASE_URL always points to PostgreSQL, so it is only used when the driver, whether given by
+// -driver or VERTIGO_DRIVER, is postgres or not given at all.
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+)
+
+// Configuration describes where Vertigo keeps its data and how it is served.
+// Unlike Vertigo settings it is never written to disk.
+type Configuration struct {
+	Driver    string // database driver, one of sqlite3, postgres or mysql
+	DSN       string // data source name passed to the database driver
+	Settings  string // path to settings.json
+	Listen    string // address the HTTP server listens on
+	Static    string // directory holding css/ and js/
+	Templates string // directory holding the .tmpl files
+	Uploads   string // directory served under /uploads/
+}
+
+// Config is the configuration the application was started with.
+// Environment variables are applied on startup, flags once main has parsed them.
+var Config = ConfigFromEnv()
+
+// ConfigFromEnv returns the built-in defaults overridden by environment variables.
+func ConfigFromEnv() Configuration {
+	c := Configuration{
+		Driver:    "sqlite3",
+		Settings:  "./settings.json",
+		Listen:    ":8000",
+		Static:    "./public",
+		Templates: "./templates",
+		Uploads:   "./public/uploads",
+	}
+	// Heroku style deployments only tell us the database URL and port.
+	if dsn := os.Getenv("DATABASE_URL"); dsn != "" {
+		if driver := os.Getenv("VERTIGO_DRIVER"); driver == "" || driver == "postgres" {
+			c.Driver = "postgres"
+			c.DSN = dsn
+		}
+	}
+	if port := os.Getenv("PORT"); port != "" {
+		c.Listen = ":" + port
+	}
+	env := map[string]*string{
+		"VERTIGO_DRIVER":    &c.Driver,
+		"VERTIGO_DSN":       &c.DSN,
+		"VERTIGO_SETTINGS":  &c.Settings,
+		"VERTIGO_LISTEN":    &c.Listen,
+		"VERTIGO_STATIC":    &c.Static,
+		"VERTIGO_TEMPLATES": &c.Templates,
+		"VERTIGO_UPLOADS":   &c.Uploads,
+	}
+	for name, value := range env {
+		if v := os.Getenv(name); v != "" {
+			*value = v
+		}
+	}
+	return c
+}
+
+// Flags registers command-line flags for every configuration value on fs.
+// The current values of c are used as flag defaults, so flags override the environment.
+func (c *Configuration) Flags(fs *flag.FlagSet) {
+	fs.StringVar(&c.Driver, "driver", c.Driver, "database driver: sqlite3, postgres or mysql (env VERTIGO_DRIVER)")
+	fs.StringVar(&c.DSN, "dsn", c.DSN, "database connection string, by default ./vertigo.db for sqlite3 (env VERTIGO_DSN, DATABASE_URL)")
+	fs.StringVar(&c.Settings, "settings", c.Settings, "path to settings file (env VERTIGO_SETTINGS)")
+	fs.StringVar(&c.Listen, "listen", c.Listen, "address to listen on (env VERTIGO_LISTEN, PORT)")
+	fs.StringVar(&c.Static, "static", c.Static, "directory of static css and js files (env VERTIGO_STATIC)")
+	fs.StringVar(&c.Templates, "templates", c.Templates, "directory of templates (env VERTIGO_TEMPLATES)")
+	fs.StringVar(&c.Uploads, "uploads", c.Uploads, "directory of uploaded files (env VERTIGO_UPLOADS)")
+}
+
+// Resolve applies the rules which depend on which flags of fs were given, once fs has been parsed.
+// A -driver flag without -dsn decides whether DATABASE_URL is the DSN: -driver=sqlite3 drops it to use
+// the default SQLite database rather than the PostgreSQL one, and -driver=postgres takes it.
+func (c *Configuration) Resolve(fs *flag.FlagSet) {
+	given := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+	legacy := os.Getenv("DATABASE_URL")
+	if !given["driver"] || given["dsn"] || legacy == "" || os.Getenv("VERTIGO_DSN") != "" {
+		return
+	}
+	switch {
+	case c.Driver != "postgres" && c.DSN == legacy:
+		c.DSN = ""
+	case c.Driver == "postgres" && c.DSN == "":
+		c.DSN = legacy
+	}
+}
+
+// Validate fills in driver specific defaults and returns an error if the configuration cannot work.
+func (c *Configuration) Validate() error {
+	switch c.Driver {
+	case "sqlite3":
+		if c.DSN == "" {
+			c.DSN = "./vertigo.db"
+		}
+	case "postgres", "mysql":
+		if c.DSN == "" {
+			return errors.New("driver " + c.Driver + " requires -dsn or VERTIGO_DSN to be set")
+		}
+	default:
+		return errors.New("unsupported database driver " + c.Driver)
+	}
+	return nil
+}
+
+// StaticDir returns path to directory dir inside the static file directory.
+func (c Configuration) StaticDir(dir string) string {
+	return filepath.Join(c.Static, dir) + string(filepath.Separator)
+}
+
+// Setup loads settings, opens the database and prepares templates and sessions
+// according to Config. It has to be called before the server or any command is run.
+func Setup() {
+	if err := Config.Validate(); err != nil {
+		panic(err)
+	}
+	Settings = VertigoSettings()
+	store = newCookieStore()
+	db = initDB()
+	rend = newRender()
+}
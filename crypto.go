@@ -1,8 +1,15 @@
-// This file contains two cryptographic functions for both storing and comparing passwords.
+// This file contains the cryptographic functions for storing and comparing passwords and for generating secrets and keys.
 // You should not modify this file unless you know what you are doing.
 package main
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -25,3 +32,56 @@ func CompareHash(digest []byte, password string) bool {
 	}
 	return false
 }
+
+// randomSecret returns 32 random bytes in hex, such as for the secret of a webhook or an access token.
+func randomSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// generateKeyPair returns a new 2048-bit RSA key pair in PEM, such as for signing ActivityPub requests.
+func generateKeyPair() (private string, public string, err error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return "", "", err
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return "", "", err
+	}
+	private = string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+	public = string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}))
+	return private, public, nil
+}
+
+// parsePrivateKey returns the RSA private key in PEM made by generateKeyPair.
+func parsePrivateKey(private string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(private))
+	if block == nil {
+		return nil, errors.New("invalid private key")
+	}
+	return x509.ParsePKCS1PrivateKey(block.Bytes)
+}
+
+// parsePublicKey returns the RSA public key in PEM, in PKIX or PKCS #1 form.
+func parsePublicKey(public string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(public))
+	if block == nil {
+		return nil, errors.New("invalid public key")
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("public key is not RSA")
+	}
+	return key, nil
+}
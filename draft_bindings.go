@@ -0,0 +1,28 @@
+package main
+
+import (
+	"net/http"
+)
+
+/*
+This is an autogenerated file by autobindings
+*/
+
+import (
+	"github.com/mholt/binding"
+)
+
+func (d *Draft) FieldMap() binding.FieldMap {
+	return binding.FieldMap{
+		&d.Content:  "content",
+		&d.Format:   "format",
+		&d.Markdown: "markdown",
+		&d.Slug:     "slug",
+		&d.Title:    "title",
+	}
+}
+
+func (d *Draft) Validate(req *http.Request, errs binding.Errors) binding.Errors {
+	// Drafts are saved as they are, so that no unsaved changes are lost to the rules of posts.
+    return errs
+}
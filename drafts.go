@@ -0,0 +1,177 @@
+// Drafts.go keeps unsaved changes of the post editor on the server, so that closing the tab does not
+// lose them. The editor autosaves into a draft every few seconds and offers to restore it when it is
+// opened again. Each user has at most one draft per post, and one for a new post, which has Post 0.
+// Saving or deleting the post discards its draft.
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+	"github.com/mholt/binding"
+)
+
+//go:generate autobindings draft
+type Draft struct {
+	ID       int64  `json:"id" gorm:"primary_key:yes"`
+	Author   int64  `json:"author"`
+	Post     int64  `json:"post"`
+	Title    string `json:"title" form:"title"`
+	Slug     string `json:"slug" form:"slug"`
+	Markdown string `json:"markdown" form:"markdown" sql:"type:text"`
+	Content  string `json:"content" form:"content" sql:"type:text"`
+	Format   string `json:"format" form:"format"`
+	Date     int64  `json:"date"`
+}
+
+// Get or draft.Get returns the draft of draft.Author for draft.Post.
+func (draft Draft) Get() (Draft, error) {
+	query := db.Where("author = ? AND post = ?", draft.Author, draft.Post).First(&draft)
+	if query.Error != nil {
+		if query.Error == gorm.RecordNotFound {
+			return draft, errors.New("not found")
+		}
+		return draft, query.Error
+	}
+	return draft, nil
+}
+
+// Save or draft.Save replaces the draft of draft.Author for draft.Post with draft.
+func (draft Draft) Save() (Draft, error) {
+	existing, err := draft.Get()
+	switch {
+	case err == nil:
+		draft.ID = existing.ID
+	case err.Error() == "not found":
+		draft.ID = 0
+	default:
+		return draft, err
+	}
+	draft.Date = time.Now().Unix()
+	query := db.Save(&draft)
+	if query.Error != nil {
+		return draft, query.Error
+	}
+	return draft, nil
+}
+
+// deleteDraft deletes the draft of user author for post with ID id.
+func deleteDraft(tx *gorm.DB, author, id int64) error {
+	return tx.Where("author = ? AND post = ?", author, id).Delete(Draft{}).Error
+}
+
+// deleteDrafts deletes drafts of all users for post with ID id.
+func deleteDrafts(tx *gorm.DB, id int64) error {
+	return tx.Where("post = ?", id).Delete(Draft{}).Error
+}
+
+// draftRequest returns the draft addressed by the mux parameter "post" for the session user.
+// The post has to be written by the user, unless it is 0 for a new post.
+func draftRequest(r *http.Request) (Draft, error) {
+	var draft Draft
+	id, err := strconv.ParseInt(mux.Vars(r)["post"], 10, 64)
+	if err != nil || id < 0 {
+		return draft, errors.New("bad request")
+	}
+	var user User
+	user, err = user.Session(r)
+	if err != nil {
+		return draft, err
+	}
+	if id != 0 {
+		var post Post
+		query := db.Where(&Post{ID: id}).First(&post)
+		if query.Error != nil {
+			if query.Error == gorm.RecordNotFound {
+				return draft, errors.New("not found")
+			}
+			return draft, query.Error
+		}
+		if post.Author != user.ID {
+			return draft, errors.New("unauthorized")
+		}
+	}
+	draft.Author = user.ID
+	draft.Post = id
+	return draft, nil
+}
+
+// draftError writes the response for an error returned by draftRequest or Draft methods.
+func draftError(w http.ResponseWriter, err error) {
+	switch err.Error() {
+	case "bad request":
+		rend.JSON(w, http.StatusBadRequest, BadRequest("invalid_id", "The post ID could not be parsed from the request URL."))
+	case "not found":
+		rend.JSON(w, http.StatusNotFound, NotFound())
+	case "unauthorized":
+		rend.JSON(w, http.StatusUnauthorized, Unauthorized())
+	default:
+		log.Println("draft: ", err)
+		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
+	}
+}
+
+// ReadDraft is a route which returns the draft of the session user for the post with ID "post",
+// or 404 if there are no unsaved changes. Use 0 for a new post.
+func ReadDraft(w http.ResponseWriter, r *http.Request) {
+	draft, err := draftRequest(r)
+	if err != nil {
+		draftError(w, err)
+		return
+	}
+	draft, err = draft.Get()
+	if err != nil {
+		draftError(w, err)
+		return
+	}
+	rend.JSON(w, http.StatusOK, draft)
+}
+
+// SaveDraft is a route which replaces the draft of the session user for the post with ID "post"
+// with the posted title, slug, format and source. Returns the saved draft.
+func SaveDraft(w http.ResponseWriter, r *http.Request) {
+	draft, err := draftRequest(r)
+	if err != nil {
+		draftError(w, err)
+		return
+	}
+	input := new(Draft)
+	if errs := binding.Bind(r, input); len(errs) > 0 {
+		rend.JSON(w, validationStatus(errs), ValidationError(errs))
+		return
+	}
+	if input.Format != "" && !validFormat(input.Format) {
+		rend.JSON(w, http.StatusBadRequest, BadRequest("invalid_format", "Format must be markdown, html or plain."))
+		return
+	}
+	draft.Title = input.Title
+	draft.Slug = input.Slug
+	draft.Markdown = input.Markdown
+	draft.Content = input.Content
+	draft.Format = input.Format
+	draft, err = draft.Save()
+	if err != nil {
+		draftError(w, err)
+		return
+	}
+	rend.JSON(w, http.StatusOK, draft)
+}
+
+// DeleteDraft is a route which discards the draft of the session user for the post with ID "post".
+func DeleteDraft(w http.ResponseWriter, r *http.Request) {
+	draft, err := draftRequest(r)
+	if err != nil {
+		draftError(w, err)
+		return
+	}
+	if err := deleteDraft(db, draft.Author, draft.Post); err != nil {
+		draftError(w, err)
+		return
+	}
+	rend.JSON(w, http.StatusOK, map[string]interface{}{"success": "Draft discarded"})
+}
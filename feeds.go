@@ -16,28 +16,29 @@ import (
 // ReadFeed renders RSS or Atom feed of latest published posts.
 // It determines the feed type with strings.Split(r.URL.Path[1:], "/")[1].
 func ReadFeed(w http.ResponseWriter, r *http.Request) {
-
-	w.Header().Set("Content-Type", "application/xml")
-
-	urlhost := urlHost()
-
 	feed := &feeds.Feed{
 		Title:       Settings.Name,
-		Link:        &feeds.Link{Href: urlhost},
+		Link:        &feeds.Link{Href: urlHost()},
 		Description: Settings.Description,
 	}
 
-	//log.Println(urlhost)
-	//log.Println(feed)
-
 	var post Post
 	posts, err := post.GetAll(r)
 	if err != nil {
 		log.Println("readfeed posts: ", err)
-		rend.JSON(w, http.StatusInternalServerError, map[string]interface{}{"error": "Internal server error"})
+		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
 		return
 	}
-	//log.Println(posts)
+
+	writeFeed(w, feed, posts, strings.Split(r.URL.Path[1:], "/")[1])
+}
+
+// writeFeed adds the published posts of posts to feed and writes it as Atom if format is "atom",
+// and otherwise as RSS.
+func writeFeed(w http.ResponseWriter, feed *feeds.Feed, posts []Post, format string) {
+	w.Header().Set("Content-Type", "application/xml")
+
+	urlhost := urlHost()
 
 	for _, post := range posts {
 
@@ -46,12 +47,12 @@ func ReadFeed(w http.ResponseWriter, r *http.Request) {
 		user, err := user.Get()
 		if err != nil {
 			log.Println("readfeed user: ", err)
-			rend.JSON(w, http.StatusInternalServerError, map[string]interface{}{"error": "Internal server error"})
+			rend.JSON(w, http.StatusInternalServerError, InternalServerError())
 			return
 		}
 
-		// Don't expose unpublished items to the feeds
-		if !post.Published {
+		// Don't expose unpublished items or pages to the feeds
+		if !post.Published || post.Kind != KindPost {
 			continue
 		}
 
@@ -59,11 +60,11 @@ func ReadFeed(w http.ResponseWriter, r *http.Request) {
 		// However, the package panics if too few values are exported, so that will do.
 		item := &feeds.Item{
 			Title:       post.Title,
-			Link:        &feeds.Link{Href: urlhost + post.Slug, Rel: "self"},
+			Link:        &feeds.Link{Href: urlhost + "/post/" + post.Slug, Rel: "self"},
 			Description: post.Excerpt,
 			Author:      &feeds.Author{Name: user.Name, Email: user.Email},
 			Created:     time.Unix(post.Date, 0),
-			Id:          urlhost + post.Slug,
+			Id:          urlhost + "/post/" + post.Slug,
 		}
 		feed.Add(item)
 	}
@@ -72,16 +73,15 @@ func ReadFeed(w http.ResponseWriter, r *http.Request) {
 	result, err := feed.ToRss()
 	if err != nil {
 		log.Println("readfeed rss: ", err)
-		rend.JSON(w, http.StatusInternalServerError, map[string]interface{}{"error": "Internal server error"})
+		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
 		return
 	}
 
-	format := strings.Split(r.URL.Path[1:], "/")[1]
 	if format == "atom" {
 		result, err = feed.ToAtom()
 		if err != nil {
 			log.Println("readfeed atom: ", err)
-			rend.JSON(w, http.StatusInternalServerError, map[string]interface{}{"error": "Internal server error"})
+			rend.JSON(w, http.StatusInternalServerError, InternalServerError())
 			return
 		}
 	}
@@ -0,0 +1,217 @@
+// Fields.go lets clients of the JSON API choose what they receive of users and posts.
+// Query parameter fields lists the fields to return, such as ?fields=id,title,slug, and
+// fields[posts] the fields of posts embedded in users. Query parameter include embeds related
+// resources which are left out by default, such as ?include=posts on users. Embedded posts leave out
+// their content and markdown unless fields[posts] lists them, so that users with many posts stay small.
+// The deprecated routes under /api/ select the same way as the ones under /api/v1/.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// Selection is what a request to the JSON API asked to receive of a resource.
+type Selection struct {
+	Fields  []string            // Fields of the resource, or nil for all of them.
+	Nested  map[string][]string // Fields of embedded resources by their name.
+	Include map[string]bool     // Related resources to embed.
+}
+
+// embedding is a related resource which may be embedded in another with include.
+type embedding struct {
+	value    interface{} // Value of the type of the resource, which tells its fields.
+	defaults []string    // Fields returned unless fields[name] lists others.
+}
+
+// postSummaryFields are the fields of embedded posts unless fields[posts] lists others.
+var postSummaryFields = withoutFields(jsonFields(reflect.TypeOf(Post{})), "content", "markdown")
+
+// userEmbeddings are the resources which may be embedded in users.
+var userEmbeddings = map[string]embedding{
+	"posts": {value: Post{}, defaults: postSummaryFields},
+}
+
+// ParseSelection reads the query parameters fields, fields[name] and include of r. Fields are checked
+// against the JSON fields of v, and embeddings lists the resources which may be embedded in v.
+// Returns an APIError with code invalid_fields or invalid_include if the parameters name unknown fields or resources.
+func ParseSelection(r *http.Request, v interface{}, embeddings map[string]embedding) (Selection, error) {
+	query := r.URL.Query()
+	selection := Selection{Nested: make(map[string][]string), Include: make(map[string]bool)}
+
+	for key := range query {
+		if key == "fields" || !strings.HasPrefix(key, "fields[") {
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(key, "fields["), "]")
+		if _, exists := embeddings[name]; !exists || !strings.HasSuffix(key, "]") {
+			return selection, BadRequest("invalid_fields", fmt.Sprintf("%s cannot be embedded in this resource.", name))
+		}
+	}
+
+	if query.Get("fields") != "" {
+		fields, err := parseFields(query.Get("fields"), v)
+		if err != nil {
+			return selection, err
+		}
+		selection.Fields = fields
+	}
+	for name, embedded := range embeddings {
+		selection.Nested[name] = embedded.defaults
+		if list := query.Get("fields[" + name + "]"); list != "" {
+			fields, err := parseFields(list, embedded.value)
+			if err != nil {
+				return selection, err
+			}
+			selection.Nested[name] = fields
+		}
+	}
+
+	for _, name := range splitList(query.Get("include")) {
+		if _, exists := embeddings[name]; !exists {
+			return selection, BadRequest("invalid_include", fmt.Sprintf("%s cannot be included in this resource.", name))
+		}
+		selection.Include[name] = true
+	}
+	return selection, nil
+}
+
+// parseFields returns the comma separated fields of list, checking that v has each of them.
+func parseFields(list string, v interface{}) ([]string, error) {
+	known := jsonFields(reflect.TypeOf(v))
+	fields := splitList(list)
+	for _, field := range fields {
+		if !containsField(known, field) {
+			return nil, BadRequest("invalid_fields", fmt.Sprintf("Unknown field %s. Fields are %s.", field, strings.Join(known, ", ")))
+		}
+	}
+	return fields, nil
+}
+
+// splitList returns the comma separated values of list without spaces and empty values.
+func splitList(list string) []string {
+	values := make([]string, 0)
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// jsonFields returns the names of the fields of struct type t in its JSON representation.
+func jsonFields(t reflect.Type) []string {
+	fields := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, name)
+	}
+	return fields
+}
+
+// withoutFields returns fields without the ones listed in exclude.
+func withoutFields(fields []string, exclude ...string) []string {
+	remaining := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if !containsField(exclude, field) {
+			remaining = append(remaining, field)
+		}
+	}
+	return remaining
+}
+
+// containsField reports whether fields contains field.
+func containsField(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// pickFields returns the JSON representation of v with only the listed fields.
+// Fields which v leaves out of its representation, such as empty ones with omitempty, stay out.
+func pickFields(v interface{}, fields []string) (map[string]interface{}, error) {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &all); err != nil {
+		return nil, err
+	}
+	picked := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		if value, exists := all[field]; exists {
+			picked[field] = value
+		}
+	}
+	return picked, nil
+}
+
+// Post or selection.Post returns post with the fields the request selected.
+// Posts are returned as they are unless the request lists fields.
+func (selection Selection) Post(post Post) (interface{}, error) {
+	if selection.Fields == nil {
+		return post, nil
+	}
+	return pickFields(post, selection.Fields)
+}
+
+// Posts or selection.Posts returns posts with the fields the request selected.
+func (selection Selection) Posts(posts []Post) ([]interface{}, error) {
+	selected := make([]interface{}, len(posts))
+	for i, post := range posts {
+		var err error
+		if selected[i], err = selection.Post(post); err != nil {
+			return nil, err
+		}
+	}
+	return selected, nil
+}
+
+// User or selection.User returns user with the fields the request selected.
+// Posts of the user are only embedded if the request included them, with the fields of fields[posts].
+func (selection Selection) User(user User) (interface{}, error) {
+	fields := selection.Fields
+	if fields == nil {
+		fields = jsonFields(reflect.TypeOf(user))
+	}
+	selected, err := pickFields(user, withoutFields(fields, "posts"))
+	if err != nil {
+		return nil, err
+	}
+	if selection.Include["posts"] && containsField(fields, "posts") {
+		embedded := Selection{Fields: selection.Nested["posts"]}
+		if selected["posts"], err = embedded.Posts(user.Posts); err != nil {
+			return nil, err
+		}
+	}
+	return selected, nil
+}
+
+// Users or selection.Users returns users with the fields the request selected.
+func (selection Selection) Users(users []User) ([]interface{}, error) {
+	selected := make([]interface{}, len(users))
+	for i, user := range users {
+		var err error
+		if selected[i], err = selection.User(user); err != nil {
+			return nil, err
+		}
+	}
+	return selected, nil
+}
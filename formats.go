@@ -0,0 +1,111 @@
+// Formats.go contains the content formats a post can be written in. Each post keeps its own format,
+// which decides how its source is rendered into post.Content and how it is searched.
+package main
+
+import (
+	"errors"
+	"html"
+	"strings"
+
+	"github.com/kennygrant/sanitize"
+)
+
+// Content formats of a post. Source of Markdown and plain text posts is kept in post.Markdown,
+// HTML posts are written directly into post.Content.
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+	FormatPlain    = "plain"
+)
+
+// validFormat reports whether format is one of the supported content formats.
+func validFormat(format string) bool {
+	switch format {
+	case FormatMarkdown, FormatHTML, FormatPlain:
+		return true
+	}
+	return false
+}
+
+// defaultFormat returns the format new posts are written in unless they say otherwise.
+func defaultFormat() string {
+	if Settings.Markdown {
+		return FormatMarkdown
+	}
+	return FormatHTML
+}
+
+// Render or post.Render fills post.Content, post.Excerpt and post.ReadingTime from the source of the post
+// according to post.Format. The content is sanitized according to the role of post.Author.
+// Markdown posts get their table of contents if they ask for one, see toc.go.
+func (post Post) Render() (Post, error) {
+	switch post.Format {
+	case FormatMarkdown:
+		post.Content = renderMarkdown(post.Markdown)
+	case FormatHTML:
+		post.Content = cleanup(post.Content)
+	case FormatPlain:
+		post.Content = renderPlain(post.Markdown)
+	default:
+		return post, errors.New("unsupported format")
+	}
+	post.Content = sanitizeContent(post.Content, post.authorRole())
+	text := strings.Replace(post.Content, tocMarker, "", 1)
+	post.Excerpt = Excerpt(text)
+	post.ReadingTime = readingTime(text)
+	if post.Format == FormatMarkdown {
+		post.Content = tableOfContents(post.Content)
+	}
+	return post, nil
+}
+
+// Convert or post.Convert changes format of the post, converting its source so that it renders
+// into the same content as before.
+func (post Post) Convert(format string) (Post, error) {
+	if !validFormat(format) {
+		return post, errors.New("unsupported format")
+	}
+	if format == post.Format {
+		return post, nil
+	}
+	switch format {
+	case FormatMarkdown:
+		markdown, err := HTMLToMarkdown(post.Content)
+		if err != nil {
+			return post, err
+		}
+		post.Markdown = markdown
+	case FormatHTML:
+		post.Markdown = ""
+	case FormatPlain:
+		post.Markdown = sanitize.HTML(post.Content)
+	}
+	post.Format = format
+	return post.Render()
+}
+
+// SearchText or post.SearchText returns the text of the post search matches against.
+// Markdown posts are searched by their source, as Blackfriday's smartypants corrections to some
+// characters would break the search.
+func (post Post) SearchText() string {
+	switch post.Format {
+	case FormatMarkdown, FormatPlain:
+		return post.Markdown
+	}
+	return sanitize.HTML(post.Content)
+}
+
+// renderPlain renders plain text into HTML paragraphs, keeping single line breaks.
+func renderPlain(text string) string {
+	text = strings.Replace(text, "\r\n", "\n", -1)
+	var paragraphs []string
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		paragraph = strings.Replace(html.EscapeString(paragraph), "\n", "<br>\n", -1)
+		paragraphs = append(paragraphs, "<p>"+paragraph+"</p>")
+	}
+	return strings.Join(paragraphs, "\n")
+}
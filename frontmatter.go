@@ -0,0 +1,360 @@
+// Frontmatter.go imports and exports posts as a directory of Markdown files with YAML front
+// matter, the format used by static site generators such as Hugo and Jekyll:
+//
+//	---
+//	title: Hello world
+//	slug: hello-world
+//	date: 2015-01-02T15:04:05Z
+//	tags: [go, vertigo]
+//	published: true
+//	author: foo@example.com
+//	---
+//	Post body in *Markdown*.
+//
+// Jekyll style `published: false` and Hugo style `draft: true` both mark a post unpublished.
+// Posts not written in Markdown have `format: html` or `format: plain` and their body in that format.
+// Pages have `kind: page`, and may name the slug of their parent page in `parent` and their place in
+// the navigation menu in `menuorder`.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gosimple/slug"
+	"github.com/jinzhu/gorm"
+	"gopkg.in/yaml.v2"
+)
+
+type frontMatter struct {
+	Title     string          `yaml:"title"`
+	Slug      string          `yaml:"slug,omitempty"`
+	Date      string          `yaml:"date,omitempty"`
+	Tags      frontMatterTags `yaml:"tags,omitempty"`
+	Published *bool           `yaml:"published,omitempty"`
+	Draft     *bool           `yaml:"draft,omitempty"`
+	Author    string          `yaml:"author,omitempty"`
+	Format    string          `yaml:"format,omitempty"`
+	Kind      string          `yaml:"kind,omitempty"`
+	Parent    string          `yaml:"parent,omitempty"`
+	MenuOrder int             `yaml:"menuorder,omitempty"`
+}
+
+// frontMatterTags accepts tags both as a YAML list and as a comma separated string.
+type frontMatterTags []string
+
+func (tags *frontMatterTags) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var list []string
+	if err := unmarshal(&list); err == nil {
+		*tags = list
+		return nil
+	}
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	*tags = splitTags(s)
+	return nil
+}
+
+// splitTags splits comma separated tags of Post.Tags.
+func splitTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// frontMatterDates lists the date formats found in the wild, most specific first.
+var frontMatterDates = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// jekyllFilename matches the date prefix of Jekyll post filenames, such as 2015-01-02-hello-world.md.
+var jekyllFilename = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}-`)
+
+func (fm frontMatter) date() (int64, error) {
+	if fm.Date == "" {
+		return time.Now().Unix(), nil
+	}
+	for _, layout := range frontMatterDates {
+		if t, err := time.Parse(layout, fm.Date); err == nil {
+			return t.Unix(), nil
+		}
+	}
+	return 0, errors.New("unrecognized date " + fm.Date)
+}
+
+func (fm frontMatter) published() bool {
+	if fm.Published != nil {
+		return *fm.Published
+	}
+	if fm.Draft != nil {
+		return !*fm.Draft
+	}
+	return true
+}
+
+// parseMarkdownFile splits a Markdown file into its front matter and body.
+func parseMarkdownFile(data []byte) (frontMatter, string, error) {
+	var fm frontMatter
+	data = bytes.Replace(data, []byte("\r\n"), []byte("\n"), -1)
+	if !bytes.HasPrefix(data, []byte("---\n")) {
+		return fm, "", errors.New("file does not start with front matter")
+	}
+	rest := data[len("---\n"):]
+	end := bytes.Index(rest, []byte("\n---"))
+	if end < 0 {
+		return fm, "", errors.New("front matter is not terminated with ---")
+	}
+	if err := yaml.Unmarshal(rest[:end], &fm); err != nil {
+		return fm, "", err
+	}
+	body := rest[end+len("\n---"):]
+	if i := bytes.IndexByte(body, '\n'); i >= 0 {
+		body = body[i+1:]
+	} else {
+		body = nil
+	}
+	return fm, strings.TrimLeft(string(body), "\n"), nil
+}
+
+// ImportMarkdown imports every .md file in dir as a post. Posts whose author email is unknown are
+// assigned to user fallback. Posts with a slug that already exists are skipped, unless sync is set,
+// in which case the existing post is updated from the file.
+func ImportMarkdown(dir string, sync bool, fallback int64) (ImportSummary, error) {
+	var summary ImportSummary
+	// Parents are set once every file is imported, since a page may come before its parent.
+	parents := make(map[int64]string)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) != ".md" {
+			return nil
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return importMarkdownFile(path, data, sync, fallback, parents, &summary)
+	})
+	if err != nil {
+		return summary, err
+	}
+	return summary, setMarkdownParents(parents, &summary)
+}
+
+// setMarkdownParents places the imported pages below the pages whose slugs their front matter names.
+func setMarkdownParents(parents map[int64]string, summary *ImportSummary) error {
+	for id, parentSlug := range parents {
+		var page, parent Post
+		if err := db.Where(&Post{ID: id}).First(&page).Error; err != nil {
+			return err
+		}
+		query := db.Where(&Post{Slug: parentSlug}).First(&parent)
+		if query.Error != nil && query.Error != gorm.RecordNotFound {
+			return query.Error
+		}
+		page.Parent = parent.ID
+		if query.Error == gorm.RecordNotFound || page.checkParent(db) != nil {
+			summary.add(ImportItem{Type: KindPage, Title: page.Title, Slug: page.Slug, Status: ImportSkipped, Reason: "parent " + parentSlug + " is not a page, the page was imported without it"})
+			continue
+		}
+		if err := db.Model(&page).Update("parent", page.Parent).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func importMarkdownFile(path string, data []byte, sync bool, fallback int64, parents map[int64]string, summary *ImportSummary) error {
+	name := filepath.Base(path)
+	fm, body, err := parseMarkdownFile(data)
+	if err != nil {
+		summary.skipped("post", name, err.Error())
+		return nil
+	}
+	date, err := fm.date()
+	if err != nil {
+		summary.skipped("post", name, err.Error())
+		return nil
+	}
+	if fm.Title == "" {
+		summary.skipped("post", name, "front matter has no title")
+		return nil
+	}
+
+	var post Post
+	post.Slug = slug.Make(fm.Slug)
+	if post.Slug == "" {
+		post.Slug = slug.Make(jekyllFilename.ReplaceAllString(strings.TrimSuffix(name, ".md"), ""))
+	}
+	if post.Slug == "" {
+		summary.skipped("post", name, "no slug could be made of the file name")
+		return nil
+	}
+	if reservedSlugs[post.Slug] {
+		summary.skipped("post", name, "slug "+post.Slug+" is reserved")
+		return nil
+	}
+	query := db.Where(&Post{Slug: post.Slug}).First(&post)
+	exists := query.Error == nil
+	if query.Error != nil && query.Error != gorm.RecordNotFound {
+		return query.Error
+	}
+	if exists && !sync {
+		summary.skipped("post", fm.Title, "a post with slug "+post.Slug+" already exists, use -sync to update it")
+		return nil
+	}
+
+	// Synced posts keep their author unless the front matter names another one.
+	if !exists {
+		post.Author = fallback
+	}
+	if fm.Author != "" {
+		var user User
+		user.Email = fm.Author
+		if user, err := user.GetByEmail(); err == nil {
+			post.Author = user.ID
+		}
+	}
+	post.Title = fm.Title
+	post.Date = date
+	post.Tags = strings.Join(fm.Tags, ", ")
+	post.Published = fm.published()
+	post.Kind = fm.Kind
+	if post.Kind == "" {
+		post.Kind = KindPost
+	}
+	if !validKind(post.Kind) {
+		summary.skipped("post", name, "kind "+fm.Kind+" is not one of post or page")
+		return nil
+	}
+	post.Parent = 0
+	post.MenuOrder = 0
+	if post.Kind == KindPage {
+		post.MenuOrder = fm.MenuOrder
+	}
+	post.Format = fm.Format
+	if post.Format == "" {
+		post.Format = FormatMarkdown
+	}
+	if !validFormat(post.Format) {
+		summary.skipped("post", name, "format "+fm.Format+" is not one of markdown, html or plain")
+		return nil
+	}
+	if post.Format == FormatHTML {
+		// Imported HTML is kept as is, like the HTML of WordPress posts.
+		post.Markdown = ""
+		post.Content = sanitizeContent(body, post.authorRole())
+		post.Excerpt = Excerpt(post.Content)
+		post.ReadingTime = readingTime(post.Content)
+	} else {
+		post.Markdown = body
+		if post, err = post.Render(); err != nil {
+			return err
+		}
+	}
+
+	if exists {
+		// Save writes every column, so that a post can also be unpublished from the file.
+		if err := db.Save(&post).Error; err != nil {
+			return err
+		}
+		summary.add(ImportItem{Type: post.Kind, Title: post.Title, Slug: post.Slug, Status: ImportUpdated})
+	} else {
+		if err := db.Create(&post).Error; err != nil {
+			return err
+		}
+		summary.imported(post.Kind, post.Title, post.Slug)
+	}
+	if post.Kind == KindPage && fm.Parent != "" {
+		parents[post.ID] = fm.Parent
+	}
+	return nil
+}
+
+// ExportMarkdown writes every post into dir as <slug>.md, overwriting existing files.
+// Posts keep their format, so posts written in HTML are exported with their HTML as the body.
+// Returns the number of posts written.
+func ExportMarkdown(dir string) (int, error) {
+	var post Post
+	posts, err := post.GetAll(nil)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, err
+	}
+	authors := make(map[int64]string)
+	slugs := make(map[int64]string)
+	for _, post := range posts {
+		slugs[post.ID] = post.Slug
+	}
+	for i, post := range posts {
+		if _, exists := authors[post.Author]; !exists {
+			var user User
+			user.ID = post.Author
+			if user, err := user.Get(); err == nil {
+				authors[post.Author] = user.Email
+			}
+		}
+		published := post.Published
+		fm := frontMatter{
+			Title:     post.Title,
+			Slug:      post.Slug,
+			Date:      time.Unix(post.Date, 0).UTC().Format(time.RFC3339),
+			Tags:      splitTags(post.Tags),
+			Published: &published,
+			Author:    authors[post.Author],
+		}
+		if post.Format != FormatMarkdown {
+			fm.Format = post.Format
+		}
+		if post.Kind == KindPage {
+			fm.Kind = KindPage
+			fm.Parent = slugs[post.Parent]
+			fm.MenuOrder = post.MenuOrder
+		}
+		data, err := yaml.Marshal(fm)
+		if err != nil {
+			return i, err
+		}
+		body := post.Markdown
+		if post.Format == FormatHTML {
+			body = post.Content
+		}
+		var buf bytes.Buffer
+		buf.WriteString("---\n")
+		buf.Write(data)
+		buf.WriteString("---\n")
+		buf.WriteString(body)
+		if !strings.HasSuffix(body, "\n") {
+			buf.WriteString("\n")
+		}
+		// Slugs are checked on every way in, but one which would escape dir is still refused.
+		path := filepath.Join(dir, post.Slug+".md")
+		if filepath.Dir(path) != filepath.Clean(dir) {
+			return i, fmt.Errorf("post %s: slug is not a valid file name", post.Slug)
+		}
+		if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			return i, fmt.Errorf("post %s: %v", post.Slug, err)
+		}
+	}
+	return len(posts), nil
+}
@@ -0,0 +1,787 @@
+// Graphql.go serves a GraphQL endpoint at /api/graphql for clients which would otherwise need many
+// requests to the JSON API, such as a single page application listing posts with their authors.
+// The schema covers posts, their authors and tags, and search. Lists of posts are paginated with
+// cursors. Mutations create, update, publish and unpublish posts with the same rules as the routes
+// of the JSON API, through Post.Insert, Post.Edit, Post.Publish and Post.Unpublish.
+// Queries which are nested too deep or would return too many objects are refused before they are run.
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/location"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// Limits of GraphQL queries. Lists of posts return defaultPageSize posts unless given another
+// number with argument first, which is at most maxPageSize. The complexity of a query is the number
+// of fields it returns, counting the fields of each post of a list as many times as the list has posts.
+// Introspection fields, such as __schema, and the fields inside them count toward the complexity, but have
+// their own depth limit, as the introspection query of GraphQL clients is nested deeper than queries of
+// content. Their lists, such as fields, may only be nested as deep as in that query.
+const (
+	defaultPageSize       = 10
+	maxPageSize           = 50
+	maxQueryDepth         = 10
+	maxQueryComplexity    = 1000
+	maxIntrospectionDepth = 15
+	maxIntrospectionLists = 3
+)
+
+// introspectionLists are the fields of introspection types which return lists.
+var introspectionLists = map[string]bool{
+	"types": true, "fields": true, "inputFields": true, "args": true,
+	"interfaces": true, "possibleTypes": true, "enumValues": true, "directives": true,
+}
+
+// cost is the cost of a selection set, see queryCost.
+type cost struct {
+	depth         int // Levels of fields outside introspection.
+	introspection int // Levels of introspection fields and the fields inside them.
+	lists         int // Levels of introspection lists.
+	complexity    int
+}
+
+// GraphQLRequest is the body of POST requests to /api/graphql. GET requests give the same
+// in query parameters query, variables and operationName.
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+// Tag is a tag of posts. Tags are given to posts as comma separated Post.Tags.
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// PostConnection is a page of a list of posts, see paginate.
+type PostConnection struct {
+	Edges      []PostEdge `json:"edges"`
+	PageInfo   PageInfo   `json:"pageInfo"`
+	TotalCount int        `json:"totalCount"`
+}
+
+// PostEdge is a post of a PostConnection with the cursor which points to it.
+type PostEdge struct {
+	Cursor string `json:"cursor"`
+	Node   Post   `json:"node"`
+}
+
+// PageInfo tells whether there are more posts after a PostConnection, and the cursor to get them with.
+type PageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+// graphQLContextKey is the key of the request in the context of GraphQL resolvers.
+type graphQLContextKey struct{}
+
+// graphQLSchema is the schema of /api/graphql.
+var graphQLSchema = mustGraphQLSchema()
+
+// Extensions or apierr.Extensions returns the code and invalid fields of an error returned
+// by a GraphQL resolver, which are shown in the extensions of the error.
+func (e APIError) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{"code": e.Code}
+	if len(e.Fields) > 0 {
+		extensions["fields"] = e.Fields
+	}
+	return extensions
+}
+
+// ServeGraphQL is a route which runs a GraphQL query, given as GraphQLRequest.
+// Mutations are only accepted with POST. Uses the session cookie of the request, so that mutations
+// and unpublished posts are available to logged in users.
+// Responds with the result of the query, or HTTP 400 if the query could not be run at all.
+func ServeGraphQL(w http.ResponseWriter, r *http.Request) {
+	var request GraphQLRequest
+	switch r.Method {
+	case "GET":
+		request.Query = r.URL.Query().Get("query")
+		request.OperationName = r.URL.Query().Get("operationName")
+		if variables := r.URL.Query().Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &request.Variables); err != nil {
+				graphQLError(w, http.StatusBadRequest, BadRequest("invalid_variables", "Variables must be a JSON object."))
+				return
+			}
+		}
+	case "POST":
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			graphQLError(w, http.StatusBadRequest, BadRequest("invalid_body", "The body must be a JSON object with a query."))
+			return
+		}
+	}
+	if request.Query == "" {
+		graphQLError(w, http.StatusBadRequest, BadRequest("missing_query", "The request has no query."))
+		return
+	}
+
+	document, err := parser.Parse(parser.ParseParams{Source: request.Query})
+	if err != nil {
+		rend.JSON(w, http.StatusBadRequest, map[string]interface{}{"errors": []gqlerrors.FormattedError{gqlerrors.FormatError(err)}})
+		return
+	}
+	if r.Method == "GET" && hasMutation(document) {
+		graphQLError(w, http.StatusMethodNotAllowed, BadRequest("mutation_not_allowed", "Mutations must be sent with POST."))
+		return
+	}
+	if apierr, exceeded := queryLimits(document, request.Variables); exceeded {
+		graphQLError(w, http.StatusBadRequest, apierr)
+		return
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:         graphQLSchema,
+		RequestString:  request.Query,
+		VariableValues: request.Variables,
+		OperationName:  request.OperationName,
+		Context:        context.WithValue(r.Context(), graphQLContextKey{}, r),
+	})
+	rend.JSON(w, http.StatusOK, result)
+}
+
+// graphQLError responds with a GraphQL response which only has apierr as its error.
+func graphQLError(w http.ResponseWriter, status int, apierr APIError) {
+	rend.JSON(w, status, map[string]interface{}{"errors": []gqlerrors.FormattedError{{
+		Message:    apierr.Message,
+		Locations:  []location.SourceLocation{},
+		Extensions: apierr.Extensions(),
+	}}})
+}
+
+// hasMutation reports whether document has a mutation.
+func hasMutation(document *ast.Document) bool {
+	for _, definition := range document.Definitions {
+		if operation, ok := definition.(*ast.OperationDefinition); ok && operation.Operation == ast.OperationTypeMutation {
+			return true
+		}
+	}
+	return false
+}
+
+// queryLimits checks every operation of document against maxQueryDepth and maxQueryComplexity.
+// Returns the error to respond with and true if an operation exceeds them.
+func queryLimits(document *ast.Document, variables map[string]interface{}) (APIError, bool) {
+	fragments := make(map[string]*ast.FragmentDefinition)
+	for _, definition := range document.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok {
+			fragments[fragment.Name.Value] = fragment
+		}
+	}
+	for _, definition := range document.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		c := queryCost(operation.SelectionSet, fragments, variables, make(map[string]bool), false)
+		if c.depth > maxQueryDepth {
+			return BadRequest("query_too_deep", fmt.Sprintf("The query is nested %d levels deep, but at most %d levels are allowed.", c.depth, maxQueryDepth)), true
+		}
+		if c.introspection > maxIntrospectionDepth {
+			return BadRequest("query_too_deep", fmt.Sprintf("The introspection query is nested %d levels deep, but at most %d levels are allowed.", c.introspection, maxIntrospectionDepth)), true
+		}
+		if c.lists > maxIntrospectionLists {
+			return BadRequest("query_too_deep", fmt.Sprintf("The introspection query nests %d lists, such as fields, but at most %d are allowed.", c.lists, maxIntrospectionLists)), true
+		}
+		if c.complexity > maxQueryComplexity {
+			return BadRequest("query_too_complex", fmt.Sprintf("The query has a complexity of %d, but at most %d is allowed. Ask for fewer posts with argument first.", c.complexity, maxQueryComplexity)), true
+		}
+	}
+	return APIError{}, false
+}
+
+// queryCost returns the cost of the fields of set, where introspecting tells whether set is inside an
+// introspection field. Fields of lists of posts count as many times as the list has posts.
+// Fragments already being walked through are skipped, which the validation of the query reports.
+func queryCost(set *ast.SelectionSet, fragments map[string]*ast.FragmentDefinition, variables map[string]interface{}, walking map[string]bool, introspecting bool) cost {
+	var total cost
+	if set == nil {
+		return total
+	}
+	for _, selection := range set.Selections {
+		var c cost
+		switch selection := selection.(type) {
+		case *ast.Field:
+			name := selection.Name.Value
+			inside := introspecting || strings.HasPrefix(name, "__")
+			c = queryCost(selection.SelectionSet, fragments, variables, walking, inside)
+			if inside {
+				c.introspection++
+				if introspecting && introspectionLists[name] {
+					c.lists++
+				}
+			} else {
+				c.depth++
+			}
+			c.complexity = 1 + pageSize(selection, variables)*c.complexity
+		case *ast.InlineFragment:
+			c = queryCost(selection.SelectionSet, fragments, variables, walking, introspecting)
+		case *ast.FragmentSpread:
+			fragment, exists := fragments[selection.Name.Value]
+			if !exists || walking[fragment.Name.Value] {
+				continue
+			}
+			walking[fragment.Name.Value] = true
+			c = queryCost(fragment.SelectionSet, fragments, variables, walking, introspecting)
+			delete(walking, fragment.Name.Value)
+		}
+		if c.depth > total.depth {
+			total.depth = c.depth
+		}
+		if c.introspection > total.introspection {
+			total.introspection = c.introspection
+		}
+		if c.lists > total.lists {
+			total.lists = c.lists
+		}
+		total.complexity += c.complexity
+	}
+	return total
+}
+
+// pageSize returns how many posts field returns at most, or 1 if it is not a list of posts.
+func pageSize(field *ast.Field, variables map[string]interface{}) int {
+	if field.Name.Value != "posts" && field.Name.Value != "search" {
+		return 1
+	}
+	first := defaultPageSize
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "first" {
+			continue
+		}
+		switch value := argument.Value.(type) {
+		case *ast.IntValue:
+			first, _ = strconv.Atoi(value.Value)
+		case *ast.Variable:
+			if number, ok := variables[value.Name.Value].(float64); ok {
+				first = int(number)
+			}
+		}
+	}
+	if first < 1 || first > maxPageSize {
+		first = maxPageSize
+	}
+	return first
+}
+
+// graphQLRequestOf returns the HTTP request of the context of a GraphQL resolver.
+func graphQLRequestOf(ctx context.Context) *http.Request {
+	return ctx.Value(graphQLContextKey{}).(*http.Request)
+}
+
+// cursor returns the cursor which points to post in a PostConnection.
+func cursor(post Post) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("post:" + strconv.FormatInt(post.ID, 10)))
+}
+
+// paginate returns the page of posts given by arguments first and after of a GraphQL field.
+// The page has first posts, starting after the post which cursor after points to.
+func paginate(posts []Post, args map[string]interface{}) (PostConnection, error) {
+	connection := PostConnection{Edges: make([]PostEdge, 0), TotalCount: len(posts)}
+	first := defaultPageSize
+	if value, ok := args["first"].(int); ok {
+		first = value
+	}
+	if first < 0 || first > maxPageSize {
+		return connection, BadRequest("invalid_first", fmt.Sprintf("First must be between 0 and %d.", maxPageSize))
+	}
+	start := 0
+	if after, ok := args["after"].(string); ok && after != "" {
+		start = -1
+		for i, post := range posts {
+			if cursor(post) == after {
+				start = i + 1
+				break
+			}
+		}
+		if start == -1 {
+			return connection, BadRequest("invalid_cursor", "After must be a cursor of a post of the list.")
+		}
+	}
+	for _, post := range posts[start:] {
+		if len(connection.Edges) == first {
+			connection.PageInfo.HasNextPage = true
+			break
+		}
+		connection.Edges = append(connection.Edges, PostEdge{Cursor: cursor(post), Node: post})
+	}
+	if len(connection.Edges) > 0 {
+		connection.PageInfo.EndCursor = connection.Edges[len(connection.Edges)-1].Cursor
+	}
+	return connection, nil
+}
+
+// publishedPostsOf returns the published posts of kind, tagged with tag unless it is empty.
+func publishedPostsOf(r *http.Request, kind, tag string) ([]Post, error) {
+	var post Post
+	posts, err := post.GetAll(r)
+	if err != nil {
+		return nil, err
+	}
+	filtered := make([]Post, 0)
+	for _, post := range publishedPosts(posts) {
+		if (kind == "" || post.Kind == kind) && (tag == "" || post.HasTag(tag)) {
+			filtered = append(filtered, post)
+		}
+	}
+	return filtered, nil
+}
+
+// HasTag or post.HasTag reports whether the post is tagged with tag, ignoring case.
+func (post Post) HasTag(tag string) bool {
+	for _, t := range splitTags(post.Tags) {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// allTags returns the tags of published posts, sorted by name.
+func allTags(r *http.Request) ([]Tag, error) {
+	posts, err := publishedPostsOf(r, "", "")
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	tags := make([]Tag, 0)
+	for _, post := range posts {
+		for _, name := range splitTags(post.Tags) {
+			if !seen[strings.ToLower(name)] {
+				seen[strings.ToLower(name)] = true
+				tags = append(tags, Tag{Name: name})
+			}
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool { return strings.ToLower(tags[i].Name) < strings.ToLower(tags[j].Name) })
+	return tags, nil
+}
+
+// postInput returns the post given in argument input of a mutation.
+func postInput(args map[string]interface{}) Post {
+	input, _ := args["input"].(map[string]interface{})
+	var post Post
+	post.Title, _ = input["title"].(string)
+	post.Markdown, _ = input["markdown"].(string)
+	post.Content, _ = input["content"].(string)
+	post.Format, _ = input["format"].(string)
+	post.Tags, _ = input["tags"].(string)
+	post.Slug, _ = input["slug"].(string)
+	post.Kind, _ = input["kind"].(string)
+	if parent, ok := input["parent"].(int); ok {
+		post.Parent = int64(parent)
+	}
+	post.MenuOrder, _ = input["menuOrder"].(int)
+	if series, ok := input["series"].(int); ok {
+		post.Series = int64(series)
+	}
+	post.Part, _ = input["part"].(int)
+	return post
+}
+
+// graphQLPost returns the post of the session user with the slug given in argument slug of a mutation.
+func graphQLPost(r *http.Request, args map[string]interface{}) (Post, error) {
+	var user User
+	if _, err := user.Session(r); err != nil {
+		return Post{}, Unauthorized()
+	}
+	var post Post
+	post.Slug, _ = args["slug"].(string)
+	post, err := post.Get(r)
+	if err != nil {
+		_, apierr := postError(err)
+		return post, apierr
+	}
+	return post, nil
+}
+
+// mustGraphQLSchema returns the schema of /api/graphql. It panics if the schema is invalid.
+func mustGraphQLSchema() graphql.Schema {
+	var postType, userType, tagType, connectionType *graphql.Object
+
+	connectionArgs := graphql.FieldConfigArgument{
+		"first": &graphql.ArgumentConfig{Type: graphql.Int, Description: fmt.Sprintf("Number of posts to return, %d by default and %d at most.", defaultPageSize, maxPageSize)},
+		"after": &graphql.ArgumentConfig{Type: graphql.String, Description: "Cursor of the post after which to start."},
+	}
+
+	pageInfoType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: graphql.Fields{
+			"hasNextPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"endCursor":   &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	postType = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Post",
+		Description: "A blog post or a page.",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":          &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+				"title":       &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"slug":        &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"content":     &graphql.Field{Type: graphql.String, Description: "HTML content of the post."},
+				"markdown":    &graphql.Field{Type: graphql.String, Description: "Markdown source of the post, if it is written in Markdown."},
+				"format":      &graphql.Field{Type: graphql.String},
+				"kind":        &graphql.Field{Type: graphql.String},
+				"excerpt":     &graphql.Field{Type: graphql.String},
+				"date":        &graphql.Field{Type: graphql.Int, Description: "Time the post was created as a Unix timestamp."},
+				"readingTime": &graphql.Field{Type: graphql.Int, Description: "Estimated reading time in minutes."},
+				"viewCount":   &graphql.Field{Type: graphql.Int},
+				"parent":      &graphql.Field{Type: graphql.Int},
+				"menuOrder":   &graphql.Field{Type: graphql.Int},
+				"series":      &graphql.Field{Type: graphql.Int},
+				"part":        &graphql.Field{Type: graphql.Int},
+				"published":   &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+				"tags": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(tagType))),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						tags := make([]Tag, 0)
+						for _, name := range splitTags(p.Source.(Post).Tags) {
+							tags = append(tags, Tag{Name: name})
+						}
+						return tags, nil
+					},
+				},
+				"author": &graphql.Field{
+					Type: userType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						user, err := User{ID: p.Source.(Post).Author}.Get()
+						if err != nil {
+							if err.Error() == "not found" {
+								return nil, nil
+							}
+							return nil, InternalServerError()
+						}
+						return user, nil
+					},
+				},
+			}
+		}),
+	})
+
+	edgeType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PostEdge",
+		Fields: graphql.Fields{
+			"cursor": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"node":   &graphql.Field{Type: graphql.NewNonNull(postType)},
+		},
+	})
+
+	connectionType = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "PostConnection",
+		Description: "A page of a list of posts. Pass endCursor of pageInfo as argument after to get the next page.",
+		Fields: graphql.Fields{
+			"edges":      &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(edgeType)))},
+			"pageInfo":   &graphql.Field{Type: graphql.NewNonNull(pageInfoType)},
+			"totalCount": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		},
+	})
+
+	userType = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "User",
+		Description: "An author of the blog.",
+		Fields: graphql.Fields{
+			"id":     &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"name":   &graphql.Field{Type: graphql.String},
+			"avatar": &graphql.Field{Type: graphql.String},
+			"role":   &graphql.Field{Type: graphql.String},
+			"posts": &graphql.Field{
+				Type:        graphql.NewNonNull(connectionType),
+				Description: "Posts of the user, newest first. Unpublished posts are only listed to the user themselves.",
+				Args:        connectionArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					user := p.Source.(User)
+					var session User
+					if session, err := session.Session(graphQLRequestOf(p.Context)); err != nil || session.ID != user.ID {
+						user.Posts = publishedPosts(user.Posts)
+					}
+					return paginate(user.Posts, p.Args)
+				},
+			},
+		},
+	})
+
+	tagType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Tag",
+		Fields: graphql.Fields{
+			"name": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"posts": &graphql.Field{
+				Type:        graphql.NewNonNull(connectionType),
+				Description: "Published posts and pages with the tag, newest first.",
+				Args:        connectionArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					posts, err := publishedPostsOf(graphQLRequestOf(p.Context), "", p.Source.(Tag).Name)
+					if err != nil {
+						return nil, InternalServerError()
+					}
+					return paginate(posts, p.Args)
+				},
+			},
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"post": &graphql.Field{
+				Type:        postType,
+				Description: "The post with the slug. Unpublished posts are only returned to their author.",
+				Args:        graphql.FieldConfigArgument{"slug": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					r := graphQLRequestOf(p.Context)
+					var post Post
+					post.Slug = p.Args["slug"].(string)
+					post, err := post.Get(r)
+					if err != nil {
+						if err.Error() == "not found" {
+							return nil, nil
+						}
+						return nil, InternalServerError()
+					}
+					if !post.Visible(r) {
+						return nil, nil
+					}
+					return post, nil
+				},
+			},
+			"posts": &graphql.Field{
+				Type:        graphql.NewNonNull(connectionType),
+				Description: "Published posts, newest first.",
+				Args: graphql.FieldConfigArgument{
+					"first": connectionArgs["first"],
+					"after": connectionArgs["after"],
+					"kind":  &graphql.ArgumentConfig{Type: graphql.String, Description: "Kind of the posts, post by default or page.", DefaultValue: KindPost},
+					"tag":   &graphql.ArgumentConfig{Type: graphql.String, Description: "Tag the posts must have."},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					kind, _ := p.Args["kind"].(string)
+					if !validKind(kind) {
+						return nil, BadRequest("invalid_kind", "Kind must be post or page.")
+					}
+					tag, _ := p.Args["tag"].(string)
+					posts, err := publishedPostsOf(graphQLRequestOf(p.Context), kind, tag)
+					if err != nil {
+						return nil, InternalServerError()
+					}
+					return paginate(posts, p.Args)
+				},
+			},
+			"user": &graphql.Field{
+				Type: userType,
+				Args: graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					user, err := User{ID: int64(p.Args["id"].(int))}.Get()
+					if err != nil {
+						if err.Error() == "not found" {
+							return nil, nil
+						}
+						return nil, InternalServerError()
+					}
+					return user, nil
+				},
+			},
+			"users": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(userType))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					var user User
+					users, err := user.GetAll(graphQLRequestOf(p.Context))
+					if err != nil {
+						return nil, InternalServerError()
+					}
+					return users, nil
+				},
+			},
+			"viewer": &graphql.Field{
+				Type:        userType,
+				Description: "The logged in user, or null without a session.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					var user User
+					user, err := user.Session(graphQLRequestOf(p.Context))
+					if err != nil {
+						return nil, nil
+					}
+					return user, nil
+				},
+			},
+			"tags": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(tagType))),
+				Description: "Tags of published posts, sorted by name.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					tags, err := allTags(graphQLRequestOf(p.Context))
+					if err != nil {
+						return nil, InternalServerError()
+					}
+					return tags, nil
+				},
+			},
+			"tag": &graphql.Field{
+				Type:        tagType,
+				Description: "The tag with the name, or null if no published post has it.",
+				Args:        graphql.FieldConfigArgument{"name": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					tags, err := allTags(graphQLRequestOf(p.Context))
+					if err != nil {
+						return nil, InternalServerError()
+					}
+					for _, tag := range tags {
+						if strings.EqualFold(tag.Name, p.Args["name"].(string)) {
+							return tag, nil
+						}
+					}
+					return nil, nil
+				},
+			},
+			"search": &graphql.Field{
+				Type:        graphql.NewNonNull(connectionType),
+				Description: "Published posts which contain the query in their title or content.",
+				Args: graphql.FieldConfigArgument{
+					"query": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"first": connectionArgs["first"],
+					"after": connectionArgs["after"],
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					r := graphQLRequestOf(p.Context)
+					search := Search{Query: p.Args["query"].(string)}
+					if errs := search.Validate(r, nil); len(errs) > 0 {
+						return nil, ValidationError(errs)
+					}
+					search, err := search.Get(r)
+					if err != nil {
+						return nil, InternalServerError()
+					}
+					return paginate(search.Posts, p.Args)
+				},
+			},
+		},
+	})
+
+	postInputType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name:        "PostInput",
+		Description: "Fields of a post. Fields which are left out keep their current values when updating a post.",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"title":     &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"markdown":  &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"content":   &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"format":    &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"tags":      &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Comma separated tags."},
+			"slug":      &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"kind":      &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"parent":    &graphql.InputObjectFieldConfig{Type: graphql.Int},
+			"menuOrder": &graphql.InputObjectFieldConfig{Type: graphql.Int},
+			"series":    &graphql.InputObjectFieldConfig{Type: graphql.Int},
+			"part":      &graphql.InputObjectFieldConfig{Type: graphql.Int},
+		},
+	})
+
+	mutationType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createPost": &graphql.Field{
+				Type:        graphql.NewNonNull(postType),
+				Description: "Creates a new post, as POST /api/v1/posts does. Requires active session.",
+				Args:        graphql.FieldConfigArgument{"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(postInputType)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					r := graphQLRequestOf(p.Context)
+					var user User
+					if _, err := user.Session(r); err != nil {
+						return nil, Unauthorized()
+					}
+					post := postInput(p.Args)
+					errs := post.Validate(r, nil)
+					errs = requireField(errs, "title", post.Title, "Title is required.")
+					if len(errs) > 0 {
+						return nil, ValidationError(errs)
+					}
+					post, err := post.Insert(r)
+					if err != nil {
+						_, apierr := postError(err)
+						return nil, apierr
+					}
+					// The post is saved, so the autosaved draft of the editor is no longer needed.
+					if err := deleteDraft(db, post.Author, 0); err != nil {
+						log.Println("graphql create post draft: ", err)
+					}
+					return post, nil
+				},
+			},
+			"updatePost": &graphql.Field{
+				Type:        graphql.NewNonNull(postType),
+				Description: "Updates a post, as PATCH /api/v1/posts/{slug} does. Only the author of the post is allowed.",
+				Args: graphql.FieldConfigArgument{
+					"slug":  &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(postInputType)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					r := graphQLRequestOf(p.Context)
+					post, err := graphQLPost(r, p.Args)
+					if err != nil {
+						return nil, err
+					}
+					input := postInput(p.Args)
+					if errs := input.Validate(r, nil); len(errs) > 0 {
+						return nil, ValidationError(errs)
+					}
+					if post, err = post.Edit(r, input); err != nil {
+						_, apierr := postError(err)
+						return nil, apierr
+					}
+					return post, nil
+				},
+			},
+			"publishPost": &graphql.Field{
+				Type:        graphql.NewNonNull(postType),
+				Description: "Publishes a post, as PUT /api/v1/posts/{slug}/published does. Only the author of the post is allowed.",
+				Args:        graphql.FieldConfigArgument{"slug": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					r := graphQLRequestOf(p.Context)
+					post, err := graphQLPost(r, p.Args)
+					if err != nil {
+						return nil, err
+					}
+					if post, err = post.Publish(r); err != nil {
+						_, apierr := postError(err)
+						return nil, apierr
+					}
+					return post, nil
+				},
+			},
+			"unpublishPost": &graphql.Field{
+				Type:        graphql.NewNonNull(postType),
+				Description: "Unpublishes a post, as DELETE /api/v1/posts/{slug}/published does. Only the author of the post is allowed.",
+				Args:        graphql.FieldConfigArgument{"slug": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					r := graphQLRequestOf(p.Context)
+					post, err := graphQLPost(r, p.Args)
+					if err != nil {
+						return nil, err
+					}
+					if err := post.Unpublish(r); err != nil {
+						_, apierr := postError(err)
+						return nil, apierr
+					}
+					post.Published = false
+					return post, nil
+				},
+			},
+		},
+	})
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{Query: queryType, Mutation: mutationType})
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
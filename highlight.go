@@ -0,0 +1,103 @@
+// Highlight.go adds syntax highlighting to fenced code blocks of Markdown posts. Code is highlighted
+// once when a post is rendered, and colored by the theme stylesheet chosen in settings, so readers
+// need no JavaScript. Each line is wrapped in <span class="line">, which lets the line number
+// stylesheet count them with CSS counters.
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/russross/blackfriday"
+	"github.com/sourcegraph/syntaxhighlight"
+)
+
+// CodeThemes lists the color themes of highlighted code, each of which has a stylesheet
+// at /css/highlight-<theme>.css.
+var CodeThemes = []string{"github", "monokai", "solarized-light"}
+
+// codeTheme returns the theme chosen in settings, or the first one if none is chosen.
+func codeTheme() string {
+	for _, theme := range CodeThemes {
+		if theme == Settings.CodeTheme {
+			return theme
+		}
+	}
+	return CodeThemes[0]
+}
+
+// highlightClasses matches the classes highlightCode gives to spans.
+var highlightClasses = regexp.MustCompile(`^(line|str|kwd|com|typ|lit|pun|pln|tag|htm|atn|atv|dec)$`)
+
+// highlightRenderer is the Blackfriday HTML renderer with highlighting of fenced code blocks.
+// Code blocks without a language are rendered as usual.
+type highlightRenderer struct {
+	blackfriday.Renderer
+}
+
+func (r highlightRenderer) BlockCode(out *bytes.Buffer, text []byte, info string) {
+	fields := strings.Fields(info)
+	if len(fields) == 0 {
+		r.Renderer.BlockCode(out, text, info)
+		return
+	}
+	code, err := highlightCode(text)
+	if err != nil {
+		r.Renderer.BlockCode(out, text, info)
+		return
+	}
+	if out.Len() > 0 {
+		out.WriteByte('\n')
+	}
+	out.WriteString(`<pre class="highlight"><code class="language-`)
+	template.HTMLEscape(out, []byte(fields[0]))
+	out.WriteString(`">`)
+	out.Write(code)
+	out.WriteString("</code></pre>\n")
+}
+
+// highlightCode returns src as HTML with its tokens in spans classed the way
+// google-code-prettify does it, such as "kwd" for keywords and "str" for strings.
+func highlightCode(src []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(`<span class="line">`)
+	src = bytes.TrimRight(src, "\n")
+	if err := syntaxhighlight.Print(syntaxhighlight.NewScanner(src), &buf, linePrinter(syntaxhighlight.DefaultHTMLConfig)); err != nil {
+		return nil, err
+	}
+	buf.WriteString(`</span>`)
+	return buf.Bytes(), nil
+}
+
+// linePrinter prints tokens like syntaxhighlight.HTMLPrinter, but closes and reopens the line span
+// at every line break, splitting tokens such as block comments which span many lines.
+type linePrinter syntaxhighlight.HTMLConfig
+
+func (p linePrinter) Print(w io.Writer, kind syntaxhighlight.Kind, text string) error {
+	class := syntaxhighlight.HTMLConfig(p).Class(kind)
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			if _, err := io.WriteString(w, "</span>\n<span class=\"line\">"); err != nil {
+				return err
+			}
+		}
+		if line == "" {
+			continue
+		}
+		if class != "" {
+			if _, err := io.WriteString(w, `<span class="`+class+`">`); err != nil {
+				return err
+			}
+		}
+		template.HTMLEscape(w, []byte(line))
+		if class != "" {
+			if _, err := io.WriteString(w, `</span>`); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+// Import.go contains what is shared between the importers of content from other blogs:
+// the per-item summary they report and the routes of the admin import page.
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+)
+
+// ImportItem describes what happened to a single item of an import.
+type ImportItem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Slug   string `json:"slug,omitempty"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Statuses of an ImportItem.
+const (
+	ImportImported = "imported"
+	ImportUpdated  = "updated"
+	ImportSkipped  = "skipped"
+)
+
+// ImportSummary lists every item an importer came across.
+type ImportSummary struct {
+	Imported int          `json:"imported"`
+	Updated  int          `json:"updated"`
+	Skipped  int          `json:"skipped"`
+	Items    []ImportItem `json:"items"`
+}
+
+func (summary *ImportSummary) add(item ImportItem) {
+	switch item.Status {
+	case ImportImported:
+		summary.Imported++
+	case ImportUpdated:
+		summary.Updated++
+	case ImportSkipped:
+		summary.Skipped++
+	}
+	summary.Items = append(summary.Items, item)
+}
+
+func (summary *ImportSummary) imported(kind, title, slug string) {
+	summary.add(ImportItem{Type: kind, Title: title, Slug: slug, Status: ImportImported})
+}
+
+func (summary *ImportSummary) skipped(kind, title, reason string) {
+	summary.add(ImportItem{Type: kind, Title: title, Status: ImportSkipped, Reason: reason})
+}
+
+// Print writes a human readable version of the summary to w.
+func (summary ImportSummary) Print(w io.Writer) {
+	fmt.Fprintln(w, "TYPE\tSTATUS\tTITLE\tSLUG\tREASON")
+	for _, item := range summary.Items {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", item.Type, item.Status, item.Title, item.Slug, item.Reason)
+	}
+	fmt.Fprintf(w, "\n%d imported, %d updated, %d skipped\n", summary.Imported, summary.Updated, summary.Skipped)
+}
+
+// ImportBlog is a route which imports an uploaded WordPress export file.
+// Posts without a known author are assigned to the user doing the import.
+// Only available to admins.
+func ImportBlog(w http.ResponseWriter, r *http.Request) {
+	var user User
+	user, err := user.Session(r)
+	if err != nil {
+		log.Println("importblog session: ", err)
+		rend.JSON(w, http.StatusUnauthorized, Unauthorized())
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		rend.HTML(w, http.StatusBadRequest, "user/import", Page{Err: "Please choose a WordPress export file to import."})
+		return
+	}
+	defer file.Close()
+
+	summary, err := ImportWordPress(file, user.ID)
+	if err != nil {
+		log.Println("importblog import: ", err)
+		rend.HTML(w, http.StatusBadRequest, "user/import", Page{Err: "The file could not be imported: " + err.Error()})
+		return
+	}
+	rend.HTML(w, http.StatusOK, "user/import", Page{Data: summary})
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"net/http"
+)
+
+/*
+This is an autogenerated file by autobindings
+*/
+
+import (
+	"github.com/mholt/binding"
+)
+
+func (l *Login) FieldMap() binding.FieldMap {
+	return binding.FieldMap{
+		&l.Email:    "email",
+		&l.Password: "password",
+	}
+}
+
+func (l *Login) Validate(req *http.Request, errs binding.Errors) binding.Errors {
+	errs = requireField(errs, "email", l.Email, "Email is required.")
+	if l.Email != "" && !validEmail(l.Email) {
+		errs.Add([]string{"email"}, ErrInvalidEmail, "Email is not a valid email address.")
+	}
+	errs = requireField(errs, "password", l.Password, "Password is required.")
+    return errs
+}
@@ -1,7 +1,7 @@
 package main
 
 import (
-	"html"
+	"flag"
 	"html/template"
 	"log"
 	"net/http"
@@ -13,6 +13,7 @@ import (
 	"github.com/justinas/alice"
 	//"github.com/justinas/nosurf"
 	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
 	"github.com/unrolled/render"
 )
 
@@ -21,15 +22,19 @@ func timeoutHandler(h http.Handler) http.Handler {
 }
 
 var helpers = template.FuncMap{
-	// Unescape unescapes and parses HTML from database objects.
+	// Unescape marks HTML from database objects safe to display as is.
+	// Post content is sanitized when it is saved, so entities must not be decoded here,
+	// as that would turn escaped text such as &lt;script&gt; back into markup.
 	// Used in templates such as "/post/display.tmpl"
 	"unescape": func(s string) template.HTML {
-		return template.HTML(html.UnescapeString(s))
+		return template.HTML(s)
 	},
 	// Title renders post name as a page title.
 	"title": func(t interface{}) string {
-		post, exists := t.(Post)
-		if exists {
+		switch post := t.(type) {
+		case Post:
+			return post.Title
+		case PostForm:
 			return post.Title
 		}
 		return Settings.Name
@@ -64,36 +69,103 @@ var helpers = template.FuncMap{
 		}
 		return os.Getenv(s)
 	},
-	// Markdown returns whether user has Markdown enabled from settings.
-	"Markdown": func() bool {
-		if Settings.Markdown {
-			return true
+	// Codetheme returns the color theme of highlighted code chosen in settings.
+	// Used in "/layout.tmpl".
+	"codetheme": codeTheme,
+	// Codethemes returns all color themes of highlighted code.
+	// Used in "/settings.tmpl".
+	"codethemes": func() []string {
+		return CodeThemes
+	},
+	// Linenumbers returns whether highlighted code shows line numbers.
+	"linenumbers": func() bool {
+		return Settings.LineNumbers
+	},
+	// Comments returns approved comments of a post.
+	// Used in "/post/display.tmpl".
+	"comments": func(p Post) []Comment {
+		comments, err := p.Comments()
+		if err != nil {
+			log.Println("comments helper: ", err)
+		}
+		return comments
+	},
+	// Webmentions returns approved webmentions of a post by their type.
+	// Used in "/post/display.tmpl".
+	"webmentions": func(p Post) Webmentions {
+		mentions, err := p.Webmentions()
+		if err != nil {
+			log.Println("webmentions helper: ", err)
+		}
+		return mentions
+	},
+	// Menu returns the navigation menu made of pages.
+	// Used in "/layout.tmpl".
+	"menu": func() []MenuItem {
+		menu, err := Menu()
+		if err != nil {
+			log.Println("menu helper: ", err)
 		}
-		return false
+		return menu
 	},
-	// ReadOnly checks whether a post is safe to edit with current settings.
-	"ReadOnly": func(p Post) bool {
-		if Settings.Markdown && p.Markdown == "" {
-			return true
+	// Series returns which part of its series the post is, or nil if it is not part of one.
+	// Used in "/post/display.tmpl".
+	"series": func(p Post) *SeriesNav {
+		nav, err := p.SeriesNav()
+		if err != nil {
+			log.Println("series helper: ", err)
 		}
-		return false
+		return nav
+	},
+	// Authorseries returns the series of a user, which the editor offers for their posts.
+	// Used in "/post/new.tmpl", "/post/edit.tmpl" and "/user/index.tmpl".
+	"authorseries": func(author int64) []Series {
+		series, err := AllSeries(author)
+		if err != nil {
+			log.Println("authorseries helper: ", err)
+		}
+		return series
+	},
+	// Markdown renders Markdown, such as descriptions of the API, as HTML.
+	// Used in "/api/index.tmpl".
+	"markdown": func(s string) template.HTML {
+		return template.HTML(renderMarkdown(s))
+	},
+	// Recovery returns whether password recovery is enabled in settings.
+	// Used in "/user/login.tmpl".
+	"recovery": func() bool {
+		return Settings.Recovery
+	},
+	// Pages returns every page, which the editor offers as parents.
+	// Used in "/post/new.tmpl" and "/post/edit.tmpl".
+	"pages": func() []Post {
+		pages, err := Pages()
+		if err != nil {
+			log.Println("pages helper: ", err)
+		}
+		return pages
 	},
 }
 
-var rend = render.New(render.Options{
-	Directory:  "templates",
-	Layout:     "layout",
-	Extensions: []string{".tmpl", ".html"},
-	Delims:     render.Delims{"{[", "]}"},
-	Funcs:      []template.FuncMap{helpers},
-})
+var rend *render.Render
+
+// newRender returns renderer for templates found in Config.Templates.
+func newRender() *render.Render {
+	return render.New(render.Options{
+		Directory:  Config.Templates,
+		Layout:     "layout",
+		Extensions: []string{".tmpl", ".html"},
+		Delims:     render.Delims{"{[", "]}"},
+		Funcs:      []template.FuncMap{helpers},
+	})
+}
 
 // Context
 type key int
 
 const MyKey key = 0
 
-var db = initDB()
+var db *gorm.DB
 
 var logit = new(LogWriter)
 
@@ -101,15 +173,22 @@ func init() {
 	logit.Enable()
 }
 
+// NewServer returns the handler of the site: its router wrapped with the middleware of every request.
 func NewServer() http.Handler {
+	return alice.New(Logger, DeprecatedAPI).Then(NewRouter())
+}
+
+// NewRouter returns the router of every route of the site. GenerateOpenAPI walks it to document the API.
+func NewRouter() *mux.Router {
 	th := throttled.Interval(throttled.PerSec(10), 1, &throttled.VaryBy{Path: true}, 50)
 
 	r := mux.NewRouter()
 
 	// Handle Static files
-	r.Handle("/css/{rest}", http.StripPrefix("/css/", http.FileServer(http.Dir("./public/css/")))).Methods("GET")
-	r.Handle("/js/{rest}", http.StripPrefix("/js/", http.FileServer(http.Dir("./public/js/")))).Methods("GET")
-	r.Handle("/uploads/{rest}", http.StripPrefix("/uploads/", http.FileServer(http.Dir("./public/uploads/")))).Methods("GET")
+	r.Handle("/css/{rest}", http.StripPrefix("/css/", http.FileServer(http.Dir(Config.StaticDir("css"))))).Methods("GET")
+	r.Handle("/js/{rest}", http.StripPrefix("/js/", http.FileServer(http.Dir(Config.StaticDir("js"))))).Methods("GET")
+	// Uploads may be nested, such as the year and month directories of imported WordPress media.
+	r.PathPrefix("/uploads/").Handler(http.StripPrefix("/uploads/", http.FileServer(http.Dir(Config.Uploads)))).Methods("GET")
 
 	// Handle Root
 	r.Handle("/", alice.New(th.Throttle, timeoutHandler).Then(http.HandlerFunc(Homepage))).Methods("GET")
@@ -129,9 +208,7 @@ func NewServer() http.Handler {
 	// Please note that `/new` route has to be before the `/:slug` route. Otherwise the program will try
 	// to fetch for Post named "new".
 	// For now I'll keep it this way to streamline route naming.
-	r.Handle("/post/new", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		rend.HTML(w, http.StatusOK, "post/new", nil)
-	}))).Methods("GET")
+	r.Handle("/post/new", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(NewPost))).Methods("GET")
 	r.Handle("/post/new", alice.New(th.Throttle, timeoutHandler, ProtectedPage, StrictWWWFormUrlEncoded).Then(http.HandlerFunc(CreatePost))).Methods("POST")
 	r.Handle("/post/search", alice.New(th.Throttle, timeoutHandler, StrictWWWFormUrlEncoded).Then(http.HandlerFunc(SearchPost))).Methods("POST")
 	r.HandleFunc("/post/{slug}", ReadPost).Methods("GET")
@@ -140,43 +217,75 @@ func NewServer() http.Handler {
 	r.Handle("/post/{slug}/delete", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(DeletePost))).Methods("GET")
 	r.Handle("/post/{slug}/publish", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(PublishPost))).Methods("GET")
 	r.Handle("/post/{slug}/unpublish", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(UnpublishPost))).Methods("GET")
+	r.Handle("/post/{slug}/previews", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(ReadPreviews))).Methods("GET")
+	r.Handle("/post/{slug}/previews", alice.New(th.Throttle, timeoutHandler, ProtectedPage, StrictWWWFormUrlEncoded).Then(http.HandlerFunc(CreatePreview))).Methods("POST")
+	r.Handle("/post/{slug}/previews/{id}/delete", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(RevokePreview))).Methods("GET")
+
+	// route: /series
+	r.Handle("/series/new", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(NewSeries))).Methods("GET")
+	r.Handle("/series/new", alice.New(th.Throttle, timeoutHandler, ProtectedPage, StrictWWWFormUrlEncoded).Then(http.HandlerFunc(CreateSeries))).Methods("POST")
+	r.HandleFunc("/series/{slug}", ReadSeries).Methods("GET")
+	r.HandleFunc("/series/{slug}/atom", ReadSeriesFeed).Methods("GET")
+	r.HandleFunc("/series/{slug}/rss", ReadSeriesFeed).Methods("GET")
+	r.Handle("/series/{slug}/edit", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(EditSeries))).Methods("GET")
+	r.Handle("/series/{slug}/edit", alice.New(th.Throttle, timeoutHandler, ProtectedPage, StrictWWWFormUrlEncoded).Then(http.HandlerFunc(UpdateSeries))).Methods("POST")
+	r.Handle("/series/{slug}/delete", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(DeleteSeries))).Methods("GET")
 
 	// route: /user
 	r.Handle("/user", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(ReadUser))).Methods("GET")
 	r.Handle("/user/login", alice.New(th.Throttle, timeoutHandler, SessionRedirect).Then(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		rend.HTML(w, http.StatusOK, "user/login", nil)
+		rend.HTML(w, http.StatusOK, "user/login", Page{Data: Login{}})
 	}))).Methods("GET")
 	r.Handle("/user/login", alice.New(th.Throttle, timeoutHandler, SessionRedirect, StrictWWWFormUrlEncoded).Then(http.HandlerFunc(LoginUser))).Methods("POST")
 	//r.Handle("/user/login", alice.New(th.Throttle, timeoutHandler).Then(http.HandlerFunc(LoginUser))).Methods("POST")
 	r.HandleFunc("/user/logout", LogoutUser).Methods("GET")
+	r.Handle("/user/import", alice.New(th.Throttle, timeoutHandler, ProtectedPage, AdminPage).Then(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rend.HTML(w, http.StatusOK, "user/import", Page{})
+	}))).Methods("GET")
+	// Imports can take longer than timeoutHandler allows.
+	r.Handle("/user/import", alice.New(th.Throttle, ProtectedPage, AdminPage).Then(http.HandlerFunc(ImportBlog))).Methods("POST")
+	r.Handle("/user/webhooks", alice.New(th.Throttle, timeoutHandler, ProtectedPage, AdminPage).Then(http.HandlerFunc(ReadWebhooks))).Methods("GET")
+	r.Handle("/user/webhooks", alice.New(th.Throttle, timeoutHandler, ProtectedPage, AdminPage, StrictWWWFormUrlEncoded).Then(http.HandlerFunc(CreateWebhook))).Methods("POST")
+	r.Handle("/user/webhooks/{id}/delete", alice.New(th.Throttle, timeoutHandler, ProtectedPage, AdminPage).Then(http.HandlerFunc(DeleteWebhook))).Methods("GET")
+	r.Handle("/user/webhooks/{id}/test", alice.New(th.Throttle, ProtectedPage, AdminPage).Then(http.HandlerFunc(TestWebhook))).Methods("POST")
+	r.Handle("/user/tokens", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(ReadTokens))).Methods("GET")
+	r.Handle("/user/tokens", alice.New(th.Throttle, timeoutHandler, ProtectedPage, StrictWWWFormUrlEncoded).Then(http.HandlerFunc(CreateToken))).Methods("POST")
+	r.Handle("/user/tokens/{id}/delete", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(DeleteToken))).Methods("GET")
+	r.Handle("/user/followers", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(ReadFollowers))).Methods("GET")
+	r.Handle("/user/followers/{id}/delete", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(RemoveFollower))).Methods("GET")
+	r.Handle("/user/webmentions", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(ReadWebmentions))).Methods("GET")
+	r.Handle("/user/webmentions/{id}/approve", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(ApproveWebmention))).Methods("GET")
+	r.Handle("/user/webmentions/{id}/hide", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(HideWebmention))).Methods("GET")
+	r.Handle("/user/webmentions/{id}/delete", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(DeleteWebmention))).Methods("GET")
 	r.Handle("/user/settings", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(ReadBlogSettings))).Methods("GET")
 	r.Handle("/user/settings", alice.New(th.Throttle, timeoutHandler, ProtectedPage, StrictWWWFormUrlEncoded).Then(http.HandlerFunc(UpdateBlogSettings))).Methods("POST")
 	r.Handle("/user/installation", alice.New(th.Throttle, timeoutHandler, StrictWWWFormUrlEncoded).Then(http.HandlerFunc(UpdateBlogSettings))).Methods("POST")
 	r.Handle("/user/register", alice.New(th.Throttle, timeoutHandler, SessionRedirect).Then(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		rend.HTML(w, http.StatusOK, "user/register", nil)
+		rend.HTML(w, http.StatusOK, "user/register", Page{Data: User{}})
 	}))).Methods("GET")
 	r.Handle("/user/register", alice.New(th.Throttle, timeoutHandler, ProtectedPage, StrictWWWFormUrlEncoded).Then(http.HandlerFunc(CreateUser))).Methods("POST")
 	r.Handle("/user/recover", alice.New(th.Throttle, timeoutHandler, SessionRedirect).Then(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		rend.HTML(w, http.StatusOK, "user/recover", nil)
+		rend.HTML(w, http.StatusOK, "user/recover", Page{Data: RecoveryRequest{}})
 	}))).Methods("GET")
 	r.Handle("/user/recover", alice.New(th.Throttle, timeoutHandler, ProtectedPage, StrictWWWFormUrlEncoded).Then(http.HandlerFunc(RecoverUser))).Methods("POST")
 	r.Handle("/user/reset/{id}/{recovery}", alice.New(th.Throttle, timeoutHandler, SessionRedirect).Then(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		rend.HTML(w, http.StatusOK, "user/reset", nil)
+		rend.HTML(w, http.StatusOK, "user/reset", Page{Data: PasswordReset{}})
 	}))).Methods("GET")
 	r.Handle("/user/reset/{id}/{recovery}", alice.New(th.Throttle, timeoutHandler, ProtectedPage, StrictWWWFormUrlEncoded).Then(http.HandlerFunc(ResetUserPassword))).Methods("POST")
 	//r.Post("/delete", strict.ContentType("application/x-www-form-urlencoded"), ProtectedPage, binding.Form(User{}), DeleteUser)
 
 	// route: /api
-	r.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
-		rend.HTML(w, http.StatusOK, "api/index", nil)
-	})
-	r.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
-		rend.HTML(w, http.StatusOK, "api/index", nil)
-	})
+	r.HandleFunc("/api", ReadAPIIndex(r))
+	r.HandleFunc("/api/", ReadAPIIndex(r))
+	r.HandleFunc("/api/openapi.json", ReadOpenAPI(r)).Methods("GET")
+	r.Handle("/api/graphql", alice.New(th.Throttle, timeoutHandler).Then(http.HandlerFunc(ServeGraphQL))).Methods("GET")
+	r.Handle("/api/graphql", alice.New(th.Throttle, timeoutHandler, StrictJSON).Then(http.HandlerFunc(ServeGraphQL))).Methods("POST")
 	r.Handle("/api/settings", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(ReadBlogSettings))).Methods("GET")
 	r.Handle("/api/settings", alice.New(th.Throttle, timeoutHandler, ProtectedPage, StrictJSON).Then(http.HandlerFunc(UpdateBlogSettings))).Methods("POST")
 	r.Handle("/api/installation", alice.New(th.Throttle, timeoutHandler, StrictJSON).Then(http.HandlerFunc(UpdateBlogSettings))).Methods("POST")
 	r.HandleFunc("/api/users", ReadUsers).Methods("GET")
+	// Backups can take longer than timeoutHandler allows.
+	r.Handle("/api/backup", alice.New(th.Throttle, ProtectedPage, AdminPage).Then(http.HandlerFunc(ReadBackup))).Methods("GET")
 
 	r.Handle("/api/user/login", alice.New(th.Throttle, timeoutHandler, SessionRedirect, StrictJSON).Then(http.HandlerFunc(LoginUser))).Methods("POST")
 	//r.Handle("/api/user/login", alice.New(th.Throttle, timeoutHandler).Then(http.HandlerFunc(LoginUser))).Methods("POST")
@@ -193,16 +302,114 @@ func NewServer() http.Handler {
 	r.Handle("/api/post/{slug}/publish", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(PublishPost))).Methods("GET")
 	r.Handle("/api/post/{slug}/unpublish", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(UnpublishPost))).Methods("GET")
 	r.Handle("/api/post/{slug}/delete", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(DeletePost))).Methods("GET")
+	r.Handle("/api/post/{slug}/previews", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(ReadPreviews))).Methods("GET")
+	r.Handle("/api/post/{slug}/previews", alice.New(th.Throttle, timeoutHandler, ProtectedPage, StrictJSON).Then(http.HandlerFunc(CreatePreview))).Methods("POST")
+	r.Handle("/api/post/{slug}/previews/{id}/delete", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(RevokePreview))).Methods("GET")
 	r.Handle("/api/post", alice.New(th.Throttle, timeoutHandler, ProtectedPage, StrictJSON).Then(http.HandlerFunc(CreatePost))).Methods("POST")
+	r.Handle("/api/post/preview", alice.New(th.Throttle, timeoutHandler, ProtectedPage, StrictJSON).Then(http.HandlerFunc(PreviewPost))).Methods("POST")
+	r.Handle("/api/draft/{post}", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(ReadDraft))).Methods("GET")
+	r.Handle("/api/draft/{post}", alice.New(th.Throttle, timeoutHandler, ProtectedPage, StrictJSON).Then(http.HandlerFunc(SaveDraft))).Methods("POST")
+	r.Handle("/api/draft/{post}/delete", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(DeleteDraft))).Methods("GET")
 	r.Handle("/api/post/search", alice.New(th.Throttle, timeoutHandler, StrictJSON).Then(http.HandlerFunc(SearchPost))).Methods("POST")
+	r.HandleFunc("/api/series", ReadAllSeries).Methods("GET")
+	r.Handle("/api/series", alice.New(th.Throttle, timeoutHandler, ProtectedPage, StrictJSON).Then(http.HandlerFunc(CreateSeries))).Methods("POST")
+	r.HandleFunc("/api/series/{slug}", ReadSeries).Methods("GET")
+	r.Handle("/api/series/{slug}/edit", alice.New(th.Throttle, timeoutHandler, ProtectedPage, StrictJSON).Then(http.HandlerFunc(UpdateSeries))).Methods("POST")
+	r.Handle("/api/series/{slug}/delete", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(DeleteSeries))).Methods("GET")
+
+	// route: /api/v1
+	// The routes above under /api/ are deprecated aliases of these, see DeprecatedAPI.
+	r.HandleFunc("/api/v1", ReadAPIIndex(r))
+	v1 := r.PathPrefix("/api/v1").Subrouter()
+	v1.HandleFunc("/", ReadAPIIndex(r))
+	v1.HandleFunc("/users", ReadUsers).Methods("GET")
+	v1.Handle("/users", alice.New(th.Throttle, timeoutHandler, StrictJSON).Then(http.HandlerFunc(CreateUser))).Methods("POST")
+	v1.HandleFunc("/users/{id}", ReadUser).Methods("GET")
+	v1.Handle("/session", alice.New(th.Throttle, timeoutHandler, SessionRedirect, StrictJSON).Then(http.HandlerFunc(LoginUser))).Methods("POST")
+	v1.HandleFunc("/session", LogoutUser).Methods("DELETE")
+	v1.Handle("/recoveries", alice.New(th.Throttle, timeoutHandler, StrictJSON).Then(http.HandlerFunc(RecoverUser))).Methods("POST")
+	v1.Handle("/recoveries/{id}/{recovery}", alice.New(th.Throttle, timeoutHandler, StrictJSON).Then(http.HandlerFunc(ResetUserPassword))).Methods("POST")
+	v1.Handle("/settings", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(ReadBlogSettings))).Methods("GET")
+	v1.Handle("/settings", alice.New(th.Throttle, timeoutHandler, ProtectedPage, StrictJSON).Then(http.HandlerFunc(UpdateBlogSettings))).Methods("PUT")
+	v1.Handle("/installation", alice.New(th.Throttle, timeoutHandler, StrictJSON).Then(http.HandlerFunc(UpdateBlogSettings))).Methods("POST")
+	// Backups can take longer than timeoutHandler allows.
+	v1.Handle("/backup", alice.New(th.Throttle, ProtectedPage, AdminPage).Then(http.HandlerFunc(ReadBackup))).Methods("GET")
+	v1.Handle("/webhooks", alice.New(th.Throttle, timeoutHandler, ProtectedPage, AdminPage).Then(http.HandlerFunc(ReadWebhooks))).Methods("GET")
+	v1.Handle("/webhooks", alice.New(th.Throttle, timeoutHandler, ProtectedPage, AdminPage, StrictJSON).Then(http.HandlerFunc(CreateWebhook))).Methods("POST")
+	v1.Handle("/webhooks/{id}", alice.New(th.Throttle, timeoutHandler, ProtectedPage, AdminPage).Then(http.HandlerFunc(ReadWebhook))).Methods("GET")
+	v1.Handle("/webhooks/{id}", alice.New(th.Throttle, timeoutHandler, ProtectedPage, AdminPage, StrictJSON).Then(http.HandlerFunc(UpdateWebhook))).Methods("PUT")
+	v1.Handle("/webhooks/{id}", alice.New(th.Throttle, timeoutHandler, ProtectedPage, AdminPage).Then(http.HandlerFunc(DeleteWebhook))).Methods("DELETE")
+	v1.Handle("/webhooks/{id}/deliveries", alice.New(th.Throttle, timeoutHandler, ProtectedPage, AdminPage).Then(http.HandlerFunc(ReadWebhookDeliveries))).Methods("GET")
+	v1.Handle("/webhooks/{id}/test", alice.New(th.Throttle, ProtectedPage, AdminPage).Then(http.HandlerFunc(TestWebhook))).Methods("POST")
+	v1.Handle("/tokens", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(ReadTokens))).Methods("GET")
+	v1.Handle("/tokens", alice.New(th.Throttle, timeoutHandler, ProtectedPage, StrictJSON).Then(http.HandlerFunc(CreateToken))).Methods("POST")
+	v1.Handle("/tokens/{id}", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(DeleteToken))).Methods("DELETE")
+	v1.Handle("/followers", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(ReadFollowers))).Methods("GET")
+	v1.Handle("/followers/{id}", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(RemoveFollower))).Methods("DELETE")
+	v1.Handle("/comments", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(ReadComments))).Methods("GET")
+	v1.Handle("/comments/{id}/approved", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(ApproveComment))).Methods("PUT")
+	v1.Handle("/comments/{id}/approved", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(HideComment))).Methods("DELETE")
+	v1.Handle("/comments/{id}", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(DeleteComment))).Methods("DELETE")
+	v1.Handle("/webmentions", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(ReadWebmentions))).Methods("GET")
+	v1.Handle("/webmentions/{id}/approved", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(ApproveWebmention))).Methods("PUT")
+	v1.Handle("/webmentions/{id}/approved", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(HideWebmention))).Methods("DELETE")
+	v1.Handle("/webmentions/{id}", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(DeleteWebmention))).Methods("DELETE")
+	v1.Handle("/search", alice.New(th.Throttle, timeoutHandler, StrictJSON).Then(http.HandlerFunc(SearchPost))).Methods("POST")
+	v1.HandleFunc("/posts", ReadPosts).Methods("GET")
+	v1.Handle("/posts", alice.New(th.Throttle, timeoutHandler, ProtectedPage, StrictJSON).Then(http.HandlerFunc(CreatePost))).Methods("POST")
+	v1.Handle("/posts/preview", alice.New(th.Throttle, timeoutHandler, ProtectedPage, StrictJSON).Then(http.HandlerFunc(PreviewPost))).Methods("POST")
+	v1.HandleFunc("/posts/{slug}", ReadPost).Methods("GET")
+	v1.Handle("/posts/{slug}", alice.New(th.Throttle, timeoutHandler, ProtectedPage, StrictJSON).Then(http.HandlerFunc(UpdatePost))).Methods("PATCH")
+	v1.Handle("/posts/{slug}", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(DeletePost))).Methods("DELETE")
+	v1.Handle("/posts/{slug}/published", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(PublishPost))).Methods("PUT")
+	v1.Handle("/posts/{slug}/published", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(UnpublishPost))).Methods("DELETE")
+	v1.Handle("/posts/{slug}/previews", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(ReadPreviews))).Methods("GET")
+	v1.Handle("/posts/{slug}/previews", alice.New(th.Throttle, timeoutHandler, ProtectedPage, StrictJSON).Then(http.HandlerFunc(CreatePreview))).Methods("POST")
+	v1.Handle("/posts/{slug}/previews/{id}", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(RevokePreview))).Methods("DELETE")
+	v1.Handle("/drafts/{post}", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(ReadDraft))).Methods("GET")
+	v1.Handle("/drafts/{post}", alice.New(th.Throttle, timeoutHandler, ProtectedPage, StrictJSON).Then(http.HandlerFunc(SaveDraft))).Methods("PUT")
+	v1.Handle("/drafts/{post}", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(DeleteDraft))).Methods("DELETE")
+	v1.HandleFunc("/series", ReadAllSeries).Methods("GET")
+	v1.Handle("/series", alice.New(th.Throttle, timeoutHandler, ProtectedPage, StrictJSON).Then(http.HandlerFunc(CreateSeries))).Methods("POST")
+	v1.HandleFunc("/series/{slug}", ReadSeries).Methods("GET")
+	v1.Handle("/series/{slug}", alice.New(th.Throttle, timeoutHandler, ProtectedPage, StrictJSON).Then(http.HandlerFunc(UpdateSeries))).Methods("PATCH")
+	v1.Handle("/series/{slug}", alice.New(th.Throttle, timeoutHandler, ProtectedPage).Then(http.HandlerFunc(DeleteSeries))).Methods("DELETE")
+
+	// route: /micropub
+	r.Handle("/micropub", alice.New(th.Throttle, timeoutHandler).Then(http.HandlerFunc(ServeMicropub))).Methods("GET", "POST")
+	r.Handle("/micropub/media", alice.New(th.Throttle).Then(http.HandlerFunc(ServeMicropubMedia))).Methods("POST")
+
+	// route: /webmention
+	r.Handle("/webmention", alice.New(th.Throttle, timeoutHandler, StrictWWWFormUrlEncoded).Then(http.HandlerFunc(ReceiveWebmention))).Methods("POST")
+
+	// route: /activitypub
+	r.Handle("/.well-known/webfinger", alice.New(th.Throttle, timeoutHandler).Then(http.HandlerFunc(ReadWebFinger))).Methods("GET")
+	r.Handle("/activitypub/authors/{id}", alice.New(th.Throttle, timeoutHandler).Then(http.HandlerFunc(ReadActor))).Methods("GET")
+	r.Handle("/activitypub/authors/{id}/inbox", alice.New(th.Throttle, timeoutHandler).Then(http.HandlerFunc(ReceiveActivity))).Methods("POST")
+	r.Handle("/activitypub/authors/{id}/outbox", alice.New(th.Throttle, timeoutHandler).Then(http.HandlerFunc(ReadOutbox))).Methods("GET")
+	r.Handle("/activitypub/authors/{id}/followers", alice.New(th.Throttle, timeoutHandler).Then(http.HandlerFunc(ReadFollowerCollection))).Methods("GET")
+	r.Handle("/activitypub/posts/{id}", alice.New(th.Throttle, timeoutHandler).Then(http.HandlerFunc(ReadPostObject))).Methods("GET")
+
+	// Pages live at the top level, so this has to be the last route.
+	r.HandleFunc("/{path:.+}", ReadPage).Methods("GET")
 
-	return alice.New(Logger).Then(r)
+	return r
 }
 
 func main() {
+	Config.Flags(flag.CommandLine)
+	flag.Parse()
+	Config.Resolve(flag.CommandLine)
+	Setup()
+	if flag.NArg() > 0 {
+		runCommand(flag.Args())
+	}
+	if _, err := MigrateUp(0); err != nil {
+		log.Fatal(err)
+	}
 	server := NewServer()
-	log.Println("listening port 8000")
-	http.ListenAndServe(":8000", server)
+	log.Println("listening on", Config.Listen)
+	log.Fatal(http.ListenAndServe(Config.Listen, server))
 }
 
 func Logger(h http.Handler) http.Handler {
@@ -1,18 +1,32 @@
 package main
 
 import (
+	"archive/zip"
 	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
+	"flag"
 	"fmt"
+	"io/ioutil"
 	//"log"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 	"testing"
-	//"time" FIXME, readd when http.Request is resolved
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/gorilla/mux"
+	"github.com/mholt/binding"
 
 	//_ "github.com/go-sql-driver/mysql" FIXME, is this needed?
 	//"github.com/jinzhu/gorm" FIXME, is this needed?
@@ -32,6 +46,55 @@ var sessioncookie string
 var secondusersessioncookie string
 var malformedsessioncookie = "MTQxNDc2NzAyOXxEdi1CQkFFQ180SUFBUkFCRUFBQUhmLUNBQUVHYzNSeWFXNW5EQVlBQkhWelpYSUZhVzUwTmpRRUFnQUN8Y2PFc-lZ8aEMWypbKXTD-LWg6o9DtJaMzd8NMc8m87A="
 
+// requestOption changes a request made by serve before it is sent.
+type requestOption func(*http.Request)
+
+// asJSON and asForm send the body of the request as JSON and as an URL encoded form.
+var (
+	asJSON = withContentType("application/json")
+	asForm = withContentType("application/x-www-form-urlencoded")
+)
+
+// withContentType sets the Content-Type of the request.
+func withContentType(contentType string) requestOption {
+	return func(r *http.Request) {
+		r.Header.Set("Content-Type", contentType)
+	}
+}
+
+// withSession sends the request with the session cookie of a logged in user.
+func withSession(cookie string) requestOption {
+	return func(r *http.Request) {
+		r.AddCookie(&http.Cookie{Name: "user", Value: cookie})
+	}
+}
+
+// withToken sends the request with an access token in its Authorization header.
+func withToken(token string) requestOption {
+	return func(r *http.Request) {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+}
+
+// serve makes a request with body to the test server and returns the recorded response.
+func serve(method, url, body string, options ...requestOption) *httptest.ResponseRecorder {
+	var recorder = httptest.NewRecorder()
+	request, _ := http.NewRequest(method, url, strings.NewReader(body))
+	for _, option := range options {
+		option(request)
+	}
+	server.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestMain(m *testing.M) {
+	Setup()
+	if _, err := MigrateUp(0); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
 func TestInstallationWizard(t *testing.T) {
 
 	Convey("Opening homepage", t, func() {
@@ -108,14 +171,14 @@ func TestEmptyAPIRoutes(t *testing.T) {
 			request, _ := http.NewRequest("GET", "/api/user/0", nil)
 			server.ServeHTTP(recorder, request)
 			So(recorder.Code, ShouldEqual, 404)
-			So(recorder.Body.String(), ShouldEqual, `{"error":"Not Found"}`)
+			So(recorder.Body.String(), ShouldEqual, `{"error":"Not Found","code":"not_found"}`)
 		})
 
 		Convey("get user should return 404", func() {
 			request, _ := http.NewRequest("GET", "/api/post/0", nil)
 			server.ServeHTTP(recorder, request)
 			So(recorder.Code, ShouldEqual, 404)
-			So(recorder.Body.String(), ShouldEqual, `{"error":"Not Found"}`)
+			So(recorder.Body.String(), ShouldEqual, `{"error":"Not Found","code":"not_found"}`)
 		})
 	})
 }
@@ -147,7 +210,7 @@ func TestSavingSettingsViaInstallationWizard(t *testing.T) {
 		var recorder = httptest.NewRecorder()
 
 		Convey("it should return 200 OK", func() {
-			settings.Hostname = "example.com"
+			settings.Hostname = "http://example.com"
 			settings.Name = "Foo's blog"
 			settings.Description = "Foo's test blog"
 			settings.Mailer.Domain = os.Getenv("MAILGUN_API_DOMAIN")
@@ -202,7 +265,7 @@ func TestManipulatingSettings(t *testing.T) {
 func TestCreateFirstUser(t *testing.T) {
 
 	user.Name = "Juuso"
-	user.Password = "foo"
+	user.Password = "correct-horse"
 	user.Email = "vertigo-test@mailinator.com"
 	testCreateUser(t, user.Name, user.Password, user.Email)
 }
@@ -243,7 +306,7 @@ func testCreateUser(t *testing.T, name string, password string, email string) {
 			request.Header.Set("Content-Type", "application/json")
 			server.ServeHTTP(recorder, request)
 			So(recorder.Code, ShouldEqual, 409)
-			So(recorder.Body.String(), ShouldEqual, `{"error":"Email already in use"}`)
+			So(recorder.Body.String(), ShouldEqual, `{"error":"Email already in use","code":"email_taken"}`)
 		})
 	})
 }
@@ -255,7 +318,7 @@ func TestReadUser(t *testing.T) {
 		request, _ := http.NewRequest("GET", fmt.Sprintf("/api/user/%d", user.ID), nil)
 		server.ServeHTTP(recorder, request)
 		So(recorder.Code, ShouldEqual, 200)
-		So(recorder.Body.String(), ShouldEqual, `{"id":1,"name":"Juuso","email":"vertigo-test@mailinator.com","avatar":"","posts":[]}`)
+		So(recorder.Body.String(), ShouldEqual, `{"id":1,"name":"Juuso","email":"vertigo-test@mailinator.com","avatar":""}`)
 	})
 }
 
@@ -266,7 +329,7 @@ func TestReadUsers(t *testing.T) {
 		request, _ := http.NewRequest("GET", "/api/users", nil)
 		server.ServeHTTP(recorder, request)
 		So(recorder.Code, ShouldEqual, 200)
-		So(recorder.Body.String(), ShouldEqual, `[{"id":1,"name":"Juuso","email":"vertigo-test@mailinator.com","avatar":"","posts":[]}]`)
+		So(recorder.Body.String(), ShouldEqual, `[{"id":1,"name":"Juuso","email":"vertigo-test@mailinator.com","avatar":""}]`)
 	})
 }
 
@@ -277,7 +340,7 @@ func TestReadUserSpecialCases(t *testing.T) {
 		request, _ := http.NewRequest("GET", "/api/user/3", nil)
 		server.ServeHTTP(recorder, request)
 		So(recorder.Code, ShouldEqual, 404)
-		So(recorder.Body.String(), ShouldEqual, `{"error":"Not Found"}`)
+		So(recorder.Body.String(), ShouldEqual, `{"error":"Not Found","code":"not_found"}`)
 	})
 
 	Convey("when user ID is malformed (eg. string), it should return 400", t, func() {
@@ -285,7 +348,7 @@ func TestReadUserSpecialCases(t *testing.T) {
 		request, _ := http.NewRequest("GET", "/api/user/foobar", nil)
 		server.ServeHTTP(recorder, request)
 		So(recorder.Code, ShouldEqual, 400)
-		So(recorder.Body.String(), ShouldEqual, `{"error":"The user ID could not be parsed from the request URL."}`)
+		So(recorder.Body.String(), ShouldEqual, `{"error":"The user ID could not be parsed from the request URL.","code":"invalid_id"}`)
 	})
 }
 
@@ -461,6 +524,24 @@ func TestReadPost(t *testing.T) {
 	})
 }
 
+func TestIncrementViewcount(t *testing.T) {
+
+	Convey("counting a view should only increase the view count", t, func() {
+		counted := Post{Title: "Counted", Slug: "counted", Kind: KindPost, Format: FormatMarkdown, Markdown: "Views", Content: "<p>Views</p>", Viewcount: 5}
+		So(db.Create(&counted).Error, ShouldBeNil)
+		defer db.Delete(&counted)
+
+		stale := counted
+		stale.Title = "Stale title"
+		stale.Viewcount = 0
+		stale.Increment(nil)
+		var saved Post
+		So(db.Where(&Post{ID: counted.ID}).First(&saved).Error, ShouldBeNil)
+		So(saved.Title, ShouldEqual, "Counted")
+		So(saved.Viewcount, ShouldEqual, 6)
+	})
+}
+
 func TestReadPostSpecialCases(t *testing.T) {
 
 	Convey("should return error when accessing slug called `new`", t, func() {
@@ -468,7 +549,7 @@ func TestReadPostSpecialCases(t *testing.T) {
 		request, _ := http.NewRequest("GET", "/api/post/new", nil)
 		server.ServeHTTP(recorder, request)
 		So(recorder.Code, ShouldEqual, 400)
-		So(recorder.Body.String(), ShouldEqual, `{"error":"There can't be a post called 'new'."}`)
+		So(recorder.Body.String(), ShouldEqual, `{"error":"There can't be a post called 'new'.","code":"reserved_slug"}`)
 	})
 }
 
@@ -578,7 +659,7 @@ func TestPostOwner(t *testing.T) {
 
 		Convey("post owner should have data linked to their profile", func() {
 			var recorder = httptest.NewRecorder()
-			request, _ := http.NewRequest("GET", "/api/user/1", nil)
+			request, _ := http.NewRequest("GET", "/api/user/1?include=posts&fields[posts]="+strings.Join(jsonFields(reflect.TypeOf(Post{})), ","), nil)
 			request.Header.Set("Content-Type", "application/json")
 			server.ServeHTTP(recorder, request)
 			So(recorder.Code, ShouldEqual, 200)
@@ -623,7 +704,7 @@ func testUpdatePostAPI(t *testing.T, payload []byte) {
 		request.Header.Set("Content-Type", "application/json")
 		server.ServeHTTP(recorder, request)
 		So(recorder.Code, ShouldEqual, 401)
-		So(recorder.Body.String(), ShouldEqual, `{"error":"Unauthorized"}`)
+		So(recorder.Body.String(), ShouldEqual, `{"error":"Unauthorized","code":"unauthorized"}`)
 	})
 
 	Convey("should return 401 with bad authorization", t, func() {
@@ -634,7 +715,7 @@ func testUpdatePostAPI(t *testing.T, payload []byte) {
 		request.Header.Set("Content-Type", "application/json")
 		server.ServeHTTP(recorder, request)
 		So(recorder.Code, ShouldEqual, 401)
-		So(recorder.Body.String(), ShouldEqual, `{"error":"Unauthorized"}`)
+		So(recorder.Body.String(), ShouldEqual, `{"error":"Unauthorized","code":"unauthorized"}`)
 	})
 
 	Convey("should return 404 with non-existent post", t, func() {
@@ -790,7 +871,7 @@ func TestDeletePost(t *testing.T) {
 			request.Header.Set("Content-Type", "application/json")
 			server.ServeHTTP(recorder, request)
 			So(recorder.Code, ShouldEqual, 401)
-			So(recorder.Body.String(), ShouldEqual, `{"error":"Unauthorized"}`)
+			So(recorder.Body.String(), ShouldEqual, `{"error":"Unauthorized","code":"unauthorized"}`)
 		})
 
 		Convey("it should return 401 with malformed sessioncookie", func() {
@@ -801,7 +882,7 @@ func TestDeletePost(t *testing.T) {
 			request.Header.Set("Content-Type", "application/json")
 			server.ServeHTTP(recorder, request)
 			So(recorder.Code, ShouldEqual, 401)
-			So(recorder.Body.String(), ShouldEqual, `{"error":"Unauthorized"}`)
+			So(recorder.Body.String(), ShouldEqual, `{"error":"Unauthorized","code":"unauthorized"}`)
 		})
 
 		Convey("it should return 404 when trying to delete non-existent post", func() {
@@ -840,7 +921,7 @@ func TestReadSettings(t *testing.T) {
 			request, _ := http.NewRequest("GET", "/api/settings", nil)
 			server.ServeHTTP(recorder, request)
 			So(recorder.Code, ShouldEqual, 401)
-			So(recorder.Body.String(), ShouldEqual, `{"error":"Unauthorized"}`)
+			So(recorder.Body.String(), ShouldEqual, `{"error":"Unauthorized","code":"unauthorized"}`)
 		})
 
 		Convey("reading with malformed sessioncookies it should return 401", func() {
@@ -850,7 +931,7 @@ func TestReadSettings(t *testing.T) {
 			request.AddCookie(cookie)
 			server.ServeHTTP(recorder, request)
 			So(recorder.Code, ShouldEqual, 401)
-			So(recorder.Body.String(), ShouldEqual, `{"error":"Unauthorized"}`)
+			So(recorder.Body.String(), ShouldEqual, `{"error":"Unauthorized","code":"unauthorized"}`)
 		})
 
 		Convey("reading with sessioncookies it should return 200", func() {
@@ -928,7 +1009,7 @@ func testUpdateSettings(t *testing.T, payload []byte, s Vertigo) {
 			request.Header.Set("Content-Type", "application/json")
 			server.ServeHTTP(recorder, request)
 			So(recorder.Code, ShouldEqual, 401)
-			So(recorder.Body.String(), ShouldEqual, `{"error":"Unauthorized"}`)
+			So(recorder.Body.String(), ShouldEqual, `{"error":"Unauthorized","code":"unauthorized"}`)
 		})
 
 		Convey("with successful sessioncookies", func() {
@@ -955,7 +1036,7 @@ func TestAllowRegistration(t *testing.T) {
 			request.Header.Set("Content-Type", "application/json")
 			server.ServeHTTP(recorder, request)
 			So(recorder.Code, ShouldEqual, 403)
-			So(recorder.Body.String(), ShouldEqual, `{"error":"New registrations are not allowed at this time."}`)
+			So(recorder.Body.String(), ShouldEqual, `{"error":"New registrations are not allowed at this time.","code":"registrations_closed"}`)
 		})
 
 		Convey("should return HTTP 403 on frontend", func() {
@@ -1116,7 +1197,7 @@ func TestPasswordRecovery(t *testing.T) {
 			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 			server.ServeHTTP(recorder, request)
 			So(recorder.Code, ShouldEqual, 401)
-			So(recorder.Body.String(), ShouldEqual, `{"error":"User with that email does not exist."}`)
+			So(recorder.Body.String(), ShouldEqual, `{"error":"User with that email does not exist.","code":"unknown_email"}`)
 		})
 
 		Convey("should return 302 with latest user email", func() {
@@ -1167,7 +1248,7 @@ func TestPasswordReset(t *testing.T) {
 			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 			server.ServeHTTP(recorder, request)
 			So(recorder.Code, ShouldEqual, 400)
-			So(recorder.Body.String(), ShouldEqual, `{"error":"User ID could not be parsed from request URL."}`)
+			So(recorder.Body.String(), ShouldEqual, `{"error":"User ID could not be parsed from request URL.","code":"invalid_id"}`)
 		})
 
 		Convey("should return 400 when recovery UUID does not pass UUID checks", func() {
@@ -1176,7 +1257,7 @@ func TestPasswordReset(t *testing.T) {
 			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 			server.ServeHTTP(recorder, request)
 			So(recorder.Code, ShouldEqual, 400)
-			So(recorder.Body.String(), ShouldEqual, `{"error":"Could not parse UUID from the request."}`)
+			So(recorder.Body.String(), ShouldEqual, `{"error":"Could not parse UUID from the request.","code":"invalid_recovery"}`)
 		})
 
 		Convey("should return 400 when user with given ID does not exist", func() {
@@ -1185,7 +1266,7 @@ func TestPasswordReset(t *testing.T) {
 			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 			server.ServeHTTP(recorder, request)
 			So(recorder.Code, ShouldEqual, 400)
-			So(recorder.Body.String(), ShouldEqual, `{"error":"User with that ID does not exist."}`)
+			So(recorder.Body.String(), ShouldEqual, `{"error":"User with that ID does not exist.","code":"invalid_id"}`)
 		})
 	})
 
@@ -1268,7 +1349,7 @@ func TestPostSecurity(t *testing.T) {
 			request.Header.Set("Content-Type", "application/json")
 			server.ServeHTTP(recorder, request)
 			So(recorder.Code, ShouldEqual, 401)
-			So(recorder.Body.String(), ShouldEqual, `{"error":"Unauthorized"}`)
+			So(recorder.Body.String(), ShouldEqual, `{"error":"Unauthorized","code":"unauthorized"}`)
 		})
 
 		Convey("publishing post of another user", func() {
@@ -1278,7 +1359,7 @@ func TestPostSecurity(t *testing.T) {
 			request.AddCookie(cookie)
 			server.ServeHTTP(recorder, request)
 			So(recorder.Code, ShouldEqual, 401)
-			So(recorder.Body.String(), ShouldEqual, `{"error":"Unauthorized"}`)
+			So(recorder.Body.String(), ShouldEqual, `{"error":"Unauthorized","code":"unauthorized"}`)
 		})
 
 		Convey("deleting post of another user", func() {
@@ -1289,12 +1370,2398 @@ func TestPostSecurity(t *testing.T) {
 			request.Header.Set("Content-Type", "application/json")
 			server.ServeHTTP(recorder, request)
 			So(recorder.Code, ShouldEqual, 401)
-			So(recorder.Body.String(), ShouldEqual, `{"error":"Unauthorized"}`)
+			So(recorder.Body.String(), ShouldEqual, `{"error":"Unauthorized","code":"unauthorized"}`)
 		})
 	})
 }
 */
 
+func TestHTMLToMarkdown(t *testing.T) {
+
+	Convey("converting HTML to Markdown", t, func() {
+
+		Convey("should render back into the same HTML", func() {
+			html := "<h2 id=\"title\">Title</h2>\n\n<p>Some <strong>bold</strong>, <em>emphasized</em> and <a href=\"/post/x\">linked</a> text.</p>\n\n<ul>\n<li>one</li>\n<li>two</li>\n</ul>\n"
+			markdown, err := HTMLToMarkdown(html)
+			So(err, ShouldBeNil)
+			So(markdown, ShouldEqual, "## Title\n\nSome **bold**, *emphasized* and [linked](/post/x) text.\n\n- one\n- two\n")
+			So(renderMarkdown(markdown), ShouldEqual, html)
+		})
+
+		Convey("should escape text which looks like Markdown", func() {
+			markdown, err := HTMLToMarkdown("<p>2 * 3 &lt; [7]</p>")
+			So(err, ShouldBeNil)
+			So(renderMarkdown(markdown), ShouldEqual, "<p>2 * 3 &lt; [7]</p>\n")
+		})
+
+		Convey("should keep elements Markdown cannot express as HTML", func() {
+			markdown, err := HTMLToMarkdown(`<p>A <span class="note">note</span></p>`)
+			So(err, ShouldBeNil)
+			So(markdown, ShouldEqual, "A <span class=\"note\">note</span>\n")
+		})
+
+		Convey("should keep heading IDs which differ from the generated ones", func() {
+			markdown, err := HTMLToMarkdown("<h2 id=\"setup\">Getting started</h2>\n")
+			So(err, ShouldBeNil)
+			So(markdown, ShouldEqual, "## Getting started {#setup}\n")
+			So(renderMarkdown(markdown), ShouldEqual, "<h2 id=\"setup\">Getting started</h2>\n")
+		})
+	})
+}
+
+func TestPostFormats(t *testing.T) {
+
+	Convey("rendering a plain text post", t, func() {
+		p := Post{Format: FormatPlain, Markdown: "Hello <world>\nsecond line\n\nnext *paragraph*"}
+		p, err := p.Render()
+		So(err, ShouldBeNil)
+		So(p.Content, ShouldEqual, "<p>Hello &lt;world&gt;<br>\nsecond line</p>\n<p>next *paragraph*</p>")
+	})
+
+	Convey("converting a Markdown post to HTML", t, func() {
+		p := Post{Format: FormatMarkdown, Markdown: "*foo*"}
+		p, err := p.Render()
+		So(err, ShouldBeNil)
+		p, err = p.Convert(FormatHTML)
+		So(err, ShouldBeNil)
+		So(p.Format, ShouldEqual, FormatHTML)
+		So(p.Markdown, ShouldEqual, "")
+		So(p.Content, ShouldContainSubstring, "<em>foo</em>")
+	})
+
+	Convey("rendering a post with an unknown format should fail", t, func() {
+		p := Post{Format: "rst"}
+		_, err := p.Render()
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestSanitizeContent(t *testing.T) {
+
+	Convey("sanitizing post content", t, func() {
+		hosts := Settings.EmbedHosts
+		Settings.EmbedHosts = "www.youtube.com"
+		Reset(func() {
+			Settings.EmbedHosts = hosts
+		})
+
+		vectors := []string{
+			`<script>alert(1)</script>`,
+			`<img src="x" onerror="alert(1)">`,
+			`<a href="javascript:alert(1)">click</a>`,
+			`<a href="JaVaScRiPt:alert(1)">click</a>`,
+			`<svg onload="alert(1)"></svg>`,
+			`<p style="background:url(javascript:alert(1))">styled</p>`,
+			`<iframe src="javascript:alert(1)"></iframe>`,
+			`<object data="data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg=="></object>`,
+			`<body onload="alert(1)">`,
+			`<math><a xlink:href="javascript:alert(1)">click</a></math>`,
+		}
+
+		Convey("should remove scripts for every role", func() {
+			for _, role := range []string{RoleAuthor, RoleAdmin} {
+				for _, vector := range vectors {
+					sanitized := strings.ToLower(sanitizeContent(vector, role))
+					So(sanitized, ShouldNotContainSubstring, "<script")
+					So(sanitized, ShouldNotContainSubstring, "javascript:")
+					So(sanitized, ShouldNotContainSubstring, "onerror")
+					So(sanitized, ShouldNotContainSubstring, "onload")
+					So(sanitized, ShouldNotContainSubstring, "style=")
+					So(sanitized, ShouldNotContainSubstring, "<object")
+				}
+			}
+		})
+
+		Convey("should keep ordinary markup", func() {
+			html := `<p><strong>bold</strong> <a href="/post/x">link</a></p><pre><code class="language-go">a &lt; b</code></pre>`
+			So(sanitizeContent(html, RoleAuthor), ShouldEqual, html)
+		})
+
+		Convey("should only let admins embed iframes from allowed hosts", func() {
+			embed := `<iframe src="https://www.youtube.com/embed/x" width="560" height="315"></iframe>`
+			So(sanitizeContent(embed, RoleAdmin), ShouldEqual, embed)
+			So(sanitizeContent(embed, RoleAuthor), ShouldEqual, "")
+			So(sanitizeContent(`<iframe src="https://www.youtube.com.example.com/x"></iframe>`, RoleAdmin), ShouldEqual, "")
+		})
+
+		Convey("should sanitize rendered Markdown", func() {
+			p := Post{Format: FormatMarkdown, Markdown: "Hello <script>alert(1)</script> [x](javascript:alert(1))"}
+			p, err := p.Render()
+			So(err, ShouldBeNil)
+			So(p.Content, ShouldNotContainSubstring, "<script")
+			So(p.Content, ShouldNotContainSubstring, "javascript:")
+		})
+
+		Convey("should be applied to old posts with the policy of migration 6", func() {
+			embed := `<iframe src="https://www.youtube.com/embed/x" width="560" height="315"></iframe>`
+			So(sanitizePolicyV6(RoleAdmin, "www.youtube.com").Sanitize(embed), ShouldEqual, embed)
+			So(sanitizePolicyV6(RoleAuthor, "www.youtube.com").Sanitize(embed), ShouldEqual, "")
+			So(sanitizePolicyV6(RoleAuthor, "").Sanitize(`<p>Hi<script>alert(1)</script></p>`), ShouldEqual, "<p>Hi</p>")
+		})
+	})
+}
+
+func TestHighlight(t *testing.T) {
+
+	Convey("rendering a fenced code block with a language", t, func() {
+		p := Post{Format: FormatMarkdown, Markdown: "```go\nfunc main() {\n\t/* a <b> */\n}\n```\n"}
+		p, err := p.Render()
+		So(err, ShouldBeNil)
+
+		Convey("should highlight it on every line", func() {
+			So(p.Content, ShouldStartWith, `<pre class="highlight"><code class="language-go"><span class="line"><span class="kwd">func</span>`)
+			So(p.Content, ShouldContainSubstring, `<span class="com">/* a &lt;b&gt; */</span>`)
+			So(strings.Count(p.Content, `<span class="line">`), ShouldEqual, 3)
+		})
+
+		Convey("should convert back into the same Markdown", func() {
+			markdown, err := HTMLToMarkdown(p.Content)
+			So(err, ShouldBeNil)
+			So(markdown, ShouldEqual, "```go\nfunc main() {\n\t/* a <b> */\n}\n```\n")
+		})
+	})
+
+	Convey("rendering a code block without a language should not highlight it", t, func() {
+		So(renderMarkdown("    x := 1\n"), ShouldEqual, "<pre><code>x := 1\n</code></pre>\n")
+	})
+}
+
+func TestTableOfContents(t *testing.T) {
+
+	Convey("rendering a post which asks for a table of contents", t, func() {
+		p := Post{Format: FormatMarkdown, Markdown: "[TOC]\n\n# Getting *started*\n\nSome text.[^1]\n\n## Install\n\n### From source {#source}\n\n## Configure\n\n# Done\n\n[^1]: A footnote.\n"}
+		p, err := p.Render()
+		So(err, ShouldBeNil)
+
+		Convey("should give every heading an ID", func() {
+			So(p.Content, ShouldContainSubstring, `<h1 id="getting-started">Getting <em>started</em></h1>`)
+			So(p.Content, ShouldContainSubstring, `<h2 id="install">Install</h2>`)
+			So(p.Content, ShouldContainSubstring, `<h3 id="source">From source</h3>`)
+		})
+
+		Convey("should replace the marker with nested links to the headings", func() {
+			So(p.Content, ShouldStartWith, "<nav class=\"toc\">\n<ul>\n<li><a href=\"#getting-started\">Getting started</a>\n<ul>\n"+
+				"<li><a href=\"#install\">Install</a>\n<ul>\n<li><a href=\"#source\">From source</a></li>\n</ul>\n</li>\n"+
+				"<li><a href=\"#configure\">Configure</a></li>\n</ul>\n</li>\n"+
+				"<li><a href=\"#done\">Done</a></li>\n</ul>\n</nav>")
+			So(p.Content, ShouldNotContainSubstring, "[TOC]")
+		})
+
+		Convey("should keep the footnotes", func() {
+			So(p.Content, ShouldContainSubstring, `<sup class="footnote-ref" id="fnref:1"><a href="#fn:1">1</a></sup>`)
+			So(p.Content, ShouldContainSubstring, `<div class="footnotes">`)
+			So(p.Content, ShouldContainSubstring, `<a class="footnote-return" href="#fnref:1">`)
+		})
+
+		Convey("should leave the table of contents out of the excerpt", func() {
+			So(p.Excerpt, ShouldStartWith, "Getting started")
+		})
+	})
+
+	Convey("rendering a post without the marker should not add a table of contents", t, func() {
+		p := Post{Format: FormatMarkdown, Markdown: "# Title\n\nText.\n"}
+		p, err := p.Render()
+		So(err, ShouldBeNil)
+		So(p.Content, ShouldNotContainSubstring, `<nav`)
+	})
+
+	Convey("reading time should be rounded up to whole minutes", t, func() {
+		So(readingTime(""), ShouldEqual, 0)
+		So(readingTime("<p>"+strings.Repeat("word ", 10)+"</p>"), ShouldEqual, 1)
+		So(readingTime("<p>"+strings.Repeat("word ", 401)+"</p>"), ShouldEqual, 3)
+		// Migration 7 estimated the reading time of existing posts the same way.
+		So(readingTimeV7(""), ShouldEqual, 0)
+		So(readingTimeV7("<p>"+strings.Repeat("word ", 401)+"</p>"), ShouldEqual, 3)
+	})
+}
+
+func TestPreviewAndDrafts(t *testing.T) {
+
+	Convey("without authentication", t, func() {
+		var recorder = httptest.NewRecorder()
+
+		Convey("previewing a post should fail", func() {
+			request, _ := http.NewRequest("POST", "/api/post/preview", strings.NewReader(`{"title": "Preview", "markdown": "# Hello"}`))
+			request.Header.Set("Content-Type", "application/json")
+			server.ServeHTTP(recorder, request)
+			So(recorder.Code, ShouldEqual, 401)
+		})
+
+		Convey("reading a draft should fail", func() {
+			request, _ := http.NewRequest("GET", "/api/draft/0", nil)
+			server.ServeHTTP(recorder, request)
+			So(recorder.Code, ShouldEqual, 401)
+		})
+
+		Convey("saving a draft should fail", func() {
+			request, _ := http.NewRequest("POST", "/api/draft/0", strings.NewReader(`{"title": "Draft", "markdown": "Unsaved"}`))
+			request.Header.Set("Content-Type", "application/json")
+			server.ServeHTTP(recorder, request)
+			So(recorder.Code, ShouldEqual, 401)
+		})
+
+		Convey("discarding a draft should fail", func() {
+			request, _ := http.NewRequest("GET", "/api/draft/0/delete", nil)
+			server.ServeHTTP(recorder, request)
+			So(recorder.Code, ShouldEqual, 401)
+		})
+	})
+
+	Convey("with a session", t, func() {
+		admin := []requestOption{asJSON, withSession(sessioncookie)}
+		read := func(url string) (int, Draft) {
+			var draft Draft
+			recorder := serve("GET", url, "", admin...)
+			json.Unmarshal(recorder.Body.Bytes(), &draft)
+			return recorder.Code, draft
+		}
+
+		Convey("a draft should be saved, replaced and discarded", func() {
+			recorder := serve("PUT", "/api/v1/drafts/0", `{"title": "Draft", "markdown": "First"}`, admin...)
+			So(recorder.Code, ShouldEqual, 200)
+			var first Draft
+			json.Unmarshal(recorder.Body.Bytes(), &first)
+			So(first.Author, ShouldEqual, user.ID)
+			So(first.Post, ShouldEqual, 0)
+
+			code, draft := read("/api/v1/drafts/0")
+			So(code, ShouldEqual, 200)
+			So(draft.Title, ShouldEqual, "Draft")
+			So(draft.Markdown, ShouldEqual, "First")
+
+			recorder = serve("POST", "/api/draft/0", `{"title": "Draft", "markdown": "Second"}`, admin...)
+			So(recorder.Code, ShouldEqual, 200)
+			code, draft = read("/api/draft/0")
+			So(code, ShouldEqual, 200)
+			So(draft.ID, ShouldEqual, first.ID)
+			So(draft.Markdown, ShouldEqual, "Second")
+			var count int
+			db.Model(Draft{}).Where("author = ? AND post = ?", user.ID, 0).Count(&count)
+			So(count, ShouldEqual, 1)
+
+			So(serve("DELETE", "/api/v1/drafts/0", "", admin...).Code, ShouldEqual, 200)
+			code, _ = read("/api/v1/drafts/0")
+			So(code, ShouldEqual, 404)
+		})
+
+		Convey("the draft of a post should be removed when the post is saved", func() {
+			var post Post
+			json.Unmarshal(serve("POST", "/api/v1/posts", `{"title": "Drafted post", "markdown": "Saved"}`, admin...).Body.Bytes(), &post)
+			So(post.ID, ShouldNotEqual, 0)
+			url := fmt.Sprintf("/api/v1/drafts/%d", post.ID)
+
+			So(serve("PUT", url, `{"title": "Drafted post", "markdown": "Unsaved"}`, admin...).Code, ShouldEqual, 200)
+			code, draft := read(url)
+			So(code, ShouldEqual, 200)
+			So(draft.Markdown, ShouldEqual, "Unsaved")
+
+			So(serve("PATCH", "/api/v1/posts/"+post.Slug, `{"markdown": "Unsaved"}`, admin...).Code, ShouldEqual, 200)
+			code, _ = read(url)
+			So(code, ShouldEqual, 404)
+		})
+	})
+}
+
+func TestPreviewLinks(t *testing.T) {
+
+	draft := Post{Title: "Secret draft", Slug: "secret-draft", Format: FormatMarkdown, Markdown: "Not yet.", Content: "<p>Not yet.</p>"}
+	if err := db.Create(&draft).Error; err != nil {
+		panic(err)
+	}
+	read := func(url string) int {
+		var recorder = httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", url, nil)
+		server.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	Convey("an unpublished post", t, func() {
+
+		Convey("should not be found without a preview token", func() {
+			So(read("/api/post/secret-draft"), ShouldEqual, 404)
+			So(read("/post/secret-draft"), ShouldEqual, 404)
+		})
+
+		Convey("should be found with a preview token", func() {
+			preview, err := draft.NewPreview(1)
+			So(err, ShouldBeNil)
+			So(preview.URL, ShouldStartWith, "/post/secret-draft?preview=")
+			So(read(preview.URL), ShouldEqual, 200)
+			So(read("/api/post/secret-draft?preview="+preview.Token()), ShouldEqual, 200)
+
+			Convey("but not with a tampered one", func() {
+				parts := strings.Split(preview.Token(), ".")
+				So(read(fmt.Sprintf("/post/secret-draft?preview=%s.%d.%s", parts[0], preview.Expires+3600, parts[2])), ShouldEqual, 404)
+				So(read("/post/secret-draft?preview=foobar"), ShouldEqual, 404)
+			})
+
+			Convey("nor after it has been revoked", func() {
+				So(draft.RevokePreview(preview.ID), ShouldBeNil)
+				So(read(preview.URL), ShouldEqual, 404)
+			})
+		})
+
+		Convey("should refuse preview links which never or hardly ever expire", func() {
+			_, err := draft.NewPreview(0)
+			So(err, ShouldNotBeNil)
+			_, err = draft.NewPreview(maxPreviewDays + 1)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("should not accept expired preview tokens", func() {
+			preview := Preview{Post: draft.ID, Date: 1, Expires: 2}
+			So(db.Create(&preview).Error, ShouldBeNil)
+			So(draft.previewAllowed(preview.Token()), ShouldBeFalse)
+		})
+	})
+
+	if err := deletePreviews(db, draft.ID); err != nil {
+		panic(err)
+	}
+	if err := db.Delete(&draft).Error; err != nil {
+		panic(err)
+	}
+}
+
+func TestPages(t *testing.T) {
+
+	about := Post{Title: "About us", Slug: "about", Kind: KindPage, MenuOrder: 2, Published: true, Format: FormatMarkdown, Markdown: "Who we are.", Content: "<p>Who we are.</p>"}
+	if err := db.Create(&about).Error; err != nil {
+		panic(err)
+	}
+	team := Post{Title: "Our team", Slug: "team", Kind: KindPage, Parent: about.ID, MenuOrder: 1, Published: true, Format: FormatMarkdown, Markdown: "Everyone.", Content: "<p>Everyone.</p>"}
+	if err := db.Create(&team).Error; err != nil {
+		panic(err)
+	}
+	contact := Post{Title: "Contact", Slug: "contact", Kind: KindPage, MenuOrder: 1, Published: true, Format: FormatMarkdown, Markdown: "Write to us.", Content: "<p>Write to us.</p>"}
+	if err := db.Create(&contact).Error; err != nil {
+		panic(err)
+	}
+	read := func(url string) *httptest.ResponseRecorder {
+		var recorder = httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", url, nil)
+		server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	Convey("pages", t, func() {
+
+		Convey("should be found at top-level paths made of their parents", func() {
+			So(read("/about").Code, ShouldEqual, 200)
+			So(read("/about/team").Code, ShouldEqual, 200)
+		})
+
+		Convey("should redirect other paths to their canonical one", func() {
+			recorder := read("/team")
+			So(recorder.Code, ShouldEqual, 301)
+			So(recorder.HeaderMap.Get("Location"), ShouldEqual, "/about/team")
+			So(read("/post/about").Code, ShouldEqual, 301)
+		})
+
+		Convey("should not be found at paths of blog posts", func() {
+			So(read("/nothing-here").Code, ShouldEqual, 404)
+		})
+
+		Convey("should be listed in the menu in their order and below their parent", func() {
+			menu, err := Menu()
+			So(err, ShouldBeNil)
+			So(len(menu), ShouldEqual, 2)
+			So(menu[0].URL, ShouldEqual, "/contact")
+			So(menu[1].URL, ShouldEqual, "/about")
+			So(len(menu[1].Children), ShouldEqual, 1)
+			So(menu[1].Children[0].URL, ShouldEqual, "/about/team")
+		})
+
+		Convey("should not be placed below themselves or below blog posts", func() {
+			about.Parent = team.ID
+			So(about.checkParent(db), ShouldNotBeNil)
+			post := Post{Title: "Blog post", Slug: "blog-post", Kind: KindPost, Format: FormatMarkdown}
+			So(db.Create(&post).Error, ShouldBeNil)
+			contact.Parent = post.ID
+			So(contact.checkParent(db), ShouldNotBeNil)
+			So(db.Delete(&post).Error, ShouldBeNil)
+		})
+
+		Convey("should keep their place when a stale copy of them is saved", func() {
+			stale := team
+			stale.Parent, stale.MenuOrder = 0, 0
+			So(db.Model(&team).Update("menu_order", 3).Error, ShouldBeNil)
+			_, err := stale.Update(nil)
+			So(err, ShouldBeNil)
+			var saved Post
+			So(db.Where(&Post{ID: team.ID}).First(&saved).Error, ShouldBeNil)
+			So(saved.Parent, ShouldEqual, about.ID)
+			So(saved.MenuOrder, ShouldEqual, 3)
+			So(db.Model(&team).Update("menu_order", 1).Error, ShouldBeNil)
+		})
+
+		Convey("should be left out of feeds and post listings", func() {
+			So(read("/feeds/rss").Body.String(), ShouldNotContainSubstring, "About us")
+			So(read("/api/posts").Body.String(), ShouldNotContainSubstring, "About us")
+			So(read("/api/posts?kind=page").Body.String(), ShouldContainSubstring, "About us")
+		})
+	})
+
+	for _, page := range []Post{team, about, contact} {
+		if err := db.Delete(&page).Error; err != nil {
+			panic(err)
+		}
+	}
+}
+
+func TestSeries(t *testing.T) {
+
+	series := Series{Title: "Go from scratch", Slug: "go-from-scratch", Author: 1}
+	if err := db.Create(&series).Error; err != nil {
+		panic(err)
+	}
+	var parts []Post
+	for i, title := range []string{"Installing Go", "Writing tests", "Unreleased part", "Hello world"} {
+		post := Post{Title: title, Slug: fmt.Sprintf("go-from-scratch-%d", i), Kind: KindPost, Author: 1, Series: series.ID, Part: i + 1, Published: i != 2, Format: FormatMarkdown}
+		// Parts are ordered by their number, not by when they were written.
+		if title == "Hello world" {
+			post.Part = 1
+			post.Date = -1
+		}
+		if err := db.Create(&post).Error; err != nil {
+			panic(err)
+		}
+		parts = append(parts, post)
+	}
+	read := func(url string) *httptest.ResponseRecorder {
+		var recorder = httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", url, nil)
+		server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	Convey("a series", t, func() {
+
+		Convey("should list its published parts in order", func() {
+			posts, err := series.GetPosts(false)
+			So(err, ShouldBeNil)
+			So(len(posts), ShouldEqual, 3)
+			So(posts[0].Title, ShouldEqual, "Hello world")
+			So(posts[1].Title, ShouldEqual, "Installing Go")
+			So(posts[2].Title, ShouldEqual, "Writing tests")
+		})
+
+		Convey("should tell each part its place and neighbours", func() {
+			nav, err := parts[0].SeriesNav()
+			So(err, ShouldBeNil)
+			So(nav.Part, ShouldEqual, 2)
+			So(nav.Total, ShouldEqual, 3)
+			So(nav.Previous.Title, ShouldEqual, "Hello world")
+			So(nav.Next.Title, ShouldEqual, "Writing tests")
+
+			nav, err = parts[3].SeriesNav()
+			So(err, ShouldBeNil)
+			So(nav.Previous, ShouldBeNil)
+		})
+
+		Convey("should count an unpublished part only for itself", func() {
+			nav, err := parts[2].SeriesNav()
+			So(err, ShouldBeNil)
+			So(nav.Part, ShouldEqual, 4)
+			So(nav.Total, ShouldEqual, 4)
+		})
+
+		Convey("should have an index page, feeds and a JSON representation", func() {
+			So(read("/series/go-from-scratch").Code, ShouldEqual, 200)
+			feed := read("/series/go-from-scratch/rss").Body.String()
+			So(feed, ShouldContainSubstring, "Installing Go")
+			So(feed, ShouldNotContainSubstring, "Unreleased part")
+			So(feed, ShouldContainSubstring, "<link>http://example.com/series/go-from-scratch</link>")
+			So(read("/api/series/go-from-scratch").Body.String(), ShouldContainSubstring, "Writing tests")
+			So(read("/api/series").Body.String(), ShouldContainSubstring, "go-from-scratch")
+			So(read("/series/nothing-here").Code, ShouldEqual, 404)
+		})
+
+		Convey("should only take posts of its author", func() {
+			post := Post{Kind: KindPost, Author: 2, Series: series.ID}
+			_, err := post.checkSeries()
+			So(err, ShouldNotBeNil)
+			post = Post{Kind: KindPage, Author: 1, Series: series.ID}
+			_, err = post.checkSeries()
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("should make new posts its last part", func() {
+			post := Post{Kind: KindPost, Author: 1, Series: series.ID}
+			post, err := post.checkSeries()
+			So(err, ShouldBeNil)
+			So(post.Part, ShouldEqual, 4)
+		})
+
+		Convey("should keep its posts when it is deleted", func() {
+			So(series.Delete(), ShouldBeNil)
+			var post Post
+			So(db.Where(&Post{ID: parts[0].ID}).First(&post).Error, ShouldBeNil)
+			So(post.Series, ShouldEqual, 0)
+		})
+	})
+
+	for _, post := range parts {
+		if err := db.Delete(&post).Error; err != nil {
+			panic(err)
+		}
+	}
+}
+
+func TestAPIV1(t *testing.T) {
+
+	Convey("the versioned API", t, func() {
+
+		Convey("should list posts without deprecation headers", func() {
+			recorder := serve("GET", "/api/v1/posts", "")
+			So(recorder.Code, ShouldEqual, 200)
+			So(recorder.HeaderMap.Get("Deprecation"), ShouldEqual, "")
+		})
+
+		Convey("should answer errors with a code", func() {
+			recorder := serve("GET", "/api/v1/posts/nothing-here", "")
+			So(recorder.Code, ShouldEqual, 404)
+			So(recorder.Body.String(), ShouldEqual, `{"error":"Not Found","code":"not_found"}`)
+		})
+
+		Convey("should use the HTTP verbs of the resources", func() {
+			So(serve("DELETE", "/api/v1/posts/nothing-here", "{}").Code, ShouldEqual, 401)
+			So(serve("PATCH", "/api/v1/posts/nothing-here", "{}", asJSON).Code, ShouldEqual, 401)
+			So(serve("DELETE", "/api/v1/series/nothing-here", "{}").Code, ShouldEqual, 401)
+		})
+
+		Convey("should refuse bodies which are not JSON", func() {
+			recorder := serve("POST", "/api/v1/users", "{}", withContentType("text/plain"))
+			So(recorder.Code, ShouldEqual, 415)
+			So(recorder.Body.String(), ShouldContainSubstring, `"code":"unsupported_media_type"`)
+		})
+	})
+
+	Convey("the unversioned API", t, func() {
+
+		Convey("should still work but be marked deprecated", func() {
+			recorder := serve("GET", "/api/posts", "")
+			So(recorder.Code, ShouldEqual, 200)
+			So(recorder.HeaderMap.Get("Deprecation"), ShouldEqual, "true")
+			So(recorder.HeaderMap.Get("Link"), ShouldEqual, `</api/v1/>; rel="successor-version"`)
+		})
+	})
+
+	Convey("binding errors should be listed by field", t, func() {
+		errs := binding.Errors{
+			{FieldNames: []string{"parent"}, Classification: binding.TypeError, Message: "Invalid integer"},
+		}
+		apierr := ValidationError(errs)
+		So(apierr.Code, ShouldEqual, ErrValidation)
+		So(len(apierr.Fields), ShouldEqual, 1)
+		So(apierr.Fields[0], ShouldResemble, FieldError{Field: "parent", Code: "invalid_type", Message: "Invalid integer"})
+	})
+}
+
+func TestOpenAPI(t *testing.T) {
+
+	Convey("the OpenAPI document", t, func() {
+		router := NewRouter()
+		doc, err := GenerateOpenAPI(router)
+		So(err, ShouldBeNil)
+
+		Convey("should document every registered route of the API", func() {
+			var documented []string
+			for _, op := range doc.Operations {
+				documented = append(documented, op.Method+" "+op.Path)
+			}
+			router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+				template, err := route.GetPathTemplate()
+				if err != nil || !strings.HasPrefix(template, "/api") {
+					return nil
+				}
+				methods, err := route.GetMethods()
+				if err != nil {
+					return nil
+				}
+				for _, method := range methods {
+					So(method+" "+openAPIPath(template), ShouldBeIn, documented)
+				}
+				return nil
+			})
+		})
+
+		Convey("should describe deprecated routes by their successors", func() {
+			for route, apidoc := range apiDocs {
+				if apidoc.Successor != "" {
+					So(apiDocs, ShouldContainKey, apidoc.Successor)
+					So(route, ShouldNotEqual, apidoc.Successor)
+				}
+			}
+			So(doc.Paths["/api/post/{slug}"]["get"].Deprecated, ShouldBeTrue)
+			So(doc.Paths["/api/v1/posts/{slug}"]["get"].Deprecated, ShouldBeFalse)
+		})
+
+		Convey("should describe the bodies by their structs", func() {
+			So(doc.Components.Schemas, ShouldContainKey, "Post")
+			So(doc.Components.Schemas, ShouldContainKey, "User")
+			So(doc.Components.Schemas, ShouldContainKey, "Vertigo")
+			So(doc.Components.Schemas, ShouldContainKey, "Search")
+			So(doc.Components.Schemas["Post"].Required, ShouldResemble, []string{"title"})
+			So(doc.Components.Schemas["User"].Properties, ShouldNotContainKey, "Digest")
+			So(doc.Components.Schemas["Vertigo"].Properties["mailgun"].Ref, ShouldEqual, "#/components/schemas/MailgunSettings")
+		})
+
+		Convey("should be served as JSON", func() {
+			var recorder = httptest.NewRecorder()
+			request, _ := http.NewRequest("GET", "/api/openapi.json", nil)
+			server.ServeHTTP(recorder, request)
+			So(recorder.Code, ShouldEqual, 200)
+			So(recorder.HeaderMap.Get("Deprecation"), ShouldEqual, "")
+			var served map[string]interface{}
+			So(json.Unmarshal(recorder.Body.Bytes(), &served), ShouldBeNil)
+			So(served["openapi"], ShouldEqual, "3.0.3")
+			So(served["paths"], ShouldContainKey, "/api/v1/posts")
+		})
+
+		Convey("should be rendered as the /api page", func() {
+			var recorder = httptest.NewRecorder()
+			request, _ := http.NewRequest("GET", "/api", nil)
+			server.ServeHTTP(recorder, request)
+			So(recorder.Code, ShouldEqual, 200)
+			page, _ := goquery.NewDocumentFromReader(recorder.Body)
+			So(page.Find("h3").Length(), ShouldEqual, len(doc.Operations)-len(doc.Deprecated()))
+			So(page.Find("h3 a[href='/api/v1/posts']").Length(), ShouldEqual, 1)
+		})
+	})
+}
+
+func TestValidation(t *testing.T) {
+
+	admin := []requestOption{asJSON, withSession(sessioncookie)}
+	fields := func(recorder *httptest.ResponseRecorder) []FieldError {
+		var apierr APIError
+		json.Unmarshal(recorder.Body.Bytes(), &apierr)
+		return apierr.Fields
+	}
+
+	Convey("the rules of fields", t, func() {
+
+		Convey("should accept only email addresses", func() {
+			So(validEmail("foo@example.com"), ShouldBeTrue)
+			So(validEmail("foo@example"), ShouldBeFalse)
+			So(validEmail("foo bar@example.com"), ShouldBeFalse)
+		})
+
+		Convey("should accept only strong passwords", func() {
+			So(strongPassword("correct-horse"), ShouldBeTrue)
+			So(strongPassword("Password"), ShouldBeTrue)
+			So(strongPassword("password"), ShouldBeFalse)
+			So(strongPassword("a1"), ShouldBeFalse)
+		})
+
+		Convey("should accept only absolute URLs as hostnames", func() {
+			So(validHostname("http://example.com"), ShouldBeTrue)
+			So(validHostname("http://example.com/"), ShouldBeTrue)
+			So(validHostname("https://example.com/blog/"), ShouldBeFalse)
+			So(validHostname("example.com"), ShouldBeFalse)
+			So(validHostname("ftp://example.com"), ShouldBeFalse)
+		})
+	})
+
+	Convey("invalid input", t, func() {
+
+		Convey("should answer 422 with the invalid fields in the API", func() {
+			recorder := serve("POST", "/api/v1/users", `{"email": "foo@example", "password": "short"}`, admin...)
+			So(recorder.Code, ShouldEqual, 422)
+			So(recorder.Body.String(), ShouldContainSubstring, `"code":"validation_failed"`)
+			So(fields(recorder), ShouldContain, FieldError{Field: "email", Code: ErrInvalidEmail, Message: "Email is not a valid email address."})
+			So(fields(recorder), ShouldContain, FieldError{Field: "password", Code: ErrWeakPassword, Message: "Password must be at least 8 characters long and mix letters with digits or other characters."})
+		})
+
+		Convey("should answer 400 to bodies which cannot be read", func() {
+			recorder := serve("POST", "/api/v1/posts", `{"title": 1}`, admin...)
+			So(recorder.Code, ShouldEqual, 400)
+		})
+
+		Convey("should require a title of new posts, but not of updated ones", func() {
+			recorder := serve("POST", "/api/v1/posts", `{"markdown": "No title"}`, admin...)
+			So(recorder.Code, ShouldEqual, 422)
+			So(fields(recorder), ShouldContain, FieldError{Field: "title", Code: "required", Message: "Title is required."})
+
+			recorder = serve("POST", "/api/v1/posts", fmt.Sprintf(`{"title": "%s"}`, strings.Repeat("a", maxTitleLength+1)), admin...)
+			So(recorder.Code, ShouldEqual, 422)
+			So(fields(recorder), ShouldContain, FieldError{Field: "title", Code: ErrTooLong, Message: "Title is too long."})
+		})
+
+		Convey("should require an absolute URL as the hostname", func() {
+			recorder := serve("PUT", "/api/v1/settings", `{"name": "Foo's blog", "description": "Foo's test blog", "hostname": "example.com"}`, admin...)
+			So(recorder.Code, ShouldEqual, 422)
+			So(fields(recorder), ShouldResemble, []FieldError{{Field: "hostname", Code: ErrInvalidHostname, Message: "Hostname must be an absolute URL, such as http://example.com."}})
+
+			recorder = serve("PUT", "/api/v1/settings", `{"name": "Foo's blog", "description": "Foo's test blog", "hostname": "http://example.com/blog"}`, admin...)
+			So(recorder.Code, ShouldEqual, 422)
+			So(fields(recorder)[0].Code, ShouldEqual, ErrInvalidHostname)
+		})
+
+		Convey("should store the hostname without a trailing slash", func() {
+			s := *Settings
+			defer s.Save()
+			s.Hostname = "http://example.com/"
+			payload, _ := json.Marshal(s)
+			recorder := serve("PUT", "/api/v1/settings", string(payload), admin...)
+			So(recorder.Code, ShouldEqual, 200)
+			So(Settings.Hostname, ShouldEqual, "http://example.com")
+		})
+
+		Convey("should require Mailgun settings only if recovery is enabled", func() {
+			recorder := serve("PUT", "/api/v1/settings", `{"name": "Foo's blog", "description": "Foo's test blog", "hostname": "http://example.com", "recovery": true}`, admin...)
+			So(recorder.Code, ShouldEqual, 422)
+			So(len(fields(recorder)), ShouldEqual, 2)
+			So(fields(recorder)[0].Field, ShouldEqual, "mgdomain")
+			So(fields(recorder)[1].Field, ShouldEqual, "mgprikey")
+		})
+
+		Convey("should render forms again with the errors next to their fields", func() {
+			var recorder = httptest.NewRecorder()
+			request, _ := http.NewRequest("POST", "/user/login", strings.NewReader(`email=foo@example&password=foo`))
+			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			server.ServeHTTP(recorder, request)
+			So(recorder.Code, ShouldEqual, 422)
+			doc, _ := goquery.NewDocumentFromReader(recorder.Body)
+			So(doc.Find("small.error").Text(), ShouldEqual, "Email is not a valid email address.")
+			value, _ := doc.Find("input[name=email]").Attr("value")
+			So(value, ShouldEqual, "foo@example")
+		})
+	})
+
+	Convey("password recovery", t, func() {
+
+		Convey("should be refused when it is disabled", func() {
+			So(Settings.Recovery, ShouldBeFalse)
+			recorder := serve("POST", "/api/v1/recoveries", `{"email": "vertigo-test@mailinator.com"}`, admin...)
+			So(recorder.Code, ShouldEqual, 403)
+			So(recorder.Body.String(), ShouldEqual, `{"error":"Password recovery is disabled.","code":"recovery_disabled"}`)
+		})
+	})
+}
+
+func TestFields(t *testing.T) {
+
+	read := func(url string) (int, string) {
+		var recorder = httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", url, nil)
+		server.ServeHTTP(recorder, request)
+		return recorder.Code, recorder.Body.String()
+	}
+	decode := func(body string) map[string]interface{} {
+		var v map[string]interface{}
+		json.Unmarshal([]byte(body), &v)
+		return v
+	}
+	keys := func(v interface{}) []string {
+		var names []string
+		for name := range v.(map[string]interface{}) {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	Convey("users on the JSON API", t, func() {
+
+		Convey("should leave out posts unless they are included", func() {
+			code, body := read("/api/v1/users/1")
+			So(code, ShouldEqual, 200)
+			So(decode(body), ShouldNotContainKey, "posts")
+			So(decode(body)["name"], ShouldEqual, "Juuso")
+		})
+
+		Convey("should embed posts without their bodies with include=posts", func() {
+			code, body := read("/api/v1/users/1?include=posts")
+			So(code, ShouldEqual, 200)
+			So(decode(body), ShouldContainKey, "posts")
+			for _, post := range decode(body)["posts"].([]interface{}) {
+				So(post, ShouldContainKey, "title")
+				So(post, ShouldNotContainKey, "content")
+				So(post, ShouldNotContainKey, "markdown")
+			}
+		})
+
+		Convey("should return only the selected fields", func() {
+			code, body := read("/api/v1/users/1?fields=id,name,posts&include=posts&fields[posts]=title,content")
+			So(code, ShouldEqual, 200)
+			So(keys(decode(body)), ShouldResemble, []string{"id", "name", "posts"})
+			for _, post := range decode(body)["posts"].([]interface{}) {
+				So(keys(post), ShouldResemble, []string{"content", "title"})
+			}
+			code, body = read("/api/v1/users?fields=name")
+			So(code, ShouldEqual, 200)
+			So(body, ShouldStartWith, `[{"name":"Juuso"}`)
+		})
+
+		Convey("should select the same way on the deprecated routes", func() {
+			code, body := read("/api/user/1")
+			So(code, ShouldEqual, 200)
+			So(decode(body), ShouldNotContainKey, "posts")
+			code, body = read("/api/users")
+			So(code, ShouldEqual, 200)
+			So(body, ShouldNotContainSubstring, `"posts"`)
+			code, body = read("/api/user/1?include=posts")
+			So(code, ShouldEqual, 200)
+			So(decode(body)["posts"], ShouldNotBeEmpty)
+			for _, post := range decode(body)["posts"].([]interface{}) {
+				So(post, ShouldNotContainKey, "content")
+				So(post, ShouldNotContainKey, "markdown")
+			}
+		})
+
+		Convey("should refuse unknown fields and resources", func() {
+			code, body := read("/api/v1/users/1?fields=name,shoesize")
+			So(code, ShouldEqual, 400)
+			So(body, ShouldContainSubstring, `"code":"invalid_fields"`)
+			code, body = read("/api/v1/users/1?include=comments")
+			So(code, ShouldEqual, 400)
+			So(body, ShouldContainSubstring, `"code":"invalid_include"`)
+			code, body = read("/api/v1/users?fields[comments]=id")
+			So(code, ShouldEqual, 400)
+			So(body, ShouldContainSubstring, `"code":"invalid_fields"`)
+		})
+	})
+
+	Convey("posts on the JSON API", t, func() {
+
+		Convey("should return only the selected fields", func() {
+			code, body := read("/api/v1/posts?fields=title,slug")
+			So(code, ShouldEqual, 200)
+			var posts []map[string]interface{}
+			json.Unmarshal([]byte(body), &posts)
+			for _, post := range posts {
+				So(keys(post), ShouldResemble, []string{"slug", "title"})
+			}
+		})
+
+		Convey("should refuse unknown fields", func() {
+			code, _ := read("/api/v1/posts?fields=title,author.name")
+			So(code, ShouldEqual, 400)
+			code, _ = read("/api/v1/posts?include=author")
+			So(code, ShouldEqual, 400)
+		})
+	})
+}
+
+func TestGraphQL(t *testing.T) {
+
+	type response struct {
+		Data   map[string]interface{}
+		Errors []struct {
+			Message    string
+			Extensions map[string]interface{}
+		}
+	}
+	query := func(body string, session bool) (int, response) {
+		var recorder = httptest.NewRecorder()
+		request, _ := http.NewRequest("POST", "/api/graphql", strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		if session {
+			request.AddCookie(&http.Cookie{Name: "user", Value: sessioncookie})
+		}
+		server.ServeHTTP(recorder, request)
+		var result response
+		json.Unmarshal(recorder.Body.Bytes(), &result)
+		return recorder.Code, result
+	}
+
+	Convey("the GraphQL endpoint", t, func() {
+
+		Convey("should list posts with their authors in pages", func() {
+			code, result := query(`{"query": "{ posts(first: 1) { edges { cursor node { title author { name } } } pageInfo { hasNextPage endCursor } totalCount } }"}`, false)
+			So(code, ShouldEqual, 200)
+			So(result.Errors, ShouldBeEmpty)
+			posts := result.Data["posts"].(map[string]interface{})
+			So(len(posts["edges"].([]interface{})), ShouldBeLessThanOrEqualTo, 1)
+		})
+
+		Convey("should refuse queries which are too deep or too complex", func() {
+			code, result := query(`{"query": "{ posts { edges { node { author { posts { edges { node { author { posts { edges { node { title } } } } } } } } } } } }"}`, false)
+			So(code, ShouldEqual, 400)
+			So(result.Errors[0].Extensions["code"], ShouldEqual, "query_too_deep")
+			code, result = query(`{"query": "{ posts(first: 50) { edges { node { author { posts(first: 50) { edges { node { title } } } } } } } }"}`, false)
+			So(code, ShouldEqual, 400)
+			So(result.Errors[0].Extensions["code"], ShouldEqual, "query_too_complex")
+		})
+
+		Convey("should limit introspection queries too", func() {
+			code, result := query(`{"query": "{ __schema { types { name fields { name args { name type { kind ofType { kind ofType { kind ofType { kind ofType { kind ofType { kind ofType { kind } } } } } } } } type { kind name } } } } }"}`, false)
+			So(code, ShouldEqual, 200)
+			So(result.Errors, ShouldBeEmpty)
+
+			code, result = query(`{"query": "{ __schema { types { fields { type { fields { type { fields { type { fields { name } } } } } } } } } }"}`, false)
+			So(code, ShouldEqual, 400)
+			So(result.Errors[0].Extensions["code"], ShouldEqual, "query_too_deep")
+			So(result.Errors[0].Message, ShouldContainSubstring, "lists")
+
+			code, result = query(`{"query": "{ __type(name: \"Post\") { ofType { ofType { ofType { ofType { ofType { ofType { ofType { ofType { ofType { ofType { ofType { ofType { ofType { ofType { ofType { name } } } } } } } } } } } } } } } } }"}`, false)
+			So(code, ShouldEqual, 400)
+			So(result.Errors[0].Extensions["code"], ShouldEqual, "query_too_deep")
+		})
+
+		Convey("should refuse mutations sent with GET", func() {
+			var recorder = httptest.NewRecorder()
+			request, _ := http.NewRequest("GET", `/api/graphql?query=mutation{publishPost(slug:"x"){id}}`, nil)
+			server.ServeHTTP(recorder, request)
+			So(recorder.Code, ShouldEqual, 405)
+		})
+
+		Convey("should require a session for mutations", func() {
+			code, result := query(`{"query": "mutation { createPost(input: {title: \"GraphQL\"}) { slug } }"}`, false)
+			So(code, ShouldEqual, 200)
+			So(result.Errors[0].Extensions["code"], ShouldEqual, "unauthorized")
+		})
+
+		Convey("should create, publish and update posts of the user", func() {
+			code, result := query(`{"query": "mutation { createPost(input: {title: \"GraphQL post\", markdown: \"Hello\", tags: \"graphql, api\"}) { slug published } }"}`, true)
+			So(code, ShouldEqual, 200)
+			So(result.Errors, ShouldBeEmpty)
+			created := result.Data["createPost"].(map[string]interface{})
+			So(created["published"], ShouldEqual, false)
+			slug := created["slug"].(string)
+
+			code, result = query(fmt.Sprintf(`{"query": "mutation { publishPost(slug: \"%s\") { published } }"}`, slug), true)
+			So(result.Errors, ShouldBeEmpty)
+			So(result.Data["publishPost"].(map[string]interface{})["published"], ShouldEqual, true)
+
+			code, result = query(`{"query": "{ tag(name: \"GraphQL\") { name posts { totalCount } } }"}`, false)
+			So(result.Errors, ShouldBeEmpty)
+			So(result.Data["tag"].(map[string]interface{})["posts"].(map[string]interface{})["totalCount"], ShouldEqual, 1)
+
+			code, result = query(fmt.Sprintf(`{"query": "mutation { updatePost(slug: \"%s\", input: {title: \"%s\"}) { title } }"}`, slug, strings.Repeat("a", maxTitleLength+1)), true)
+			So(result.Errors[0].Extensions["code"], ShouldEqual, "validation_failed")
+
+			code, result = query(fmt.Sprintf(`{"query": "mutation { unpublishPost(slug: \"%s\") { published } }"}`, slug), true)
+			So(result.Errors, ShouldBeEmpty)
+			code, result = query(fmt.Sprintf(`{"query": "{ post(slug: \"%s\") { title } }"}`, slug), false)
+			So(code, ShouldEqual, 200)
+			So(result.Data["post"], ShouldBeNil)
+		})
+	})
+}
+
+func TestWebhooks(t *testing.T) {
+
+	webhookRetryDelay = 10 * time.Millisecond
+
+	// The receiver checks the signature of every request and fails the first attempt of each event
+	// but pings, so that it has to be retried.
+	received := make(chan WebhookEvent, 10)
+	attempts := make(map[string]int)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Header.Get("X-Vertigo-Signature") != webhookSignature("s3cret", body) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		var event WebhookEvent
+		json.Unmarshal(body, &event)
+		attempts[event.ID]++
+		if event.Event != EventPing && attempts[event.ID] == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		received <- event
+	}))
+	defer receiver.Close()
+
+	admin := []requestOption{asJSON, withSession(sessioncookie)}
+	receive := func() WebhookEvent {
+		select {
+		case event := <-received:
+			return event
+		case <-time.After(5 * time.Second):
+			return WebhookEvent{}
+		}
+	}
+
+	var webhook Webhook
+
+	Convey("webhooks", t, func() {
+
+		Convey("should be refused with an invalid URL", func() {
+			recorder := serve("POST", "/api/v1/webhooks", `{"url": "ftp://example.com"}`, admin...)
+			So(recorder.Code, ShouldEqual, 422)
+			So(recorder.Body.String(), ShouldContainSubstring, `"code":"invalid_url"`)
+			recorder = serve("POST", "/api/v1/webhooks", fmt.Sprintf(`{"url": "%s", "events": "post.eaten"}`, receiver.URL), admin...)
+			So(recorder.Code, ShouldEqual, 422)
+		})
+
+		Convey("should be created by admins", func() {
+			recorder := serve("POST", "/api/v1/webhooks", fmt.Sprintf(`{"url": "%s", "secret": "s3cret", "events": "post.published"}`, receiver.URL), admin...)
+			So(recorder.Code, ShouldEqual, 200)
+			json.Unmarshal(recorder.Body.Bytes(), &webhook)
+			So(webhook.ID, ShouldBeGreaterThan, 0)
+			So(webhook.Secret, ShouldEqual, "s3cret")
+		})
+
+		Convey("should receive a signed test event", func() {
+			recorder := serve("POST", fmt.Sprintf("/api/v1/webhooks/%d/test", webhook.ID), "", admin...)
+			So(recorder.Code, ShouldEqual, 200)
+			var delivery WebhookDelivery
+			json.Unmarshal(recorder.Body.Bytes(), &delivery)
+			So(delivery.Status, ShouldEqual, 200)
+			So(receive().Event, ShouldEqual, EventPing)
+		})
+
+		Convey("should receive published posts and retry failed deliveries", func() {
+			recorder := serve("POST", "/api/v1/posts", `{"title": "Webhook post", "markdown": "Hello hooks"}`, admin...)
+			So(recorder.Code, ShouldEqual, 200)
+			var created Post
+			json.Unmarshal(recorder.Body.Bytes(), &created)
+
+			recorder = serve("PUT", "/api/v1/posts/"+created.Slug+"/published", "", admin...)
+			So(recorder.Code, ShouldEqual, 200)
+			event := receive()
+			So(event.Event, ShouldEqual, EventPostPublished)
+			So(event.Data.(map[string]interface{})["slug"], ShouldEqual, created.Slug)
+
+			// The attempt is recorded once the receiver has answered.
+			var deliveries []WebhookDelivery
+			for i := 0; i < 50 && (len(deliveries) == 0 || deliveries[0].Attempt != 2); i++ {
+				time.Sleep(20 * time.Millisecond)
+				recorder = serve("GET", fmt.Sprintf("/api/v1/webhooks/%d/deliveries", webhook.ID), "", admin...)
+				json.Unmarshal(recorder.Body.Bytes(), &deliveries)
+			}
+			So(len(deliveries), ShouldBeGreaterThanOrEqualTo, 3)
+			So(deliveries[0].Attempt, ShouldEqual, 2)
+			So(deliveries[0].Status, ShouldEqual, 200)
+			So(deliveries[1].Attempt, ShouldEqual, 1)
+			So(deliveries[1].Status, ShouldEqual, 503)
+			So(deliveries[1].EventID, ShouldEqual, deliveries[0].EventID)
+		})
+
+		Convey("should require a session", func() {
+			var recorder = httptest.NewRecorder()
+			request, _ := http.NewRequest("GET", "/api/v1/webhooks", nil)
+			server.ServeHTTP(recorder, request)
+			So(recorder.Code, ShouldEqual, 401)
+		})
+
+		Convey("should be deleted with their deliveries", func() {
+			recorder := serve("DELETE", fmt.Sprintf("/api/v1/webhooks/%d", webhook.ID), "", admin...)
+			So(recorder.Code, ShouldEqual, 200)
+			recorder = serve("GET", fmt.Sprintf("/api/v1/webhooks/%d", webhook.ID), "", admin...)
+			So(recorder.Code, ShouldEqual, 404)
+		})
+	})
+}
+
+func TestMicropub(t *testing.T) {
+
+	createToken := func(scope string) string {
+		var recorder = httptest.NewRecorder()
+		request, _ := http.NewRequest("POST", "/api/v1/tokens", strings.NewReader(fmt.Sprintf(`{"name": "Micropub client", "scope": "%s"}`, scope)))
+		request.Header.Set("Content-Type", "application/json")
+		request.AddCookie(&http.Cookie{Name: "user", Value: sessioncookie})
+		server.ServeHTTP(recorder, request)
+		var token AccessToken
+		json.Unmarshal(recorder.Body.Bytes(), &token)
+		return token.Token
+	}
+	token := createToken("")
+	mediaToken := createToken("media")
+
+	source := func(location string) map[string][]interface{} {
+		recorder := serve("GET", "/micropub?q=source&url="+location, "", withToken(token))
+		var entry struct {
+			Properties map[string][]interface{}
+		}
+		json.Unmarshal(recorder.Body.Bytes(), &entry)
+		return entry.Properties
+	}
+
+	Convey("the Micropub endpoint", t, func() {
+
+		Convey("should require a valid access token with the scope of the action", func() {
+			So(serve("GET", "/micropub?q=config", "").Code, ShouldEqual, 401)
+			So(serve("GET", "/micropub?q=config", "", withToken("not-a-token")).Code, ShouldEqual, 403)
+			recorder := serve("POST", "/micropub", "h=entry&content=Hello", asForm, withToken(mediaToken))
+			So(recorder.Code, ShouldEqual, 403)
+			So(recorder.Body.String(), ShouldContainSubstring, `"error":"insufficient_scope"`)
+		})
+
+		Convey("should advertise the media endpoint", func() {
+			recorder := serve("GET", "/micropub?q=config", "", withToken(token))
+			So(recorder.Code, ShouldEqual, 200)
+			So(recorder.Body.String(), ShouldContainSubstring, `"media-endpoint":"http://example.com/micropub/media"`)
+		})
+
+		Convey("should create, update, delete and undelete notes", func() {
+			recorder := serve("POST", "/micropub", "h=entry&content=Hello+from+Micropub&category[]=indieweb&category[]=notes&access_token="+token, asForm)
+			So(recorder.Code, ShouldEqual, 201)
+			location := recorder.HeaderMap.Get("Location")
+			So(location, ShouldStartWith, "http://example.com/post/")
+			properties := source(location)
+			So(properties["content"], ShouldResemble, []interface{}{"Hello from Micropub"})
+			So(properties["name"], ShouldResemble, []interface{}{"Hello from Micropub"})
+			So(properties["post-status"], ShouldResemble, []interface{}{"published"})
+
+			recorder = serve("POST", "/micropub", fmt.Sprintf(`{"action": "update", "url": "%s", "replace": {"content": ["Hello again"]}, "add": {"category": ["go"]}, "delete": {"category": ["notes"]}}`, location), asJSON, withToken(token))
+			So(recorder.Code, ShouldEqual, 204)
+			properties = source(location)
+			So(properties["content"], ShouldResemble, []interface{}{"Hello again"})
+			So(properties["category"], ShouldResemble, []interface{}{"indieweb", "go"})
+
+			recorder = serve("POST", "/micropub", fmt.Sprintf(`{"action": "update", "url": "%s", "delete": ["category"]}`, location), asJSON, withToken(token))
+			So(recorder.Code, ShouldEqual, 204)
+			So(source(location)["category"], ShouldBeEmpty)
+
+			slug := location[strings.LastIndex(location, "/")+1:]
+			So(serve("POST", "/micropub", fmt.Sprintf(`{"action": "delete", "url": "%s"}`, location), asJSON, withToken(token)).Code, ShouldEqual, 204)
+			So(serve("GET", "/api/v1/posts/"+slug, "").Code, ShouldEqual, 404)
+			So(serve("POST", "/micropub", "action=undelete&url="+location, asForm, withToken(token)).Code, ShouldEqual, 204)
+			So(serve("GET", "/api/v1/posts/"+slug, "").Code, ShouldEqual, 200)
+		})
+
+		Convey("should keep drafts unpublished", func() {
+			recorder := serve("POST", "/micropub", `{"type": ["h-entry"], "properties": {"name": ["Micropub draft"], "content": [{"html": "<p>Draft</p>"}], "mp-slug": ["micropub-draft"], "post-status": ["draft"]}}`, asJSON, withToken(token))
+			So(recorder.Code, ShouldEqual, 201)
+			So(recorder.HeaderMap.Get("Location"), ShouldEqual, "http://example.com/post/micropub-draft")
+			So(serve("GET", "/api/v1/posts/micropub-draft", "").Code, ShouldEqual, 404)
+			So(source("http://example.com/post/micropub-draft")["content"], ShouldResemble, []interface{}{map[string]interface{}{"html": "<p>Draft</p>"}})
+		})
+	})
+
+	Convey("the Micropub media endpoint", t, func() {
+
+		uploads := Config.Uploads
+		Config.Uploads, _ = ioutil.TempDir("", "vertigo-uploads")
+		defer func() {
+			os.RemoveAll(Config.Uploads)
+			Config.Uploads = uploads
+		}()
+		upload := func(content string) *httptest.ResponseRecorder {
+			var body bytes.Buffer
+			form := multipart.NewWriter(&body)
+			file, _ := form.CreateFormFile("file", "upload")
+			file.Write([]byte(content))
+			form.Close()
+			return serve("POST", "/micropub/media", body.String(), withContentType(form.FormDataContentType()), withToken(mediaToken))
+		}
+
+		Convey("should save images in the uploads directory", func() {
+			recorder := upload("GIF89a\x01\x00\x01\x00\x00\x00\x00;")
+			So(recorder.Code, ShouldEqual, 201)
+			location := recorder.HeaderMap.Get("Location")
+			So(location, ShouldStartWith, "http://example.com/uploads/")
+			So(location, ShouldEndWith, ".gif")
+		})
+
+		Convey("should refuse files which are not media", func() {
+			So(upload("<html><script>alert(1)</script></html>").Code, ShouldEqual, 400)
+		})
+	})
+}
+
+func TestWebmentions(t *testing.T) {
+
+	// The stand-in site advertises its webmention endpoint on every page, records the webmentions
+	// it receives, and serves pages which reply to or merely mention a post of the blog.
+	received := make(chan url.Values, 10)
+	var target string
+	reply := "Great   post!"
+	unlinked := false
+	standin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/webmention":
+			r.ParseForm()
+			received <- r.PostForm
+			w.WriteHeader(http.StatusAccepted)
+		case "/reply":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			if unlinked {
+				fmt.Fprint(w, `<p>Moved on.</p>`)
+				return
+			}
+			fmt.Fprintf(w, `<article class="h-entry"><a class="p-author h-card" href="/"><span class="p-name">Stand In</span></a> <a class="u-in-reply-to" href="%s">Re: Webmention post</a><div class="e-content">%s</div></article>`, target, reply)
+		case "/unrelated":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, `<p>Nothing to see here.</p>`)
+		default:
+			w.Header().Set("Link", `</webmention>; rel="webmention"`)
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, `<p>An article.</p>`)
+		}
+	}))
+	defer standin.Close()
+
+	mention := func(source, target string) *httptest.ResponseRecorder {
+		form := url.Values{"source": {source}, "target": {target}}
+		return serve("POST", "/webmention", form.Encode(), asForm)
+	}
+	// status polls the webmention of source until it is no longer pending.
+	status := func(source string) Webmention {
+		for i := 0; i < 100; i++ {
+			var mentions []Webmention
+			json.Unmarshal(serve("GET", "/api/v1/webmentions", "", withSession(sessioncookie)).Body.Bytes(), &mentions)
+			for _, m := range mentions {
+				if m.Source == source && m.Status != MentionPending {
+					return m
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		return Webmention{}
+	}
+
+	var post Post
+
+	Convey("webmentions", t, func() {
+
+		Convey("should be sent to the pages a published post links to", func() {
+			recorder := serve("POST", "/api/v1/posts", fmt.Sprintf(`{"title": "Webmention post", "markdown": "I read [an article](%s/article)."}`, standin.URL), asJSON, withSession(sessioncookie))
+			So(recorder.Code, ShouldEqual, 200)
+			json.Unmarshal(recorder.Body.Bytes(), &post)
+			target = "http://example.com/post/" + post.Slug
+
+			So(serve("PUT", "/api/v1/posts/"+post.Slug+"/published", "", withSession(sessioncookie)).Code, ShouldEqual, 200)
+			select {
+			case form := <-received:
+				So(form.Get("source"), ShouldEqual, target)
+				So(form.Get("target"), ShouldEqual, standin.URL+"/article")
+			case <-time.After(5 * time.Second):
+				So("no webmention received", ShouldBeEmpty)
+			}
+		})
+
+		Convey("should be refused for targets which are not posts of the blog", func() {
+			So(mention(standin.URL+"/reply", "http://example.com/post/no-such-post").Code, ShouldEqual, 400)
+			So(mention(standin.URL+"/reply", standin.URL+"/article").Code, ShouldEqual, 400)
+			So(mention("ftp://example.org/reply", target).Code, ShouldEqual, 400)
+		})
+
+		Convey("should be verified and shown once approved", func() {
+			recorder := mention(standin.URL+"/reply", target)
+			So(recorder.Code, ShouldEqual, 202)
+			verified := status(standin.URL + "/reply")
+			So(verified.Status, ShouldEqual, MentionVerified)
+			So(verified.Type, ShouldEqual, MentionReply)
+			So(verified.Author, ShouldEqual, "Stand In")
+			So(verified.AuthorURL, ShouldEqual, standin.URL+"/")
+			So(verified.Content, ShouldEqual, "Great post!")
+			So(serve("GET", "/post/"+post.Slug, "").Body.String(), ShouldNotContainSubstring, "Stand In")
+
+			So(serve("PUT", fmt.Sprintf("/api/v1/webmentions/%d/approved", verified.ID), "", withSession(sessioncookie)).Code, ShouldEqual, 200)
+			body := serve("GET", "/post/"+post.Slug, "").Body.String()
+			So(body, ShouldContainSubstring, "Stand In")
+			So(body, ShouldContainSubstring, "Great post!")
+		})
+
+		Convey("should await approval again when they are sent again", func() {
+			reply = "Buy   things!"
+			defer func() { reply = "Great   post!" }()
+			So(mention(standin.URL+"/reply", target).Code, ShouldEqual, 202)
+			changed := status(standin.URL + "/reply")
+			So(changed.Status, ShouldEqual, MentionVerified)
+			So(changed.Approved, ShouldBeFalse)
+			So(changed.Content, ShouldEqual, "Buy things!")
+			body := serve("GET", "/post/"+post.Slug, "").Body.String()
+			So(body, ShouldNotContainSubstring, "Buy things!")
+			So(body, ShouldNotContainSubstring, "Great post!")
+
+			unlinked = true
+			defer func() { unlinked = false }()
+			So(mention(standin.URL+"/reply", target).Code, ShouldEqual, 202)
+			gone := status(standin.URL + "/reply")
+			So(gone.Status, ShouldEqual, MentionInvalid)
+			So(gone.Content, ShouldBeEmpty)
+			So(gone.Author, ShouldNotEqual, "Stand In")
+		})
+
+		Convey("should be invalid if the source does not link to the target", func() {
+			So(mention(standin.URL+"/unrelated", target).Code, ShouldEqual, 202)
+			So(status(standin.URL+"/unrelated").Status, ShouldEqual, MentionInvalid)
+		})
+
+		Convey("should be deleted by the author of the post", func() {
+			invalid := status(standin.URL + "/unrelated")
+			So(serve("DELETE", fmt.Sprintf("/api/v1/webmentions/%d", invalid.ID), "", withSession(sessioncookie)).Code, ShouldEqual, 200)
+			So(serve("DELETE", fmt.Sprintf("/api/v1/webmentions/%d", invalid.ID), "", withSession(sessioncookie)).Code, ShouldEqual, 404)
+			So(serve("GET", "/api/v1/webmentions", "").Code, ShouldEqual, 401)
+		})
+	})
+}
+
+func TestActivityPub(t *testing.T) {
+
+	// The fake instance has two actors, alice and bob. The inbox of alice checks the signature of every
+	// activity delivered to it against the key of the author.
+	private, public, _ := generateKeyPair()
+	aliceKey, _ := parsePrivateKey(private)
+	private, bobPublic, _ := generateKeyPair()
+	bobKey, _ := parsePrivateKey(private)
+	var authorKey *rsa.PublicKey
+	received := make(chan map[string]interface{}, 10)
+	var instance *httptest.Server
+	instance = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		alice := instance.URL + "/users/alice"
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/users/alice":
+			w.Header().Set("Content-Type", activityContentType)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"id":                alice,
+				"type":              "Person",
+				"preferredUsername": "alice",
+				"name":              "Alice",
+				"url":               instance.URL + "/@alice",
+				"inbox":             alice + "/inbox",
+				"publicKey":         map[string]string{"id": alice + "#main-key", "owner": alice, "publicKeyPem": public},
+			})
+		case r.Method == "GET" && r.URL.Path == "/users/bob":
+			bob := instance.URL + "/users/bob"
+			w.Header().Set("Content-Type", activityContentType)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"id":                bob,
+				"type":              "Person",
+				"preferredUsername": "bob",
+				"name":              "Bob",
+				"url":               instance.URL + "/@bob",
+				"inbox":             bob + "/inbox",
+				"publicKey":         map[string]string{"id": bob + "#main-key", "owner": bob, "publicKeyPem": bobPublic},
+			})
+		case r.Method == "POST" && r.URL.Path == "/users/alice/inbox":
+			body, _ := ioutil.ReadAll(r.Body)
+			parameters := make(map[string]string)
+			for _, match := range signatureParameter.FindAllStringSubmatch(r.Header.Get("Signature"), -1) {
+				parameters[match[1]] = match[2]
+			}
+			signed, err := signingString(r, strings.Fields(parameters["headers"]))
+			signature, _ := base64.StdEncoding.DecodeString(parameters["signature"])
+			hash := sha256.Sum256([]byte(signed))
+			if err != nil || authorKey == nil || r.Header.Get("Digest") != bodyDigest(body) || rsa.VerifyPKCS1v15(authorKey, crypto.SHA256, hash[:], signature) != nil {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			var activity map[string]interface{}
+			json.Unmarshal(body, &activity)
+			received <- activity
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer instance.Close()
+	alice := instance.URL + "/users/alice"
+	bob := instance.URL + "/users/bob"
+
+	admin := []requestOption{asJSON, withSession(sessioncookie)}
+	// sendAs delivers activity to inbox signed by actor with key.
+	sendAs := func(actor string, key *rsa.PrivateKey, inbox string, activity map[string]interface{}) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(activity)
+		return serve("POST", inbox, string(body), withContentType(activityContentType), func(r *http.Request) {
+			signRequest(r, body, actor+"#main-key", key)
+		})
+	}
+	// send delivers activity to inbox as alice.
+	send := func(inbox string, activity map[string]interface{}) *httptest.ResponseRecorder {
+		return sendAs(alice, aliceKey, inbox, activity)
+	}
+	// receive returns the next activity of kind delivered to alice.
+	receive := func(kind string) map[string]interface{} {
+		for {
+			select {
+			case activity := <-received:
+				if activity["type"] == kind {
+					return activity
+				}
+			case <-time.After(5 * time.Second):
+				return nil
+			}
+		}
+	}
+
+	var post Post
+	var actor string
+
+	Convey("the ActivityPub actor of an author", t, func() {
+
+		Convey("should be found by WebFinger", func() {
+			recorder := serve("POST", "/api/v1/posts", `{"title": "Federated post", "markdown": "Hello fediverse"}`, admin...)
+			So(recorder.Code, ShouldEqual, 200)
+			json.Unmarshal(recorder.Body.Bytes(), &post)
+			actor = actorID(post.Author)
+
+			recorder = serve("GET", actor, "", admin...)
+			So(recorder.Code, ShouldEqual, 200)
+			So(recorder.HeaderMap.Get("Content-Type"), ShouldStartWith, activityContentType)
+			var person remoteActor
+			json.Unmarshal(recorder.Body.Bytes(), &person)
+			So(person.Inbox, ShouldEqual, actor+"/inbox")
+			So(person.PublicKey.ID, ShouldEqual, actor+"#main-key")
+			authorKey, _ = parsePublicKey(person.PublicKey.PublicKeyPem)
+			So(authorKey, ShouldNotBeNil)
+
+			recorder = serve("GET", "/.well-known/webfinger?resource=acct:"+person.PreferredUsername+"@example.com", "", admin...)
+			So(recorder.Code, ShouldEqual, 200)
+			So(recorder.Body.String(), ShouldContainSubstring, `"href":"`+actor+`"`)
+			So(serve("GET", "/.well-known/webfinger?resource=acct:nobody@example.com", "", admin...).Code, ShouldEqual, 404)
+		})
+
+		Convey("should refuse activities which are not signed by their actor", func() {
+			follow := map[string]interface{}{"id": alice + "#follow", "type": "Follow", "actor": alice, "object": actor}
+			So(serve("POST", actor+"/inbox", `{"type": "Follow"}`, admin...).Code, ShouldEqual, 401)
+			follow["actor"] = instance.URL + "/users/mallory"
+			So(send(actor+"/inbox", follow).Code, ShouldEqual, 403)
+		})
+
+		Convey("should accept followers", func() {
+			follow := map[string]interface{}{"id": alice + "#follow", "type": "Follow", "actor": alice, "object": actor}
+			So(send(actor+"/inbox", follow).Code, ShouldEqual, 202)
+			accept := receive("Accept")
+			So(accept, ShouldNotBeNil)
+			So(accept["actor"], ShouldEqual, actor)
+			So(accept["object"].(map[string]interface{})["id"], ShouldEqual, alice+"#follow")
+
+			var followers []Follower
+			json.Unmarshal(serve("GET", "/api/v1/followers", "", admin...).Body.Bytes(), &followers)
+			So(len(followers), ShouldEqual, 1)
+			So(followers[0].Name, ShouldEqual, "Alice")
+			So(followers[0].URL, ShouldEqual, instance.URL+"/@alice")
+		})
+
+		Convey("should deliver posts as they are published, edited and deleted", func() {
+			So(serve("PUT", "/api/v1/posts/"+post.Slug+"/published", "", admin...).Code, ShouldEqual, 200)
+			create := receive("Create")
+			So(create, ShouldNotBeNil)
+			object := create["object"].(map[string]interface{})
+			So(object["id"], ShouldEqual, postObjectID(post.ID))
+			So(object["url"], ShouldEqual, "http://example.com/post/"+post.Slug)
+			So(object["content"], ShouldContainSubstring, "Hello fediverse")
+
+			So(serve("PATCH", "/api/v1/posts/"+post.Slug, `{"markdown": "Hello again"}`, admin...).Code, ShouldEqual, 200)
+			update := receive("Update")
+			So(update, ShouldNotBeNil)
+			So(update["object"].(map[string]interface{})["content"], ShouldContainSubstring, "Hello again")
+		})
+
+		Convey("should show public replies as comments", func() {
+			note := map[string]interface{}{
+				"id":           alice + "/statuses/1",
+				"type":         "Note",
+				"attributedTo": alice,
+				"inReplyTo":    postObjectID(post.ID),
+				"content":      "<p>Nice <b>post</b>!</p>",
+				"to":           []string{publicAudience},
+			}
+			So(send(actor+"/inbox", map[string]interface{}{"id": alice + "/statuses/1/activity", "type": "Create", "actor": alice, "object": note}).Code, ShouldEqual, 202)
+			So(serve("GET", "/post/"+post.Slug, "", admin...).Body.String(), ShouldNotContainSubstring, "Nice post!")
+
+			var comments []Comment
+			json.Unmarshal(serve("GET", "/api/v1/comments", "", admin...).Body.Bytes(), &comments)
+			So(comments, ShouldNotBeEmpty)
+			comment := comments[0]
+			So(comment.Remote, ShouldEqual, alice+"/statuses/1")
+			So(comment.Actor, ShouldEqual, alice)
+			So(comment.Approved, ShouldBeFalse)
+			So(serve("PUT", fmt.Sprintf("/api/v1/comments/%d/approved", comment.ID), "", admin...).Code, ShouldEqual, 200)
+			body := serve("GET", "/post/"+post.Slug, "", admin...).Body.String()
+			So(body, ShouldContainSubstring, "Nice post!")
+			So(body, ShouldContainSubstring, "Alice")
+
+			So(send(actor+"/inbox", map[string]interface{}{"id": alice + "/statuses/1#delete", "type": "Delete", "actor": alice, "object": alice + "/statuses/1"}).Code, ShouldEqual, 202)
+			So(serve("GET", "/post/"+post.Slug, "", admin...).Body.String(), ShouldNotContainSubstring, "Nice post!")
+		})
+
+		Convey("should only let the author of a reply change or delete it", func() {
+			note := map[string]interface{}{
+				"id":           alice + "/statuses/2",
+				"type":         "Note",
+				"attributedTo": alice,
+				"inReplyTo":    postObjectID(post.ID),
+				"content":      "<p>Original reply</p>",
+				"to":           []string{publicAudience},
+			}
+			So(send(actor+"/inbox", map[string]interface{}{"id": alice + "/statuses/2/activity", "type": "Create", "actor": alice, "object": note}).Code, ShouldEqual, 202)
+			var comment Comment
+			So(db.Where("remote = ?", alice+"/statuses/2").First(&comment).Error, ShouldBeNil)
+			So(serve("PUT", fmt.Sprintf("/api/v1/comments/%d/approved", comment.ID), "", admin...).Code, ShouldEqual, 200)
+
+			forged := map[string]interface{}{"id": alice + "/statuses/2", "type": "Note", "attributedTo": bob, "inReplyTo": postObjectID(post.ID), "content": "<p>Forged reply</p>", "to": []string{publicAudience}}
+			So(sendAs(bob, bobKey, actor+"/inbox", map[string]interface{}{"id": bob + "/statuses/2#update", "type": "Update", "actor": bob, "object": forged}).Code, ShouldEqual, 202)
+			So(sendAs(bob, bobKey, actor+"/inbox", map[string]interface{}{"id": bob + "/statuses/2#delete", "type": "Delete", "actor": bob, "object": alice + "/statuses/2"}).Code, ShouldEqual, 202)
+			var kept Comment
+			So(db.Where("remote = ?", alice+"/statuses/2").First(&kept).Error, ShouldBeNil)
+			So(kept.Content, ShouldEqual, "Original reply")
+			So(kept.Approved, ShouldBeTrue)
+
+			note["content"] = "<p>Edited reply</p>"
+			So(send(actor+"/inbox", map[string]interface{}{"id": alice + "/statuses/2#update", "type": "Update", "actor": alice, "object": note}).Code, ShouldEqual, 202)
+			var edited Comment
+			So(db.Where("remote = ?", alice+"/statuses/2").First(&edited).Error, ShouldBeNil)
+			So(edited.Content, ShouldEqual, "Edited reply")
+			So(edited.Approved, ShouldBeFalse)
+
+			unattributed := map[string]interface{}{"id": alice + "/statuses/3", "type": "Note", "inReplyTo": postObjectID(post.ID), "content": "<p>Nobody's reply</p>", "to": []string{publicAudience}}
+			So(send(actor+"/inbox", map[string]interface{}{"id": alice + "/statuses/3/activity", "type": "Create", "actor": alice, "object": unattributed}).Code, ShouldEqual, 202)
+			var count int
+			db.Model(Comment{}).Where("remote = ?", alice+"/statuses/3").Count(&count)
+			So(count, ShouldEqual, 0)
+
+			So(serve("DELETE", fmt.Sprintf("/api/v1/comments/%d", comment.ID), "", admin...).Code, ShouldEqual, 200)
+		})
+
+		Convey("should tell followers of deleted posts and removed followers", func() {
+			So(serve("DELETE", "/api/v1/posts/"+post.Slug, "", admin...).Code, ShouldEqual, 200)
+			deleted := receive("Delete")
+			So(deleted, ShouldNotBeNil)
+			So(deleted["object"].(map[string]interface{})["id"], ShouldEqual, postObjectID(post.ID))
+
+			var followers []Follower
+			json.Unmarshal(serve("GET", "/api/v1/followers", "", admin...).Body.Bytes(), &followers)
+			So(serve("DELETE", fmt.Sprintf("/api/v1/followers/%d", followers[0].ID), "", admin...).Code, ShouldEqual, 200)
+			So(receive("Reject"), ShouldNotBeNil)
+			json.Unmarshal(serve("GET", "/api/v1/followers", "", admin...).Body.Bytes(), &followers)
+			So(followers, ShouldBeEmpty)
+		})
+	})
+}
+
+func TestCommands(t *testing.T) {
+
+	run := func(args ...string) (int, string) {
+		var stdout, stderr bytes.Buffer
+		code := Command(args, &stdout, &stderr)
+		return code, stdout.String() + stderr.String()
+	}
+	// runJSON runs the command with -json last, after any positional arguments.
+	runJSON := func(v interface{}, args ...string) int {
+		var stdout bytes.Buffer
+		code := Command(append(args, "-json"), &stdout, ioutil.Discard)
+		json.Unmarshal(stdout.Bytes(), v)
+		return code
+	}
+	admin := []requestOption{asJSON, withSession(sessioncookie)}
+
+	Convey("the admin commands", t, func() {
+
+		Convey("should refuse unknown commands and missing arguments", func() {
+			code, out := run("nothing")
+			So(code, ShouldEqual, ExitUsage)
+			So(out, ShouldContainSubstring, "unknown command")
+			code, out = run("user", "create")
+			So(code, ShouldEqual, ExitUsage)
+			So(out, ShouldContainSubstring, "usage: vertigo user create")
+			code, _ = run("post", "publish")
+			So(code, ShouldEqual, ExitUsage)
+		})
+
+		Convey("should create users with a generated password", func() {
+			var created map[string]interface{}
+			So(runJSON(&created, "user", "create", "-email", "cli@example.com", "-name", "Cli"), ShouldEqual, ExitOK)
+			So(created["role"], ShouldEqual, RoleAuthor)
+			So(created["password"], ShouldNotBeEmpty)
+
+			code, out := run("user", "list")
+			So(code, ShouldEqual, ExitOK)
+			So(out, ShouldContainSubstring, "cli@example.com")
+			code, _ = run("user", "create", "-email", "king@example.com", "-role", "king")
+			So(code, ShouldEqual, ExitError)
+		})
+
+		Convey("should change roles and reset passwords", func() {
+			code, _ := run("user", "set-role", "-email", "cli@example.com", "-role", "admin")
+			So(code, ShouldEqual, ExitOK)
+			code, _ = run("user", "reset-password", "-email", "cli@example.com", "-password", "Cli-password1")
+			So(code, ShouldEqual, ExitOK)
+			cli, err := User{Email: "cli@example.com"}.GetByEmail()
+			So(err, ShouldBeNil)
+			So(cli.Role, ShouldEqual, RoleAdmin)
+			So(CompareHash(cli.Digest, "Cli-password1"), ShouldBeTrue)
+			So(cli.Recovery, ShouldBeEmpty)
+
+			code, _ = run("user", "set-role", "-email", "nobody@example.com", "-role", "admin")
+			So(code, ShouldEqual, ExitError)
+		})
+
+		Convey("should publish, unpublish and delete posts", func() {
+			var created Post
+			json.Unmarshal(serve("POST", "/api/v1/posts", `{"title": "Command post", "markdown": "From the shell"}`, admin...).Body.Bytes(), &created)
+			So(created.Slug, ShouldNotBeEmpty)
+			published := func() bool {
+				var posts []map[string]interface{}
+				runJSON(&posts, "post", "list")
+				for _, p := range posts {
+					if p["slug"] == created.Slug {
+						return p["published"].(bool)
+					}
+				}
+				return false
+			}
+
+			code, _ := run("post", "publish", created.Slug, "-json")
+			So(code, ShouldEqual, ExitOK)
+			So(published(), ShouldBeTrue)
+			code, _ = run("post", "unpublish", created.Slug)
+			So(code, ShouldEqual, ExitOK)
+			So(published(), ShouldBeFalse)
+			So(serve("PUT", fmt.Sprintf("/api/v1/drafts/%d", created.ID), `{"markdown": "Unsaved"}`, admin...).Code, ShouldEqual, 200)
+			So(db.Create(&Webmention{Post: created.ID, Source: "http://example.com/cli-mention", Target: "http://example.com/post/" + created.Slug}).Error, ShouldBeNil)
+			code, _ = run("post", "delete", created.Slug)
+			So(code, ShouldEqual, ExitOK)
+			So(serve("GET", "/api/v1/posts/"+created.Slug, "", admin...).Code, ShouldEqual, 404)
+			var drafts, mentions int
+			db.Model(Draft{}).Where("post = ?", created.ID).Count(&drafts)
+			db.Model(Webmention{}).Where("post = ?", created.ID).Count(&mentions)
+			So(drafts, ShouldEqual, 0)
+			So(mentions, ShouldEqual, 0)
+			code, _ = run("post", "delete", created.Slug)
+			So(code, ShouldEqual, ExitError)
+		})
+
+		Convey("should read and write settings", func() {
+			description := Settings.Description
+			defer func() {
+				s := *Settings
+				s.Description = description
+				s.Save()
+			}()
+			var name string
+			So(runJSON(&name, "settings", "get", "name"), ShouldEqual, ExitOK)
+			So(name, ShouldEqual, Settings.Name)
+
+			code, _ := run("settings", "set", "description", "Written from the shell")
+			So(code, ShouldEqual, ExitOK)
+			So(Settings.Description, ShouldEqual, "Written from the shell")
+			code, _ = run("settings", "set", "cookiehash", "stolen")
+			So(code, ShouldEqual, ExitError)
+			code, _ = run("settings", "set", "hostname", "example.com")
+			So(code, ShouldEqual, ExitError)
+			So(Settings.Hostname, ShouldEqual, "http://example.com")
+			code, _ = run("settings", "get", "nothing")
+			So(code, ShouldEqual, ExitError)
+		})
+	})
+}
+
+func TestConfiguration(t *testing.T) {
+
+	// setenv sets the environment variables of values, unsetting empty ones, and returns a function
+	// which restores them.
+	setenv := func(values map[string]string) func() {
+		old := make(map[string]string)
+		for name, value := range values {
+			old[name] = os.Getenv(name)
+			if value == "" {
+				os.Unsetenv(name)
+			} else {
+				os.Setenv(name, value)
+			}
+		}
+		return func() {
+			for name, value := range old {
+				if value == "" {
+					os.Unsetenv(name)
+				} else {
+					os.Setenv(name, value)
+				}
+			}
+		}
+	}
+	clean := map[string]string{"DATABASE_URL": "", "PORT": "", "VERTIGO_DRIVER": "", "VERTIGO_DSN": "", "VERTIGO_LISTEN": "", "VERTIGO_UPLOADS": ""}
+	parse := func(args ...string) (Configuration, error) {
+		c := ConfigFromEnv()
+		fs := flag.NewFlagSet("vertigo", flag.ContinueOnError)
+		fs.SetOutput(ioutil.Discard)
+		c.Flags(fs)
+		if err := fs.Parse(args); err != nil {
+			return c, err
+		}
+		c.Resolve(fs)
+		return c, c.Validate()
+	}
+
+	Convey("the configuration", t, func() {
+
+		Convey("should default to SQLite", func() {
+			defer setenv(clean)()
+			c, err := parse()
+			So(err, ShouldBeNil)
+			So(c.Driver, ShouldEqual, "sqlite3")
+			So(c.DSN, ShouldEqual, "./vertigo.db")
+			So(c.Listen, ShouldEqual, ":8000")
+			So(c.StaticDir("css"), ShouldEqual, "public"+string(filepath.Separator)+"css"+string(filepath.Separator))
+		})
+
+		Convey("should prefer VERTIGO_* variables to legacy ones", func() {
+			defer setenv(clean)()
+			defer setenv(map[string]string{"DATABASE_URL": "postgres://legacy", "PORT": "5000", "VERTIGO_DSN": "postgres://vertigo"})()
+			c, err := parse()
+			So(err, ShouldBeNil)
+			So(c.Driver, ShouldEqual, "postgres")
+			So(c.DSN, ShouldEqual, "postgres://vertigo")
+			So(c.Listen, ShouldEqual, ":5000")
+		})
+
+		Convey("should prefer flags to the environment", func() {
+			defer setenv(clean)()
+			defer setenv(map[string]string{"VERTIGO_LISTEN": ":7000", "VERTIGO_UPLOADS": "/srv/uploads"})()
+			c, err := parse("-listen=127.0.0.1:9000")
+			So(err, ShouldBeNil)
+			So(c.Listen, ShouldEqual, "127.0.0.1:9000")
+			So(c.Uploads, ShouldEqual, "/srv/uploads")
+		})
+
+		Convey("should only use DATABASE_URL when no other driver flag was given", func() {
+			defer setenv(clean)()
+			defer setenv(map[string]string{"DATABASE_URL": "postgres://legacy"})()
+			c, err := parse()
+			So(err, ShouldBeNil)
+			So(c.Driver, ShouldEqual, "postgres")
+			So(c.DSN, ShouldEqual, "postgres://legacy")
+			c, err = parse("-driver=sqlite3")
+			So(err, ShouldBeNil)
+			So(c.DSN, ShouldEqual, "./vertigo.db")
+			c, err = parse("-driver=sqlite3", "-dsn=/tmp/blog.db")
+			So(err, ShouldBeNil)
+			So(c.DSN, ShouldEqual, "/tmp/blog.db")
+			c, err = parse("-driver=postgres")
+			So(err, ShouldBeNil)
+			So(c.DSN, ShouldEqual, "postgres://legacy")
+		})
+
+		Convey("should only use DATABASE_URL when VERTIGO_DRIVER is postgres or not set", func() {
+			defer setenv(clean)()
+			defer setenv(map[string]string{"DATABASE_URL": "postgres://legacy", "VERTIGO_DRIVER": "sqlite3"})()
+			c, err := parse()
+			So(err, ShouldBeNil)
+			So(c.Driver, ShouldEqual, "sqlite3")
+			So(c.DSN, ShouldEqual, "./vertigo.db")
+
+			defer setenv(map[string]string{"VERTIGO_DRIVER": "mysql"})()
+			_, err = parse()
+			So(err, ShouldNotBeNil)
+			defer setenv(map[string]string{"VERTIGO_DSN": "user:password@/vertigo"})()
+			c, err = parse()
+			So(err, ShouldBeNil)
+			So(c.DSN, ShouldEqual, "user:password@/vertigo")
+			defer setenv(map[string]string{"VERTIGO_DSN": ""})()
+			c, err = parse("-driver=postgres")
+			So(err, ShouldBeNil)
+			So(c.DSN, ShouldEqual, "postgres://legacy")
+
+			defer setenv(map[string]string{"VERTIGO_DRIVER": "postgres", "VERTIGO_DSN": ""})()
+			c, err = parse()
+			So(err, ShouldBeNil)
+			So(c.Driver, ShouldEqual, "postgres")
+			So(c.DSN, ShouldEqual, "postgres://legacy")
+		})
+
+		Convey("should refuse unknown drivers and servers without a DSN", func() {
+			defer setenv(clean)()
+			_, err := parse("-driver=oracle")
+			So(err, ShouldNotBeNil)
+			_, err = parse("-driver=mysql")
+			So(err, ShouldNotBeNil)
+			_, err = parse("-driver=mysql", "-dsn=user:password@/vertigo")
+			So(err, ShouldBeNil)
+		})
+	})
+}
+
+func TestMigrations(t *testing.T) {
+
+	latest, previous := migrations[len(migrations)-1], migrations[len(migrations)-2]
+	status := func() map[int64]bool {
+		applied := make(map[int64]bool)
+		states, err := MigrationStatus()
+		So(err, ShouldBeNil)
+		So(states, ShouldHaveLength, len(migrations))
+		for _, state := range states {
+			applied[state.Version] = state.Applied
+		}
+		return applied
+	}
+
+	Convey("the migrations", t, func() {
+
+		Convey("should all be applied after setup", func() {
+			for version, applied := range status() {
+				So(version, ShouldBeGreaterThan, 0)
+				So(applied, ShouldBeTrue)
+			}
+			done, err := MigrateUp(0)
+			So(err, ShouldBeNil)
+			So(done, ShouldBeEmpty)
+		})
+
+		Convey("should roll back and re-apply the latest migration", func() {
+			done, err := MigrateDown(1)
+			So(err, ShouldBeNil)
+			So(done, ShouldHaveLength, 1)
+			So(done[0].Version, ShouldEqual, latest.Version)
+			So(status()[latest.Version], ShouldBeFalse)
+
+			done, err = MigrateUp(0)
+			So(err, ShouldBeNil)
+			So(done, ShouldHaveLength, 1)
+			So(done[0].Version, ShouldEqual, latest.Version)
+			So(status()[latest.Version], ShouldBeTrue)
+		})
+
+		Convey("should keep user roles which were already set", func() {
+			tx := db.Begin()
+			defer tx.Rollback()
+			var before, after []User
+			So(tx.Order("id").Find(&before).Error, ShouldBeNil)
+			So(before, ShouldNotBeEmpty)
+			for _, m := range migrations {
+				if m.Version == 2 {
+					So(m.Up(tx), ShouldBeNil)
+				}
+			}
+			So(tx.Order("id").Find(&after).Error, ShouldBeNil)
+			So(after, ShouldHaveLength, len(before))
+			for i := range before {
+				So(after[i].Role, ShouldEqual, before[i].Role)
+			}
+		})
+
+		Convey("should only apply migrations up to the target version", func() {
+			done, err := MigrateDown(2)
+			So(err, ShouldBeNil)
+			So(done, ShouldHaveLength, 2)
+			done, err = MigrateUp(previous.Version)
+			So(err, ShouldBeNil)
+			So(done, ShouldHaveLength, 1)
+			So(status()[previous.Version], ShouldBeTrue)
+			So(status()[latest.Version], ShouldBeFalse)
+			_, err = MigrateUp(0)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("should be run by the migrate commands", func() {
+			var stdout bytes.Buffer
+			So(Command([]string{"migrate", "down"}, &stdout, ioutil.Discard), ShouldEqual, ExitOK)
+			So(stdout.String(), ShouldContainSubstring, latest.Name)
+
+			stdout.Reset()
+			So(Command([]string{"migrate", "status"}, &stdout, ioutil.Discard), ShouldEqual, ExitOK)
+			lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+			So(lines, ShouldHaveLength, len(migrations)+1)
+			So(lines[len(lines)-1], ShouldContainSubstring, latest.Name)
+			So(lines[len(lines)-1], ShouldEndWith, "pending")
+
+			var states []MigrationState
+			stdout.Reset()
+			So(Command([]string{"migrate", "status", "-json"}, &stdout, ioutil.Discard), ShouldEqual, ExitOK)
+			So(json.Unmarshal(stdout.Bytes(), &states), ShouldBeNil)
+			So(states, ShouldHaveLength, len(migrations))
+			So(states[len(states)-1].Applied, ShouldBeFalse)
+
+			stdout.Reset()
+			So(Command([]string{"migrate", "up"}, &stdout, ioutil.Discard), ShouldEqual, ExitOK)
+			So(stdout.String(), ShouldContainSubstring, latest.Name)
+			So(status()[latest.Version], ShouldBeTrue)
+
+			So(Command([]string{"migrate", "down", "-steps", "0"}, ioutil.Discard, ioutil.Discard), ShouldEqual, ExitUsage)
+		})
+	})
+}
+
+func TestBackup(t *testing.T) {
+
+	admin := []requestOption{asJSON, withSession(sessioncookie)}
+
+	Convey("the backups", t, func() {
+
+		uploads := Config.Uploads
+		Config.Uploads, _ = ioutil.TempDir("", "vertigo-uploads")
+		dir, _ := ioutil.TempDir("", "vertigo-backup")
+		defer func() {
+			os.RemoveAll(Config.Uploads)
+			os.RemoveAll(dir)
+			Config.Uploads = uploads
+		}()
+		ioutil.WriteFile(filepath.Join(Config.Uploads, "image.gif"), []byte("GIF89a"), 0644)
+		archive := filepath.Join(dir, "backup.zip")
+
+		Convey("should be downloadable by admins only", func() {
+			So(serve("GET", "/api/v1/backup", "").Code, ShouldEqual, 401)
+			recorder := serve("GET", "/api/v1/backup", "", admin...)
+			So(recorder.Code, ShouldEqual, 200)
+			So(recorder.HeaderMap.Get("Content-Type"), ShouldEqual, "application/zip")
+			So(recorder.HeaderMap.Get("Content-Disposition"), ShouldStartWith, `attachment; filename="vertigo-backup-`)
+			reader, err := zip.NewReader(bytes.NewReader(recorder.Body.Bytes()), int64(recorder.Body.Len()))
+			So(err, ShouldBeNil)
+			var names []string
+			for _, f := range reader.File {
+				names = append(names, f.Name)
+			}
+			So(names, ShouldContain, "manifest.json")
+			So(names, ShouldContain, "users.json")
+			So(names, ShouldContain, "posts.json")
+			So(names, ShouldContain, "uploads/image.gif")
+		})
+
+		Convey("should restore the content as it was when backed up", func() {
+			var manifest BackupManifest
+			var stdout bytes.Buffer
+			So(Command([]string{"backup", "-json", archive}, &stdout, ioutil.Discard), ShouldEqual, ExitOK)
+			So(json.Unmarshal(stdout.Bytes(), &manifest), ShouldBeNil)
+			So(manifest.Version, ShouldEqual, BackupVersion)
+			So(manifest.Counts["users"], ShouldBeGreaterThan, 0)
+			So(manifest.Counts["posts"], ShouldBeGreaterThan, 0)
+			So(manifest.Counts["uploads"], ShouldEqual, 1)
+
+			var created Post
+			json.Unmarshal(serve("POST", "/api/v1/posts", `{"title": "After backup", "markdown": "Gone after restore"}`, admin...).Body.Bytes(), &created)
+			So(created.Slug, ShouldNotBeEmpty)
+			os.Remove(filepath.Join(Config.Uploads, "image.gif"))
+
+			So(Command([]string{"restore", archive}, ioutil.Discard, ioutil.Discard), ShouldEqual, ExitOK)
+			So(serve("GET", "/api/v1/posts/"+created.Slug, "", admin...).Code, ShouldEqual, 404)
+			So(serve("GET", fmt.Sprintf("/api/user/%d", user.ID), "").Code, ShouldEqual, 200)
+			content, err := ioutil.ReadFile(filepath.Join(Config.Uploads, "image.gif"))
+			So(err, ShouldBeNil)
+			So(string(content), ShouldEqual, "GIF89a")
+		})
+
+		Convey("should remove uploads which are not in the backup", func() {
+			So(Command([]string{"backup", archive}, ioutil.Discard, ioutil.Discard), ShouldEqual, ExitOK)
+			os.MkdirAll(filepath.Join(Config.Uploads, "2016"), 0755)
+			ioutil.WriteFile(filepath.Join(Config.Uploads, "2016", "later.gif"), []byte("GIF89a"), 0644)
+
+			So(Command([]string{"restore", archive}, ioutil.Discard, ioutil.Discard), ShouldEqual, ExitOK)
+			_, err := os.Stat(filepath.Join(Config.Uploads, "2016"))
+			So(os.IsNotExist(err), ShouldBeTrue)
+			_, err = os.Stat(filepath.Join(Config.Uploads, "image.gif"))
+			So(err, ShouldBeNil)
+		})
+
+		Convey("should restore webhooks and clear their deliveries", func() {
+			webhook := Webhook{URL: "http://example.com/backed-up-hook", Secret: "backup secret", Events: EventPostPublished, Disabled: true}
+			So(db.Create(&webhook).Error, ShouldBeNil)
+			defer db.Delete(&webhook)
+			So(Command([]string{"backup", archive}, ioutil.Discard, ioutil.Discard), ShouldEqual, ExitOK)
+
+			So(db.Model(&webhook).Updates(map[string]interface{}{"secret": "changed"}).Error, ShouldBeNil)
+			So(db.Create(&WebhookDelivery{Webhook: webhook.ID, Event: EventPostPublished, EventID: "before-restore"}).Error, ShouldBeNil)
+
+			So(Command([]string{"restore", archive}, ioutil.Discard, ioutil.Discard), ShouldEqual, ExitOK)
+			var restored Webhook
+			So(db.Where(&Webhook{ID: webhook.ID}).First(&restored).Error, ShouldBeNil)
+			So(restored.URL, ShouldEqual, webhook.URL)
+			So(restored.Secret, ShouldEqual, "backup secret")
+			So(restored.Disabled, ShouldBeTrue)
+			var deliveries int
+			db.Model(WebhookDelivery{}).Where("webhook = ?", webhook.ID).Count(&deliveries)
+			So(deliveries, ShouldEqual, 0)
+		})
+
+		Convey("should restore webmentions and clear deleted posts", func() {
+			var post Post
+			So(db.Order("id").First(&post).Error, ShouldBeNil)
+			webmention := Webmention{Post: post.ID, Source: "http://example.com/backed-up-reply", Target: "http://example.com/post/" + post.Slug, Type: "reply", Approved: true}
+			So(db.Create(&webmention).Error, ShouldBeNil)
+			defer db.Delete(&webmention)
+			So(Command([]string{"backup", archive}, ioutil.Discard, ioutil.Discard), ShouldEqual, ExitOK)
+
+			So(db.Delete(&webmention).Error, ShouldBeNil)
+			So(db.Create(&DeletedPost{Post: post.ID, Author: user.ID, Slug: "deleted-before-restore"}).Error, ShouldBeNil)
+
+			So(Command([]string{"restore", archive}, ioutil.Discard, ioutil.Discard), ShouldEqual, ExitOK)
+			var restored Webmention
+			So(db.Where(&Webmention{ID: webmention.ID}).First(&restored).Error, ShouldBeNil)
+			So(restored.Post, ShouldEqual, post.ID)
+			So(restored.Source, ShouldEqual, webmention.Source)
+			So(restored.Approved, ShouldBeTrue)
+			var deleted int
+			db.Model(DeletedPost{}).Count(&deleted)
+			So(deleted, ShouldEqual, 0)
+		})
+
+		Convey("should restore actor keys and followers", func() {
+			key, err := actorKey(user.ID)
+			So(err, ShouldBeNil)
+			follower := Follower{Author: user.ID, Actor: "http://example.com/backed-up-actor", Inbox: "http://example.com/inbox", Follow: "http://example.com/follow/1"}
+			So(db.Create(&follower).Error, ShouldBeNil)
+			defer db.Delete(&follower)
+			So(Command([]string{"backup", archive}, ioutil.Discard, ioutil.Discard), ShouldEqual, ExitOK)
+
+			So(db.Delete(&follower).Error, ShouldBeNil)
+			So(db.Delete(&key).Error, ShouldBeNil)
+
+			So(Command([]string{"restore", archive}, ioutil.Discard, ioutil.Discard), ShouldEqual, ExitOK)
+			restoredKey, err := actorKey(user.ID)
+			So(err, ShouldBeNil)
+			So(restoredKey.ID, ShouldEqual, key.ID)
+			So(restoredKey.PrivateKey, ShouldEqual, key.PrivateKey)
+			So(restoredKey.PublicKey, ShouldEqual, key.PublicKey)
+			var restored Follower
+			So(db.Where(&Follower{ID: follower.ID}).First(&restored).Error, ShouldBeNil)
+			So(restored.Actor, ShouldEqual, follower.Actor)
+			So(restored.Inbox, ShouldEqual, follower.Inbox)
+			So(restored.Follow, ShouldEqual, follower.Follow)
+		})
+
+		Convey("should revoke access tokens", func() {
+			var token AccessToken
+			json.Unmarshal(serve("POST", "/api/v1/tokens", `{"name": "Backup client"}`, admin...).Body.Bytes(), &token)
+			So(token.Token, ShouldNotBeEmpty)
+			So(serve("GET", "/micropub?q=config", "", withToken(token.Token)).Code, ShouldEqual, 200)
+			So(Command([]string{"backup", archive}, ioutil.Discard, ioutil.Discard), ShouldEqual, ExitOK)
+
+			So(Command([]string{"restore", archive}, ioutil.Discard, ioutil.Discard), ShouldEqual, ExitOK)
+			So(serve("GET", "/micropub?q=config", "", withToken(token.Token)).Code, ShouldEqual, 403)
+			var count int
+			db.Model(AccessToken{}).Count(&count)
+			So(count, ShouldEqual, 0)
+		})
+
+		Convey("should refuse archives from a newer version", func() {
+			f, _ := os.Create(archive)
+			w := zip.NewWriter(f)
+			manifest, _ := w.Create("manifest.json")
+			json.NewEncoder(manifest).Encode(BackupManifest{Version: BackupVersion + 1})
+			w.Close()
+			f.Close()
+
+			var stderr bytes.Buffer
+			So(Command([]string{"restore", archive}, ioutil.Discard, &stderr), ShouldEqual, ExitError)
+			So(stderr.String(), ShouldContainSubstring, "newer than the supported version")
+		})
+	})
+}
+
+func TestWordPress(t *testing.T) {
+
+	export := `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:excerpt="http://wordpress.org/export/1.2/excerpt/" xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<wp:author><wp:author_login>writer</wp:author_login><wp:author_email>wordpress-writer@example.com</wp:author_email><wp:author_display_name>Writer</wp:author_display_name></wp:author>
+	<item>
+		<title>Imported post</title>
+		<dc:creator>writer</dc:creator>
+		<content:encoded><![CDATA[First paragraph with <img src="http://old.example.com/wp-content/uploads/2015/01/photo.jpg">
+
+Second paragraph]]></content:encoded>
+		<excerpt:encoded><![CDATA[]]></excerpt:encoded>
+		<wp:post_id>10</wp:post_id>
+		<wp:post_date>2015-01-02 03:04:05</wp:post_date>
+		<wp:post_date_gmt>2015-01-02 01:04:05</wp:post_date_gmt>
+		<wp:post_name>imported-post</wp:post_name>
+		<wp:status>publish</wp:status>
+		<wp:post_type>post</wp:post_type>
+		<category domain="category" nicename="news"><![CDATA[News]]></category>
+		<category domain="post_tag" nicename="go"><![CDATA[Go]]></category>
+		<wp:comment><wp:comment_id>1</wp:comment_id><wp:comment_author>Reader</wp:comment_author><wp:comment_date_gmt>2015-01-03 00:00:00</wp:comment_date_gmt><wp:comment_content>Nice</wp:comment_content><wp:comment_approved>1</wp:comment_approved><wp:comment_parent>0</wp:comment_parent></wp:comment>
+		<wp:comment><wp:comment_id>2</wp:comment_id><wp:comment_author>Writer</wp:comment_author><wp:comment_date_gmt>2015-01-04 00:00:00</wp:comment_date_gmt><wp:comment_content>Thanks</wp:comment_content><wp:comment_approved>1</wp:comment_approved><wp:comment_parent>1</wp:comment_parent></wp:comment>
+		<wp:comment><wp:comment_id>3</wp:comment_id><wp:comment_author>Spammer</wp:comment_author><wp:comment_content>Buy</wp:comment_content><wp:comment_approved>spam</wp:comment_approved></wp:comment>
+	</item>
+	<item>
+		<title>Imported draft</title>
+		<dc:creator>someone-else</dc:creator>
+		<content:encoded><![CDATA[<p>Unfinished</p>]]></content:encoded>
+		<wp:post_id>11</wp:post_id>
+		<wp:post_date>2015-02-01 00:00:00</wp:post_date>
+		<wp:post_date_gmt>0000-00-00 00:00:00</wp:post_date_gmt>
+		<wp:post_name></wp:post_name>
+		<wp:status>draft</wp:status>
+		<wp:post_type>post</wp:post_type>
+	</item>
+	<item>
+		<title>Imported child page</title>
+		<dc:creator>writer</dc:creator>
+		<content:encoded><![CDATA[Child]]></content:encoded>
+		<wp:post_id>13</wp:post_id>
+		<wp:post_parent>12</wp:post_parent>
+		<wp:post_name>imported-child-page</wp:post_name>
+		<wp:status>publish</wp:status>
+		<wp:post_type>page</wp:post_type>
+	</item>
+	<item>
+		<title>Imported parent page</title>
+		<dc:creator>writer</dc:creator>
+		<content:encoded><![CDATA[Parent]]></content:encoded>
+		<wp:post_id>12</wp:post_id>
+		<wp:post_name>imported-parent-page</wp:post_name>
+		<wp:status>publish</wp:status>
+		<wp:post_type>page</wp:post_type>
+	</item>
+	<item>
+		<title>photo</title>
+		<wp:post_id>14</wp:post_id>
+		<wp:post_type>attachment</wp:post_type>
+		<wp:attachment_url>http://old.example.com/wp-content/uploads/2015/01/photo.jpg</wp:attachment_url>
+	</item>
+	<item>
+		<title>Menu</title>
+		<wp:post_id>15</wp:post_id>
+		<wp:post_type>nav_menu_item</wp:post_type>
+	</item>
+</channel>
+</rss>`
+
+	bySlug := func(slug string) Post {
+		var p Post
+		db.Where(&Post{Slug: slug}).First(&p)
+		return p
+	}
+
+	Convey("the WordPress importer", t, func() {
+
+		Convey("should refuse files which are not WordPress exports", func() {
+			_, err := ImportWordPress(strings.NewReader("not xml"), user.ID)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldStartWith, "not a WordPress export file")
+		})
+
+		Convey("should import authors, posts, pages and comments", func() {
+			summary, err := ImportWordPress(strings.NewReader(export), user.ID)
+			So(err, ShouldBeNil)
+			// The author, two posts, two pages and two comments.
+			So(summary.Imported, ShouldEqual, 7)
+			// The spam comment, the attachment and the menu item.
+			So(summary.Skipped, ShouldEqual, 3)
+
+			writer, err := User{Email: "wordpress-writer@example.com"}.GetByEmail()
+			So(err, ShouldBeNil)
+			So(writer.Name, ShouldEqual, "Writer")
+			So(writer.Role, ShouldEqual, RoleAuthor)
+
+			imported := bySlug("imported-post")
+			So(imported.Author, ShouldEqual, writer.ID)
+			So(imported.Published, ShouldBeTrue)
+			So(imported.Format, ShouldEqual, FormatHTML)
+			So(imported.Date, ShouldEqual, time.Date(2015, 1, 2, 1, 4, 5, 0, time.UTC).Unix())
+			So(imported.Tags, ShouldEqual, "News, Go")
+			So(imported.Content, ShouldContainSubstring, `src="/uploads/2015/01/photo.jpg"`)
+			So(imported.Content, ShouldContainSubstring, "<p>Second paragraph</p>")
+
+			var comments []Comment
+			db.Where(&Comment{Post: imported.ID}).Order("id").Find(&comments)
+			So(comments, ShouldHaveLength, 2)
+			So(comments[0].Approved, ShouldBeTrue)
+			So(comments[1].Parent, ShouldEqual, comments[0].ID)
+
+			draft := bySlug("imported-draft")
+			So(draft.Published, ShouldBeFalse)
+			So(draft.Author, ShouldEqual, user.ID)
+			So(draft.Date, ShouldEqual, time.Date(2015, 2, 1, 0, 0, 0, 0, time.UTC).Unix())
+
+			parent, child := bySlug("imported-parent-page"), bySlug("imported-child-page")
+			So(parent.Kind, ShouldEqual, KindPage)
+			So(child.Kind, ShouldEqual, KindPage)
+			So(child.Parent, ShouldEqual, parent.ID)
+		})
+
+		Convey("should skip posts and authors which already exist", func() {
+			summary, err := ImportWordPress(strings.NewReader(export), user.ID)
+			So(err, ShouldBeNil)
+			So(summary.Imported, ShouldEqual, 0)
+			for _, item := range summary.Items {
+				So(item.Status, ShouldEqual, ImportSkipped)
+			}
+		})
+
+		Convey("should serve the rewritten links to nested uploads", func() {
+			path := filepath.Join(Config.Uploads, "2015", "01", "photo.jpg")
+			os.MkdirAll(filepath.Dir(path), 0755)
+			ioutil.WriteFile(path, []byte("JPEG"), 0644)
+			defer os.RemoveAll(filepath.Join(Config.Uploads, "2015"))
+
+			var recorder = httptest.NewRecorder()
+			request, _ := http.NewRequest("GET", "/uploads/2015/01/photo.jpg", nil)
+			server.ServeHTTP(recorder, request)
+			So(recorder.Code, ShouldEqual, 200)
+			So(recorder.Body.String(), ShouldEqual, "JPEG")
+		})
+
+		Convey("should be run by the import command", func() {
+			So(Command([]string{"import", "wordpress", "-author", "nobody@example.com", "export.xml"}, ioutil.Discard, ioutil.Discard), ShouldEqual, ExitError)
+			So(Command([]string{"import", "wordpress"}, ioutil.Discard, ioutil.Discard), ShouldEqual, ExitUsage)
+		})
+	})
+}
+
+func TestMarkdownFiles(t *testing.T) {
+
+	files := map[string]string{
+		"2015-01-02-jekyll-post.md": "---\ntitle: Jekyll post\ndate: \"2015-01-02\"\ntags: markdown, jekyll\npublished: false\n---\nWritten in *Jekyll*.\n",
+		"hugo.md":                   "---\ntitle: Hugo post\nslug: markdown-hugo-post\ndate: \"2015-01-03T10:00:00Z\"\ntags: [markdown, hugo]\ndraft: false\nauthor: vertigo-test@mailinator.com\n---\nWritten in **Hugo**.\n",
+		"child.md":                  "---\ntitle: Markdown child\nslug: markdown-child\nkind: page\nparent: markdown-parent\nmenuorder: 2\n---\nChild page\n",
+		"parent.md":                 "---\ntitle: Markdown parent\nslug: markdown-parent\nkind: page\n---\nParent page\n",
+		"html.md":                   "---\ntitle: Markdown HTML\nslug: markdown-html\nformat: html\n---\n<p>Kept <script>alert(1)</script>as HTML</p>\n",
+		"broken.md":                 "title: No front matter\n",
+		"undated.md":                "---\ntitle: Bad date\ndate: yesterday\n---\nBody\n",
+		"reserved.md":               "---\ntitle: Reserved\nslug: api\n---\nBody\n",
+		"escape.md":                 "---\ntitle: Escape\nslug: ../Markdown Escape\n---\nBody\n",
+		"notes.txt":                 "Not a post",
+	}
+	bySlug := func(slug string) Post {
+		var p Post
+		db.Where(&Post{Slug: slug}).First(&p)
+		return p
+	}
+
+	Convey("the Markdown importer and exporter", t, func() {
+
+		dir, _ := ioutil.TempDir("", "vertigo-markdown")
+		defer os.RemoveAll(dir)
+		for name, content := range files {
+			ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		}
+
+		Convey("should import every Markdown file with valid front matter", func() {
+			summary, err := ImportMarkdown(dir, false, user.ID)
+			So(err, ShouldBeNil)
+			So(summary.Imported, ShouldEqual, 6)
+			So(summary.Skipped, ShouldEqual, 3)
+			So(bySlug("markdown-escape").Title, ShouldEqual, "Escape")
+			So(bySlug("api").ID, ShouldEqual, 0)
+
+			jekyll := bySlug("jekyll-post")
+			So(jekyll.Title, ShouldEqual, "Jekyll post")
+			So(jekyll.Published, ShouldBeFalse)
+			So(jekyll.Date, ShouldEqual, time.Date(2015, 1, 2, 0, 0, 0, 0, time.UTC).Unix())
+			So(jekyll.Tags, ShouldEqual, "markdown, jekyll")
+			So(jekyll.Content, ShouldContainSubstring, "<em>Jekyll</em>")
+
+			hugo := bySlug("markdown-hugo-post")
+			So(hugo.Published, ShouldBeTrue)
+			So(hugo.Author, ShouldEqual, user.ID)
+			So(hugo.Tags, ShouldEqual, "markdown, hugo")
+			So(hugo.Format, ShouldEqual, FormatMarkdown)
+
+			html := bySlug("markdown-html")
+			So(html.Format, ShouldEqual, FormatHTML)
+			So(html.Markdown, ShouldBeEmpty)
+			So(html.Content, ShouldNotContainSubstring, "<script>")
+
+			parent, child := bySlug("markdown-parent"), bySlug("markdown-child")
+			So(child.Kind, ShouldEqual, KindPage)
+			So(child.MenuOrder, ShouldEqual, 2)
+			So(child.Parent, ShouldEqual, parent.ID)
+		})
+
+		Convey("should only update existing posts when syncing", func() {
+			ioutil.WriteFile(filepath.Join(dir, "hugo.md"), []byte("---\ntitle: Hugo post updated\nslug: markdown-hugo-post\ndraft: true\n---\nUpdated\n"), 0644)
+
+			summary, err := ImportMarkdown(dir, false, user.ID)
+			So(err, ShouldBeNil)
+			So(summary.Imported, ShouldEqual, 0)
+			So(bySlug("markdown-hugo-post").Title, ShouldEqual, "Hugo post")
+
+			writer, err := User{Email: "wordpress-writer@example.com"}.GetByEmail()
+			So(err, ShouldBeNil)
+			summary, err = ImportMarkdown(dir, true, writer.ID)
+			So(err, ShouldBeNil)
+			So(summary.Updated, ShouldEqual, 6)
+			hugo := bySlug("markdown-hugo-post")
+			So(hugo.Title, ShouldEqual, "Hugo post updated")
+			So(hugo.Published, ShouldBeFalse)
+			// Files without an author keep the author of the post.
+			So(hugo.Author, ShouldEqual, user.ID)
+			So(bySlug("jekyll-post").Author, ShouldEqual, user.ID)
+		})
+
+		Convey("should export posts which import back unchanged", func() {
+			out := filepath.Join(dir, "export")
+			var stdout bytes.Buffer
+			So(Command([]string{"export", "markdown", out}, &stdout, ioutil.Discard), ShouldEqual, ExitOK)
+			So(stdout.String(), ShouldStartWith, "exported ")
+
+			data, err := ioutil.ReadFile(filepath.Join(out, "jekyll-post.md"))
+			So(err, ShouldBeNil)
+			fm, body, err := parseMarkdownFile(data)
+			So(err, ShouldBeNil)
+			So(fm.Title, ShouldEqual, "Jekyll post")
+			So(fm.published(), ShouldBeFalse)
+			So([]string(fm.Tags), ShouldResemble, []string{"markdown", "jekyll"})
+			So(fm.Date, ShouldEqual, "2015-01-02T00:00:00Z")
+			So(body, ShouldEqual, "Written in *Jekyll*.\n")
+
+			data, err = ioutil.ReadFile(filepath.Join(out, "markdown-child.md"))
+			So(err, ShouldBeNil)
+			fm, _, err = parseMarkdownFile(data)
+			So(err, ShouldBeNil)
+			So(fm.Kind, ShouldEqual, KindPage)
+			So(fm.Parent, ShouldEqual, "markdown-parent")
+
+			data, err = ioutil.ReadFile(filepath.Join(out, "markdown-html.md"))
+			So(err, ShouldBeNil)
+			fm, body, err = parseMarkdownFile(data)
+			So(err, ShouldBeNil)
+			So(fm.Format, ShouldEqual, FormatHTML)
+			So(body, ShouldContainSubstring, "as HTML</p>")
+		})
+
+		Convey("should refuse to export outside the directory", func() {
+			escaping := Post{Title: "Escaping", Slug: "../escaping", Kind: KindPost, Format: FormatMarkdown, Author: user.ID}
+			So(db.Create(&escaping).Error, ShouldBeNil)
+			defer db.Delete(&escaping)
+
+			_, err := ExportMarkdown(filepath.Join(dir, "export"))
+			So(err, ShouldNotBeNil)
+			_, err = os.Stat(filepath.Join(dir, "escaping.md"))
+			So(os.IsNotExist(err), ShouldBeTrue)
+		})
+	})
+}
+
+func TestSlugs(t *testing.T) {
+
+	admin := []requestOption{asJSON, withSession(sessioncookie)}
+	create := func(body string) Post {
+		var created Post
+		json.Unmarshal(serve("POST", "/api/v1/posts", body, admin...).Body.Bytes(), &created)
+		return created
+	}
+	edit := func(slug, body string) Post {
+		var edited Post
+		json.Unmarshal(serve("PATCH", "/api/v1/posts/"+slug, body, admin...).Body.Bytes(), &edited)
+		return edited
+	}
+
+	Convey("the post slugs", t, func() {
+
+		Convey("should be unique and avoid the slugs of routes", func() {
+			So(create(`{"title": "Slug test", "markdown": "First"}`).Slug, ShouldEqual, "slug-test")
+			So(create(`{"title": "Slug test", "markdown": "Second"}`).Slug, ShouldEqual, "slug-test-2")
+			So(create(`{"title": "Search", "markdown": "Reserved"}`).Slug, ShouldEqual, "search-2")
+			So(create(`{"title": "Custom", "slug": "Custom Slug Test", "markdown": "Custom"}`).Slug, ShouldEqual, "custom-slug-test")
+		})
+
+		Convey("should follow the title of unpublished posts only", func() {
+			So(edit("slug-test-2", `{"title": "Slug test renamed"}`).Slug, ShouldEqual, "slug-test-renamed")
+			So(serve("PUT", "/api/v1/posts/slug-test-renamed/published", "", admin...).Code, ShouldEqual, 200)
+			So(edit("slug-test-renamed", `{"title": "Slug test published"}`).Slug, ShouldEqual, "slug-test-renamed")
+		})
+
+		Convey("should redirect old slugs to the renamed post", func() {
+			So(edit("slug-test-renamed", `{"slug": "slug-test-final"}`).Slug, ShouldEqual, "slug-test-final")
+
+			recorder := serve("GET", "/post/slug-test-renamed", "", admin...)
+			So(recorder.Code, ShouldEqual, 301)
+			So(recorder.HeaderMap.Get("Location"), ShouldEqual, "/post/slug-test-final")
+			recorder = serve("GET", "/api/v1/posts/slug-test-2", "", admin...)
+			So(recorder.Code, ShouldEqual, 301)
+			So(recorder.HeaderMap.Get("Location"), ShouldEqual, "/api/v1/posts/slug-test-final")
+			recorder = serve("GET", "/post/slug-test-2?preview=token", "", admin...)
+			So(recorder.HeaderMap.Get("Location"), ShouldEqual, "/post/slug-test-final?preview=token")
+		})
+
+		Convey("should give slugs of redirects to new posts", func() {
+			So(edit("custom-slug-test", `{"slug": "slug-test-2"}`).Slug, ShouldEqual, "slug-test-2")
+			So(serve("GET", "/api/v1/posts/slug-test-2", "", admin...).Code, ShouldEqual, 200)
+			So(serve("GET", "/post/custom-slug-test", "", admin...).Code, ShouldEqual, 301)
+		})
+
+		Convey("should add a suffix to taken custom slugs", func() {
+			So(edit("slug-test-final", `{"slug": "slug-test"}`).Slug, ShouldEqual, "slug-test-3")
+		})
+
+		Convey("should drop the redirects of deleted posts", func() {
+			So(serve("DELETE", "/api/v1/posts/slug-test-3", "", admin...).Code, ShouldEqual, 200)
+			So(serve("GET", "/post/slug-test-renamed", "", admin...).Code, ShouldEqual, 404)
+			So(serve("GET", "/post/slug-test-final", "", admin...).Code, ShouldEqual, 404)
+		})
+	})
+}
+
 func TestDropDatabase(t *testing.T) {
 	os.Remove("settings.json")
 	os.Remove("vertigo.db")
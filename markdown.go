@@ -0,0 +1,377 @@
+// Markdown.go converts the HTML of posts into Markdown, so that posts written in the HTML editor
+// can be edited in the Markdown editor. See https://github.com/9uuso/vertigo/issues/7.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/russross/blackfriday"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// HTMLToMarkdown converts HTML into Markdown which renders back into equivalent HTML.
+// Elements without a Markdown counterpart, such as tables, iframes and elements with attributes
+// Markdown cannot express, are kept as HTML, which Markdown passes through unchanged.
+func HTMLToMarkdown(s string) (string, error) {
+	context := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(s), context)
+	if err != nil {
+		return "", err
+	}
+	markdown := mdBlocks(nodes)
+	if markdown == "" {
+		return "", nil
+	}
+	return markdown + "\n", nil
+}
+
+// mdBlocks converts nodes into Markdown blocks separated by blank lines.
+// Consecutive inline nodes, such as text directly inside <div>, are joined into a paragraph.
+func mdBlocks(nodes []*html.Node) string {
+	var blocks []string
+	var inline []*html.Node
+	flush := func() {
+		if paragraph := mdParagraph(mdInline(inline)); paragraph != "" {
+			blocks = append(blocks, paragraph)
+		}
+		inline = nil
+	}
+	for _, n := range nodes {
+		if n.Type == html.CommentNode {
+			flush()
+			blocks = append(blocks, mdRaw(n))
+			continue
+		}
+		if !mdIsBlock(n) {
+			inline = append(inline, n)
+			continue
+		}
+		flush()
+		if block := mdBlock(n); block != "" {
+			blocks = append(blocks, block)
+		}
+	}
+	flush()
+	return strings.Join(blocks, "\n\n")
+}
+
+// mdBlockElements lists the block level elements which end a paragraph.
+var mdBlockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Header: true,
+	atom.Footer: true, atom.Aside: true, atom.Nav: true, atom.Main: true, atom.Figure: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Ul: true, atom.Ol: true, atom.Li: true, atom.Dl: true, atom.Blockquote: true, atom.Pre: true,
+	atom.Hr: true, atom.Table: true, atom.Form: true, atom.Iframe: true, atom.Video: true,
+	atom.Audio: true, atom.Script: true, atom.Style: true, atom.Details: true,
+}
+
+func mdIsBlock(n *html.Node) bool {
+	return n.Type == html.ElementNode && mdBlockElements[n.DataAtom]
+}
+
+func mdChildren(n *html.Node) []*html.Node {
+	var children []*html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		children = append(children, c)
+	}
+	return children
+}
+
+func mdBlock(n *html.Node) string {
+	// Highlighted code blocks are turned back into fenced code, see highlight.go.
+	if n.DataAtom == atom.Pre && len(n.Attr) == 1 && n.Attr[0].Key == "class" && n.Attr[0].Val == "highlight" {
+		return mdPre(n)
+	}
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		if len(n.Attr) == 1 && n.Attr[0].Key == "id" {
+			return mdHeading(n, n.Attr[0].Val)
+		}
+	}
+	if len(n.Attr) > 0 {
+		return mdRaw(n)
+	}
+	switch n.DataAtom {
+	case atom.P:
+		return mdParagraph(mdInline(mdChildren(n)))
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		return mdHeading(n, "")
+	case atom.Ul, atom.Ol:
+		return mdList(n)
+	case atom.Blockquote:
+		return mdPrefix(mdBlocks(mdChildren(n)), "> ", "> ")
+	case atom.Pre:
+		return mdPre(n)
+	case atom.Hr:
+		return "* * *"
+	case atom.Div, atom.Section, atom.Article:
+		return mdBlocks(mdChildren(n))
+	}
+	return mdRaw(n)
+}
+
+// mdHeading converts a heading with the given ID. The ID is written out only if Blackfriday
+// would not generate the same one from the heading text, which keeps links to the heading working.
+func mdHeading(n *html.Node, id string) string {
+	text := mdInline(mdChildren(n))
+	heading := strings.Repeat("#", int(n.Data[1]-'0')) + " " + text
+	if id != "" && id != blackfriday.SanitizedAnchorName(text) {
+		heading += " {#" + id + "}"
+	}
+	return heading
+}
+
+// mdList converts <ul> and <ol>. Lists containing anything else than list items are kept as HTML,
+// as are lists with attributes, because Markdown always numbers lists from one.
+func mdList(n *html.Node) string {
+	var items []string
+	for _, c := range mdChildren(n) {
+		if c.Type == html.TextNode && strings.TrimSpace(c.Data) == "" {
+			continue
+		}
+		if c.Type != html.ElementNode || c.DataAtom != atom.Li || len(c.Attr) > 0 {
+			return mdRaw(n)
+		}
+		marker := "- "
+		if n.DataAtom == atom.Ol {
+			marker = strconv.Itoa(len(items)+1) + ". "
+		}
+		indent := strings.Repeat(" ", len(marker))
+		items = append(items, mdPrefix(mdBlocks(mdChildren(c)), marker, indent))
+	}
+	return strings.Join(items, "\n")
+}
+
+// mdPre converts <pre> into a fenced code block, keeping the language of
+// <pre><code class="language-go"> blocks.
+func mdPre(n *html.Node) string {
+	children := mdChildren(n)
+	language := ""
+	if len(children) == 1 && children[0].DataAtom == atom.Code {
+		for _, attr := range children[0].Attr {
+			if attr.Key != "class" || !strings.HasPrefix(attr.Val, "language-") {
+				return mdRaw(n)
+			}
+			language = strings.TrimPrefix(attr.Val, "language-")
+		}
+	}
+	code := strings.TrimSuffix(mdText(n), "\n")
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	return fence + language + "\n" + code + "\n" + fence
+}
+
+// mdInline converts inline nodes into a single line of Markdown, with hard line breaks for <br>.
+func mdInline(nodes []*html.Node) string {
+	var buf bytes.Buffer
+	for _, n := range nodes {
+		switch n.Type {
+		case html.TextNode:
+			buf.WriteString(mdEscape(n.Data))
+		case html.ElementNode:
+			buf.WriteString(mdInlineElement(n))
+		case html.CommentNode:
+			buf.WriteString(mdRaw(n))
+		}
+	}
+	return strings.TrimSpace(buf.String())
+}
+
+func mdInlineElement(n *html.Node) string {
+	attrs := make(map[string]string)
+	for _, attr := range n.Attr {
+		attrs[attr.Key] = attr.Val
+	}
+	switch n.DataAtom {
+	case atom.Br:
+		return "  \n"
+	case atom.Strong, atom.B:
+		if len(attrs) == 0 {
+			return mdWrap(mdInline(mdChildren(n)), "**")
+		}
+	case atom.Em, atom.I:
+		if len(attrs) == 0 {
+			return mdWrap(mdInline(mdChildren(n)), "*")
+		}
+	case atom.Del, atom.S:
+		if len(attrs) == 0 {
+			return mdWrap(mdInline(mdChildren(n)), "~~")
+		}
+	case atom.Code:
+		if len(attrs) == 0 {
+			return mdCode(mdText(n))
+		}
+	case atom.A:
+		href, hasHref := attrs["href"]
+		title, hasTitle := attrs["title"]
+		if hasHref && len(attrs) == 1 || hasHref && hasTitle && len(attrs) == 2 {
+			return "[" + mdInline(mdChildren(n)) + "](" + mdURL(href) + mdTitle(title) + ")"
+		}
+	case atom.Img:
+		src, hasSrc := attrs["src"]
+		delete(attrs, "src")
+		delete(attrs, "alt")
+		delete(attrs, "title")
+		if hasSrc && len(attrs) == 0 {
+			return "![" + mdEscape(mdAttr(n, "alt")) + "](" + mdURL(src) + mdTitle(mdAttr(n, "title")) + ")"
+		}
+	}
+	return mdRaw(n)
+}
+
+func mdAttr(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// mdWrap wraps s in emphasis markers, which must not be separated from the text by whitespace.
+func mdWrap(s, marker string) string {
+	if s == "" {
+		return ""
+	}
+	return marker + s + marker
+}
+
+// mdCode returns s as a code span, using more backticks than s contains in a row.
+func mdCode(s string) string {
+	fence := "`"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		return fence + " " + s + " " + fence
+	}
+	return fence + s + fence
+}
+
+func mdURL(url string) string {
+	if strings.ContainsAny(url, " ()") {
+		return "<" + url + ">"
+	}
+	return url
+}
+
+func mdTitle(title string) string {
+	if title == "" {
+		return ""
+	}
+	return ` "` + strings.Replace(title, `"`, "&quot;", -1) + `"`
+}
+
+// mdText returns text content of n without any markup.
+func mdText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var buf bytes.Buffer
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		buf.WriteString(mdText(c))
+	}
+	return buf.String()
+}
+
+// mdRaw renders n back into HTML.
+func mdRaw(n *html.Node) string {
+	var buf bytes.Buffer
+	if err := html.Render(&buf, n); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+// mdEscaper escapes characters which Markdown would otherwise interpret. HTML special characters
+// are turned back into entities, as the parser has already decoded them.
+var mdEscaper = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`,
+	"&", "&amp;", "<", "&lt;", ">", "&gt;",
+)
+
+// mdEscape escapes text and collapses whitespace the way browsers display it.
+func mdEscape(s string) string {
+	s = mdEscaper.Replace(s)
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		if s == "" {
+			return ""
+		}
+		return " "
+	}
+	out := strings.Join(fields, " ")
+	if strings.TrimLeft(s, " \t\r\n") != s {
+		out = " " + out
+	}
+	if strings.TrimRight(s, " \t\r\n") != s {
+		out += " "
+	}
+	return out
+}
+
+// mdParagraph escapes text at the start of lines which Markdown would read as a heading or list item.
+func mdParagraph(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		line = strings.TrimLeft(line, " ")
+		switch {
+		case strings.HasPrefix(line, "#"), strings.HasPrefix(line, "- "), strings.HasPrefix(line, "+ "),
+			strings.HasPrefix(line, "="):
+			line = `\` + line
+		default:
+			digits := strings.IndexFunc(line, func(r rune) bool { return r < '0' || r > '9' })
+			if digits > 0 && strings.HasPrefix(line[digits:], ". ") {
+				line = line[:digits] + `\` + line[digits:]
+			}
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// mdPrefix prefixes the first line of s with first and the rest with rest.
+// Blank lines are left without trailing whitespace.
+func mdPrefix(s, first, rest string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		prefix := rest
+		if i == 0 {
+			prefix = first
+		}
+		if line == "" {
+			prefix = strings.TrimRight(prefix, " ")
+		}
+		lines[i] = prefix + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// postFormat returns post in the representation asked for with the format query parameter of API
+// routes: "markdown" returns only post.Markdown, "html" only post.Content and no format both.
+// Posts which are not written in Markdown are converted.
+func postFormat(post Post, format string) (Post, error) {
+	switch format {
+	case "":
+	case "html":
+		post.Markdown = ""
+	case "markdown":
+		if post.Format != FormatMarkdown {
+			markdown, err := HTMLToMarkdown(post.Content)
+			if err != nil {
+				return post, err
+			}
+			post.Markdown = markdown
+		}
+		post.Content = ""
+	default:
+		return post, errors.New("unsupported format")
+	}
+	return post, nil
+}
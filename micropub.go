@@ -0,0 +1,689 @@
+// Micropub.go implements the W3C Micropub protocol at /micropub, so that IndieWeb clients and phone apps
+// can publish to the blog. Clients authenticate with an access token of tokens.go and create, update, delete
+// and undelete h-entry posts, sent form-encoded, as multipart with photos or as JSON. Posts are referred to
+// by their URL. Files are uploaded to the media endpoint at /micropub/media, which saves them in the uploads
+// directory. See https://www.w3.org/TR/micropub/.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"html"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// maxUploadSize is the largest request the Micropub endpoints accept, files included.
+const maxUploadSize = 20 << 20
+
+// uploadTypes maps the media types of files which may be uploaded to the extension they are saved with.
+// Files are served from the domain of the blog, so types which browsers may run, such as HTML and SVG, are left out.
+var uploadTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"audio/mpeg":      ".mp3",
+	"audio/wave":      ".wav",
+	"application/ogg": ".ogg",
+	"video/mp4":       ".mp4",
+	"video/webm":      ".webm",
+}
+
+// MicropubError is the body of error responses of the Micropub endpoints. Scope is the scope
+// the access token lacks, if that is the error.
+type MicropubError struct {
+	Error       string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+	Scope       string `json:"scope,omitempty"`
+}
+
+// micropubRequest is a request to the Micropub endpoint. Requests without an action create an entry of
+// Type with Properties. Form-encoded requests are read into the same struct as JSON ones.
+type micropubRequest struct {
+	Type       []string                 `json:"type"`
+	Properties map[string][]interface{} `json:"properties"`
+	Action     string                   `json:"action"`
+	URL        string                   `json:"url"`
+	Replace    map[string][]interface{} `json:"replace"`
+	Add        map[string][]interface{} `json:"add"`
+	Delete     interface{}              `json:"delete"`
+	// Files are the files uploaded with a multipart request by the name of their property, such as photo.
+	Files map[string][]*multipart.FileHeader `json:"-"`
+}
+
+// DeletedPost is a post deleted through Micropub, kept so that it can be undeleted. Data is the post as JSON.
+// Comments and preview links of the post are deleted with it and are not restored.
+type DeletedPost struct {
+	ID        int64  `json:"id" gorm:"primary_key:yes"`
+	Post      int64  `json:"post"`
+	Author    int64  `json:"author"`
+	Slug      string `json:"slug"`
+	Published bool   `json:"published"`
+	Data      string `json:"-" sql:"type:text"`
+	Date      int64  `json:"date"`
+}
+
+// micropubError writes an error response of the Micropub endpoints.
+func micropubError(w http.ResponseWriter, status int, code, description string) {
+	rend.JSON(w, status, MicropubError{Error: code, Description: description})
+}
+
+// micropubAuthError writes the response for an error of AuthenticateToken.
+func micropubAuthError(w http.ResponseWriter, err error) {
+	switch err.Error() {
+	case "unauthorized":
+		micropubError(w, http.StatusUnauthorized, "unauthorized", "An access token is required, given in header Authorization as a bearer token.")
+	case "forbidden":
+		micropubError(w, http.StatusForbidden, "forbidden", "The access token is invalid or has been revoked.")
+	default:
+		log.Println("micropub: ", err)
+		micropubError(w, http.StatusInternalServerError, "server_error", "")
+	}
+}
+
+// micropubPostError writes the response for an error of the post methods, see postError.
+func micropubPostError(w http.ResponseWriter, err error) {
+	switch err.Error() {
+	case "unauthorized":
+		micropubError(w, http.StatusForbidden, "forbidden", "Only the author of the post may change it.")
+	case "not found":
+		micropubError(w, http.StatusBadRequest, "invalid_request", "No post has this URL.")
+	default:
+		status, apierr := postError(err)
+		if status == http.StatusInternalServerError {
+			log.Println("micropub: ", err)
+			micropubError(w, status, "server_error", "")
+			return
+		}
+		micropubError(w, http.StatusBadRequest, "invalid_request", apierr.Message)
+	}
+}
+
+// insufficientScope writes the response for an access token which lacks scope.
+func insufficientScope(w http.ResponseWriter, scope string) {
+	rend.JSON(w, http.StatusForbidden, MicropubError{Error: "insufficient_scope", Description: "The access token does not have scope " + scope + ".", Scope: scope})
+}
+
+// parseMicropub reads the form-encoded, multipart or JSON body of r. In form-encoded requests, h names the type
+// of the entry and properties with many values are suffixed with [], such as category[].
+func parseMicropub(r *http.Request) (micropubRequest, error) {
+	var req micropubRequest
+	mediatype, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediatype {
+	case "application/json":
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return req, errors.New("invalid body")
+		}
+		return req, nil
+	case "application/x-www-form-urlencoded", "multipart/form-data":
+		var err error
+		if mediatype == "multipart/form-data" {
+			err = r.ParseMultipartForm(maxUploadSize)
+		} else {
+			err = r.ParseForm()
+		}
+		if err != nil {
+			return req, errors.New("invalid body")
+		}
+	default:
+		return req, errors.New("unsupported media type")
+	}
+
+	req.Action = r.PostForm.Get("action")
+	req.URL = r.PostForm.Get("url")
+	if h := r.PostForm.Get("h"); h != "" {
+		req.Type = []string{"h-" + h}
+	}
+	req.Properties = make(map[string][]interface{})
+	for key, values := range r.PostForm {
+		switch key {
+		case "access_token", "action", "url", "h":
+			continue
+		}
+		name := strings.TrimSuffix(key, "[]")
+		for _, value := range values {
+			req.Properties[name] = append(req.Properties[name], value)
+		}
+	}
+	if r.MultipartForm != nil {
+		req.Files = make(map[string][]*multipart.FileHeader)
+		for key, files := range r.MultipartForm.File {
+			name := strings.TrimSuffix(key, "[]")
+			req.Files[name] = append(req.Files[name], files...)
+		}
+	}
+	return req, nil
+}
+
+// micropubText returns value as text, which is either a string or an object with the text in value,
+// or HTML in html. isHTML reports whether the text is HTML.
+func micropubText(value interface{}) (text string, isHTML bool) {
+	switch v := value.(type) {
+	case string:
+		return v, false
+	case map[string]interface{}:
+		if s, ok := v["html"].(string); ok {
+			return s, true
+		}
+		if s, ok := v["value"].(string); ok {
+			return s, false
+		}
+	}
+	return "", false
+}
+
+// micropubStrings returns the values of a property as text.
+func micropubStrings(values []interface{}) []string {
+	strs := make([]string, 0, len(values))
+	for _, value := range values {
+		if s, _ := micropubText(value); s != "" {
+			strs = append(strs, s)
+		}
+	}
+	return strs
+}
+
+// micropubFirst returns the first value of a property as text, or an empty string if it has none.
+func micropubFirst(properties map[string][]interface{}, name string) string {
+	if values := micropubStrings(properties[name]); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// micropubEntry returns the post described by the properties of an h-entry and whether it is published.
+// Content given as HTML is written as an HTML post and other content as Markdown. Photos are added below
+// the content, and video and audio are linked there. Entries without a name, such as notes, are titled
+// with the start of their content.
+func micropubEntry(properties map[string][]interface{}) (post Post, published bool) {
+	post.Format = FormatMarkdown
+	if values := properties["content"]; len(values) > 0 {
+		text, isHTML := micropubText(values[0])
+		if isHTML {
+			post.Format = FormatHTML
+			post.Content = text
+		} else {
+			post.Markdown = text
+		}
+	}
+	post.Title = micropubFirst(properties, "name")
+	if post.Title == "" {
+		text := post.Content
+		if post.Format == FormatMarkdown {
+			text = renderMarkdown(post.Markdown)
+		}
+		post.Title = strings.Join(strings.Fields(Excerpt(text)), " ")
+	}
+	if title := []rune(post.Title); len(title) > maxTitleLength {
+		post.Title = string(title[:maxTitleLength])
+	}
+	if post.Title == "" {
+		post.Title = "Untitled"
+	}
+
+	for _, photo := range properties["photo"] {
+		src, alt := "", ""
+		switch v := photo.(type) {
+		case string:
+			src = v
+		case map[string]interface{}:
+			src, _ = v["value"].(string)
+			alt, _ = v["alt"].(string)
+		}
+		if src == "" {
+			continue
+		}
+		if post.Format == FormatHTML {
+			post.Content += `<p><img src="` + html.EscapeString(src) + `" alt="` + html.EscapeString(alt) + `"></p>`
+		} else {
+			post.Markdown += "\n\n![" + alt + "](" + src + ")"
+		}
+	}
+	for _, src := range append(micropubStrings(properties["video"]), micropubStrings(properties["audio"])...) {
+		if post.Format == FormatHTML {
+			post.Content += `<p><a href="` + html.EscapeString(src) + `">` + html.EscapeString(path.Base(src)) + `</a></p>`
+		} else {
+			post.Markdown += "\n\n[" + path.Base(src) + "](" + src + ")"
+		}
+	}
+	post.Markdown = strings.TrimSpace(post.Markdown)
+	post.Tags = strings.Join(micropubStrings(properties["category"]), ", ")
+	post.Slug = micropubFirst(properties, "mp-slug")
+	return post, micropubFirst(properties, "post-status") != "draft"
+}
+
+// MicropubProperties or post.MicropubProperties returns the properties of the post as an h-entry.
+// Markdown and plain text posts return their source as content, HTML posts their HTML.
+func (post Post) MicropubProperties() map[string][]interface{} {
+	var content interface{} = post.Markdown
+	if post.Format == FormatHTML {
+		content = map[string]interface{}{"html": post.Content}
+	}
+	status := "draft"
+	if post.Published {
+		status = "published"
+	}
+	categories := make([]interface{}, 0)
+	for _, tag := range splitTags(post.Tags) {
+		categories = append(categories, tag)
+	}
+	properties := map[string][]interface{}{
+		"name":        {post.Title},
+		"content":     {content},
+		"category":    categories,
+		"mp-slug":     {post.Slug},
+		"post-status": {status},
+		"published":   {time.Unix(post.Date, 0).Format(time.RFC3339)},
+	}
+	if path, err := post.Path(); err == nil {
+		properties["url"] = []interface{}{urlHost() + path}
+	}
+	return properties
+}
+
+// micropubURL returns the URL of post.
+func micropubURL(post Post) (string, error) {
+	path, err := post.Path()
+	if err != nil {
+		return "", err
+	}
+	return urlHost() + path, nil
+}
+
+// saveUpload saves the uploaded file in the uploads directory under a random name.
+// Returns the URL of the file, or "unsupported media type" if it may not be uploaded.
+func saveUpload(header *multipart.FileHeader) (string, error) {
+	src, err := header.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	sniff := make([]byte, 512)
+	n, err := io.ReadFull(src, sniff)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	mediatype, _, _ := mime.ParseMediaType(http.DetectContentType(sniff[:n]))
+	ext, ok := uploadTypes[mediatype]
+	if !ok {
+		return "", errors.New("unsupported media type")
+	}
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	name, err := randomSecret()
+	if err != nil {
+		return "", err
+	}
+	name = name[:24] + ext
+	if err := os.MkdirAll(Config.Uploads, 0755); err != nil {
+		return "", err
+	}
+	dst, err := os.Create(filepath.Join(Config.Uploads, name))
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return "", err
+	}
+	if err := dst.Close(); err != nil {
+		return "", err
+	}
+	return urlHost() + "/uploads/" + name, nil
+}
+
+// uploadError writes the response for an error of saveUpload.
+func uploadError(w http.ResponseWriter, err error) {
+	if err.Error() == "unsupported media type" {
+		micropubError(w, http.StatusBadRequest, "invalid_request", "Only images, audio and video may be uploaded.")
+		return
+	}
+	log.Println("micropub upload: ", err)
+	micropubError(w, http.StatusInternalServerError, "server_error", "")
+}
+
+// ServeMicropub is the Micropub endpoint. Requires an access token with the scope of the action:
+// create, update, or delete for deleting and undeleting.
+//
+// GET answers queries q=config with the media endpoint, q=source with the properties of the post of url,
+// only the ones listed in properties[] if given, and q=syndicate-to with no syndication targets.
+//
+// POST without an action creates a post of an h-entry and answers 201 Created with its URL in Location.
+// The post is published unless post-status is draft. Photos may be uploaded in a multipart request.
+// Action update replaces, adds or deletes properties of the post of url, answering 204 No Content,
+// or 201 Created with the new URL in Location if the slug changed. Actions delete and undelete answer 204 No Content.
+func ServeMicropub(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	token, err := AuthenticateToken(r)
+	if err != nil {
+		micropubAuthError(w, err)
+		return
+	}
+	if r.Method == "GET" {
+		micropubQuery(w, r)
+		return
+	}
+
+	req, err := parseMicropub(r)
+	if err != nil {
+		if err.Error() == "unsupported media type" {
+			micropubError(w, http.StatusUnsupportedMediaType, "invalid_request", "Requests must be form-encoded, multipart or JSON.")
+			return
+		}
+		micropubError(w, http.StatusBadRequest, "invalid_request", "The body of the request could not be read.")
+		return
+	}
+	scope := map[string]string{"": ScopeCreate, "update": ScopeUpdate, "delete": ScopeDelete, "undelete": ScopeDelete}[req.Action]
+	if scope == "" {
+		micropubError(w, http.StatusBadRequest, "invalid_request", "Action must be update, delete or undelete.")
+		return
+	}
+	if !token.Allows(scope) {
+		insufficientScope(w, scope)
+		return
+	}
+	switch req.Action {
+	case "":
+		micropubCreate(w, r, req)
+	case "update":
+		micropubUpdate(w, r, req)
+	case "delete":
+		micropubDelete(w, r, req)
+	case "undelete":
+		micropubUndelete(w, r, req)
+	}
+}
+
+// micropubQuery answers the queries of ServeMicropub.
+func micropubQuery(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	switch query.Get("q") {
+	case "config":
+		rend.JSON(w, http.StatusOK, map[string]interface{}{
+			"media-endpoint": urlHost() + "/micropub/media",
+			"syndicate-to":   []string{},
+			"q":              []string{"config", "source", "syndicate-to"},
+		})
+	case "syndicate-to":
+		rend.JSON(w, http.StatusOK, map[string]interface{}{"syndicate-to": []string{}})
+	case "source":
+		post, err := postAtURL(r, query.Get("url"))
+		if err != nil {
+			micropubPostError(w, err)
+			return
+		}
+		if !post.Visible(r) {
+			micropubPostError(w, errors.New("not found"))
+			return
+		}
+		properties := post.MicropubProperties()
+		names := append(query["properties[]"], query["properties"]...)
+		if len(names) == 0 {
+			rend.JSON(w, http.StatusOK, map[string]interface{}{"type": []string{"h-entry"}, "properties": properties})
+			return
+		}
+		selected := make(map[string][]interface{})
+		for _, name := range names {
+			if values, ok := properties[name]; ok {
+				selected[name] = values
+			}
+		}
+		rend.JSON(w, http.StatusOK, map[string]interface{}{"properties": selected})
+	default:
+		micropubError(w, http.StatusBadRequest, "invalid_request", "Query q must be config, source or syndicate-to.")
+	}
+}
+
+// micropubCreate creates a post of the h-entry of req, saving the photos uploaded with it.
+func micropubCreate(w http.ResponseWriter, r *http.Request, req micropubRequest) {
+	if len(req.Type) == 0 || req.Type[0] != "h-entry" {
+		micropubError(w, http.StatusBadRequest, "invalid_request", "Only h-entry can be created.")
+		return
+	}
+	for _, name := range []string{"photo", "video", "audio"} {
+		for _, file := range req.Files[name] {
+			location, err := saveUpload(file)
+			if err != nil {
+				uploadError(w, err)
+				return
+			}
+			req.Properties[name] = append(req.Properties[name], location)
+		}
+	}
+	entry, published := micropubEntry(req.Properties)
+	post, err := entry.Insert(r)
+	if err != nil {
+		micropubPostError(w, err)
+		return
+	}
+	if published {
+		if post, err = post.Publish(r); err != nil {
+			micropubPostError(w, err)
+			return
+		}
+	}
+	location, err := micropubURL(post)
+	if err != nil {
+		micropubPostError(w, err)
+		return
+	}
+	w.Header().Set("Location", location)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// micropubUpdate applies the replace, add and delete operations of req to the properties of the post of req.URL.
+func micropubUpdate(w http.ResponseWriter, r *http.Request, req micropubRequest) {
+	post, err := postAtURL(r, req.URL)
+	if err != nil {
+		micropubPostError(w, err)
+		return
+	}
+	properties := post.MicropubProperties()
+	for name, values := range req.Replace {
+		properties[name] = values
+	}
+	for name, values := range req.Add {
+		properties[name] = append(properties[name], values...)
+	}
+	switch deletions := req.Delete.(type) {
+	case nil:
+	case []interface{}:
+		for _, name := range deletions {
+			if name, ok := name.(string); ok {
+				delete(properties, name)
+			}
+		}
+	case map[string]interface{}:
+		for name, values := range deletions {
+			values, ok := values.([]interface{})
+			if !ok {
+				continue
+			}
+			kept := make([]interface{}, 0)
+			for _, value := range properties[name] {
+				if !containsValue(values, value) {
+					kept = append(kept, value)
+				}
+			}
+			properties[name] = kept
+		}
+	default:
+		micropubError(w, http.StatusBadRequest, "invalid_request", "Delete must list properties or map them to the values to delete.")
+		return
+	}
+
+	entry, published := micropubEntry(properties)
+	// Edit renders the post in its own format, so a change of format is made before it.
+	// Plain text posts stay plain unless they are given HTML.
+	if entry.Format == FormatHTML || post.Format == FormatHTML {
+		post.Format = entry.Format
+	}
+	input := Post{Title: entry.Title, Markdown: entry.Markdown, Content: entry.Content, Tags: entry.Tags}
+	if entry.Slug != post.Slug {
+		input.Slug = entry.Slug
+	}
+	oldslug := post.Slug
+	if post, err = post.Edit(r, input); err != nil {
+		micropubPostError(w, err)
+		return
+	}
+	// Edit keeps the fields which are given empty, so deleted categories are cleared here.
+	if entry.Tags == "" && post.Tags != "" {
+		if err := db.Model(&post).UpdateColumn("tags", "").Error; err != nil {
+			micropubPostError(w, err)
+			return
+		}
+	}
+	if published != post.Published {
+		if published {
+			post, err = post.Publish(r)
+		} else {
+			err = post.Unpublish(r)
+		}
+		if err != nil {
+			micropubPostError(w, err)
+			return
+		}
+	}
+	if post.Slug != oldslug {
+		location, err := micropubURL(post)
+		if err != nil {
+			micropubPostError(w, err)
+			return
+		}
+		w.Header().Set("Location", location)
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// containsValue reports whether values contains value, comparing them as JSON values.
+func containsValue(values []interface{}, value interface{}) bool {
+	encoded, _ := json.Marshal(value)
+	for _, v := range values {
+		if other, _ := json.Marshal(v); string(other) == string(encoded) {
+			return true
+		}
+	}
+	return false
+}
+
+// micropubDelete deletes the post of req.URL, keeping a copy for micropubUndelete.
+func micropubDelete(w http.ResponseWriter, r *http.Request, req micropubRequest) {
+	post, err := postAtURL(r, req.URL)
+	if err != nil {
+		micropubPostError(w, err)
+		return
+	}
+	data, err := json.Marshal(post)
+	if err != nil {
+		micropubPostError(w, err)
+		return
+	}
+	deleted := DeletedPost{Post: post.ID, Author: post.Author, Slug: post.Slug, Published: post.Published, Data: string(data), Date: time.Now().Unix()}
+	if err := db.Create(&deleted).Error; err != nil {
+		micropubPostError(w, err)
+		return
+	}
+	if err := post.Delete(r); err != nil {
+		if err := db.Delete(&deleted).Error; err != nil {
+			log.Println("micropub delete: ", err)
+		}
+		micropubPostError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// micropubUndelete restores the post of req.URL which the current user deleted through Micropub.
+// Refuses to restore it if another post has taken its slug since.
+func micropubUndelete(w http.ResponseWriter, r *http.Request, req micropubRequest) {
+	var user User
+	user, err := user.Session(r)
+	if err != nil {
+		micropubPostError(w, errors.New("unauthorized"))
+		return
+	}
+	slug, err := slugOfURL(req.URL)
+	if err != nil {
+		micropubPostError(w, err)
+		return
+	}
+	var deleted DeletedPost
+	query := db.Order("id desc").Where("slug = ? AND author = ?", slug, user.ID).First(&deleted)
+	if query.Error != nil {
+		if query.Error == gorm.RecordNotFound {
+			micropubPostError(w, errors.New("not found"))
+			return
+		}
+		micropubPostError(w, query.Error)
+		return
+	}
+	if slug, err := uniqueSlug(deleted.Slug, 0); err != nil || slug != deleted.Slug {
+		micropubError(w, http.StatusBadRequest, "invalid_request", "Another post has taken the URL of the post since it was deleted.")
+		return
+	}
+	var post Post
+	if err := json.Unmarshal([]byte(deleted.Data), &post); err != nil {
+		micropubPostError(w, err)
+		return
+	}
+	post.Published = deleted.Published
+	if err := db.Create(&post).Error; err != nil {
+		micropubPostError(w, err)
+		return
+	}
+	if err := db.Delete(&deleted).Error; err != nil {
+		log.Println("micropub undelete: ", err)
+	}
+	if post.Published {
+		TriggerWebhooks(EventPostPublished, post)
+		Federate(ActivityCreate, post)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ServeMicropubMedia is the Micropub media endpoint. Saves the file of multipart field file in the uploads
+// directory under a random name and answers 201 Created with its URL in Location.
+// Requires an access token with scope media or create. Only images, audio and video are accepted.
+func ServeMicropubMedia(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	token, err := AuthenticateToken(r)
+	if err != nil {
+		micropubAuthError(w, err)
+		return
+	}
+	if !token.Allows(ScopeMedia) && !token.Allows(ScopeCreate) {
+		insufficientScope(w, ScopeMedia)
+		return
+	}
+	if err := r.ParseMultipartForm(maxUploadSize); err != nil {
+		micropubError(w, http.StatusBadRequest, "invalid_request", "Files must be uploaded as multipart/form-data.")
+		return
+	}
+	files := r.MultipartForm.File["file"]
+	if len(files) != 1 {
+		micropubError(w, http.StatusBadRequest, "invalid_request", "A single file must be uploaded in field file.")
+		return
+	}
+	location, err := saveUpload(files[0])
+	if err != nil {
+		uploadError(w, err)
+		return
+	}
+	w.Header().Set("Location", location)
+	w.WriteHeader(http.StatusCreated)
+}
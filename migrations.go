@@ -0,0 +1,847 @@
+// Migrations.go contains the database schema history of Vertigo. Each change to the database
+// schema is a numbered Migration with an Up and a Down function. Applied migrations are recorded
+// in the schema_migrations table, so every database knows which version it is at.
+//
+// Migrations must never use the model structs of the application directly, as those change over
+// time. Instead each migration declares a snapshot of the tables as they were at that version.
+// To change the schema, append a new Migration to the end of the migrations slice.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/kennygrant/sanitize"
+	"github.com/microcosm-cc/bluemonday"
+)
+
+// Migration is a single numbered schema change.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// SchemaMigration is a row of the schema_migrations table, one for each applied Migration.
+type SchemaMigration struct {
+	Version   int64 `gorm:"primary_key:yes"`
+	Name      string
+	AppliedAt int64
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationState tells whether a known migration has been applied to the database.
+type MigrationState struct {
+	Version   int64  `json:"version"`
+	Name      string `json:"name"`
+	Applied   bool   `json:"applied"`
+	AppliedAt int64  `json:"applied_at,omitempty"`
+}
+
+type userV1 struct {
+	ID       int64 `gorm:"primary_key:yes"`
+	Name     string
+	Email    string `sql:"unique"`
+	Avatar   string
+	Recovery string
+	Digest   []byte
+}
+
+func (userV1) TableName() string { return "users" }
+
+type postV1 struct {
+	ID        int64 `gorm:"primary_key:yes"`
+	Title     string
+	Content   string `sql:"type:text"`
+	Markdown  string `sql:"type:text"`
+	Tags      string `sql:"type:text"`
+	Date      int64
+	Slug      string
+	Author    int64
+	Excerpt   string
+	Viewcount uint
+	Published bool
+}
+
+func (postV1) TableName() string { return "posts" }
+
+type userV2 struct {
+	ID       int64 `gorm:"primary_key:yes"`
+	Name     string
+	Email    string `sql:"unique"`
+	Avatar   string
+	Role     string
+	Recovery string
+	Digest   []byte
+}
+
+func (userV2) TableName() string { return "users" }
+
+type commentV3 struct {
+	ID       int64 `gorm:"primary_key:yes"`
+	Post     int64
+	Parent   int64
+	Author   string
+	Email    string
+	URL      string
+	Content  string `sql:"type:text"`
+	Date     int64
+	Approved bool
+}
+
+func (commentV3) TableName() string { return "comments" }
+
+type redirectV4 struct {
+	ID   int64  `gorm:"primary_key:yes"`
+	Slug string `sql:"unique"`
+	Post int64
+}
+
+func (redirectV4) TableName() string { return "redirects" }
+
+type postV5 struct {
+	ID        int64  `gorm:"primary_key:yes"`
+	Title     string
+	Content   string `sql:"type:text"`
+	Markdown  string `sql:"type:text"`
+	Format    string
+	Tags      string `sql:"type:text"`
+	Date      int64
+	Slug      string
+	Author    int64
+	Excerpt   string
+	Viewcount uint
+	Published bool
+}
+
+func (postV5) TableName() string { return "posts" }
+
+// sanitizePolicyV6 is the HTML policy of posts by a user with role as it was at version 6.
+// Admins could embed iframes from the hosts in embedHosts, separated by commas or whitespace.
+func sanitizePolicyV6(role, embedHosts string) *bluemonday.Policy {
+	policy := bluemonday.UGCPolicy()
+	policy.RequireNoFollowOnLinks(false)
+	policy.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+-]+$`)).OnElements("code")
+	var hosts []string
+	for _, host := range strings.FieldsFunc(embedHosts, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n' || r == '\t' || r == '\r'
+	}) {
+		hosts = append(hosts, regexp.QuoteMeta(strings.ToLower(host)))
+	}
+	if role == "admin" && len(hosts) > 0 {
+		policy.AllowAttrs("src").Matching(regexp.MustCompile(`(?i)^(https?:)?//(` + strings.Join(hosts, "|") + `)(/|$)`)).OnElements("iframe")
+		policy.AllowAttrs("width", "height").Matching(regexp.MustCompile(`^[0-9]+%?$`)).OnElements("iframe")
+		policy.AllowAttrs("frameborder").Matching(bluemonday.Integer).OnElements("iframe")
+		policy.AllowAttrs("allowfullscreen", "title").OnElements("iframe")
+	}
+	return policy
+}
+
+type postV7 struct {
+	ID          int64  `gorm:"primary_key:yes"`
+	Title       string
+	Content     string `sql:"type:text"`
+	Markdown    string `sql:"type:text"`
+	Format      string
+	Tags        string `sql:"type:text"`
+	Date        int64
+	Slug        string
+	Author      int64
+	Excerpt     string
+	ReadingTime int
+	Viewcount   uint
+	Published   bool
+}
+
+func (postV7) TableName() string { return "posts" }
+
+// readingTimeV7 is the reading time of HTML content in minutes as it was estimated at version 7,
+// at 200 words per minute rounded up.
+func readingTimeV7(content string) int {
+	words := len(strings.Fields(sanitize.HTML(content)))
+	return (words + 199) / 200
+}
+
+type draftV8 struct {
+	ID       int64  `gorm:"primary_key:yes"`
+	Author   int64
+	Post     int64
+	Title    string
+	Slug     string
+	Markdown string `sql:"type:text"`
+	Content  string `sql:"type:text"`
+	Format   string
+	Date     int64
+}
+
+func (draftV8) TableName() string { return "drafts" }
+
+type previewV9 struct {
+	ID      int64 `gorm:"primary_key:yes"`
+	Post    int64
+	Date    int64
+	Expires int64
+}
+
+func (previewV9) TableName() string { return "previews" }
+
+type postV10 struct {
+	ID          int64  `gorm:"primary_key:yes"`
+	Title       string
+	Content     string `sql:"type:text"`
+	Markdown    string `sql:"type:text"`
+	Format      string
+	Tags        string `sql:"type:text"`
+	Date        int64
+	Slug        string
+	Kind        string
+	Parent      int64
+	MenuOrder   int
+	Author      int64
+	Excerpt     string
+	ReadingTime int
+	Viewcount   uint
+	Published   bool
+}
+
+func (postV10) TableName() string { return "posts" }
+
+type seriesV11 struct {
+	ID          int64 `gorm:"primary_key:yes"`
+	Title       string
+	Slug        string
+	Description string `sql:"type:text"`
+	Author      int64
+	Date        int64
+}
+
+func (seriesV11) TableName() string { return "series" }
+
+type postV11 struct {
+	ID          int64  `gorm:"primary_key:yes"`
+	Title       string
+	Content     string `sql:"type:text"`
+	Markdown    string `sql:"type:text"`
+	Format      string
+	Tags        string `sql:"type:text"`
+	Date        int64
+	Slug        string
+	Kind        string
+	Parent      int64
+	MenuOrder   int
+	Series      int64
+	Part        int
+	Author      int64
+	Excerpt     string
+	ReadingTime int
+	Viewcount   uint
+	Published   bool
+}
+
+func (postV11) TableName() string { return "posts" }
+
+type webhookV12 struct {
+	ID       int64 `gorm:"primary_key:yes"`
+	URL      string
+	Secret   string
+	Events   string
+	Disabled bool
+	Date     int64
+}
+
+func (webhookV12) TableName() string { return "webhooks" }
+
+type webhookDeliveryV12 struct {
+	ID       int64 `gorm:"primary_key:yes"`
+	Webhook  int64
+	Event    string
+	EventID  string
+	Attempt  int
+	Status   int
+	Error    string `sql:"type:text"`
+	Duration int64
+	Date     int64
+}
+
+func (webhookDeliveryV12) TableName() string { return "webhook_deliveries" }
+
+type accessTokenV13 struct {
+	ID     int64 `gorm:"primary_key:yes"`
+	Owner  int64
+	Name   string
+	Scope  string
+	Digest string
+	Date   int64
+	Used   int64
+}
+
+func (accessTokenV13) TableName() string { return "access_tokens" }
+
+type deletedPostV13 struct {
+	ID        int64 `gorm:"primary_key:yes"`
+	Post      int64
+	Author    int64
+	Slug      string
+	Published bool
+	Data      string `sql:"type:text"`
+	Date      int64
+}
+
+func (deletedPostV13) TableName() string { return "deleted_posts" }
+
+type webmentionV14 struct {
+	ID        int64 `gorm:"primary_key:yes"`
+	Post      int64
+	Source    string
+	Target    string
+	Type      string
+	Author    string
+	AuthorURL string
+	Content   string `sql:"type:text"`
+	Status    string
+	Approved  bool
+	Date      int64
+	Verified  int64
+}
+
+func (webmentionV14) TableName() string { return "webmentions" }
+
+type actorKeyV15 struct {
+	ID         int64 `gorm:"primary_key:yes"`
+	Author     int64
+	PrivateKey string `sql:"type:text"`
+	PublicKey  string `sql:"type:text"`
+}
+
+func (actorKeyV15) TableName() string { return "actor_keys" }
+
+type followerV15 struct {
+	ID     int64 `gorm:"primary_key:yes"`
+	Author int64
+	Actor  string
+	Name   string
+	URL    string
+	Inbox  string
+	Follow string
+	Date   int64
+}
+
+func (followerV15) TableName() string { return "followers" }
+
+type commentV15 struct {
+	ID       int64 `gorm:"primary_key:yes"`
+	Post     int64
+	Parent   int64
+	Author   string
+	Email    string
+	URL      string
+	Content  string `sql:"type:text"`
+	Date     int64
+	Approved bool
+	Remote   string
+}
+
+func (commentV15) TableName() string { return "comments" }
+
+type commentV16 struct {
+	ID       int64 `gorm:"primary_key:yes"`
+	Post     int64
+	Parent   int64
+	Author   string
+	Email    string
+	URL      string
+	Content  string `sql:"type:text"`
+	Date     int64
+	Approved bool
+	Remote   string
+	Actor    string
+}
+
+func (commentV16) TableName() string { return "comments" }
+
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create users and posts",
+		// Databases created before migrations existed already have these tables,
+		// in which case AutoMigrate only adds the columns they are missing.
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&userV1{}, &postV1{}).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.DropTable(&postV1{}).Error; err != nil {
+				return err
+			}
+			return tx.DropTable(&userV1{}).Error
+		},
+	},
+	{
+		Version: 2,
+		Name:    "add user roles",
+		// Existing users without a role become authors, and the first user is made an admin if there
+		// are none. Roles which were already set are kept.
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&userV2{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec("UPDATE users SET role = ? WHERE role IS NULL OR role = ''", RoleAuthor).Error; err != nil {
+				return err
+			}
+			var admins int
+			if err := tx.Model(&userV2{}).Where("role = ?", RoleAdmin).Count(&admins).Error; err != nil {
+				return err
+			}
+			if admins > 0 {
+				return nil
+			}
+			var first userV2
+			query := tx.Order("id").First(&first)
+			if query.Error == gorm.RecordNotFound {
+				return nil
+			}
+			if query.Error != nil {
+				return query.Error
+			}
+			return tx.Model(&first).Update("role", RoleAdmin).Error
+		},
+		// SQLite older than 3.35 cannot drop columns, so this fails there.
+		Down: func(tx *gorm.DB) error {
+			return tx.Model(&userV2{}).DropColumn("role").Error
+		},
+	},
+	{
+		Version: 3,
+		Name:    "create comments",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&commentV3{}).Error; err != nil {
+				return err
+			}
+			return tx.Model(&commentV3{}).AddIndex("idx_comments_post", "post").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.DropTable(&commentV3{}).Error
+		},
+	},
+	{
+		Version: 4,
+		Name:    "unique post slugs and redirects",
+		// Posts sharing a slug are renamed the way Post.Insert does it now,
+		// the oldest one keeps the slug and the rest get -2, -3 and so on.
+		Up: func(tx *gorm.DB) error {
+			var posts []postV1
+			if err := tx.Order("id").Find(&posts).Error; err != nil && err != gorm.RecordNotFound {
+				return err
+			}
+			taken := make(map[string]bool)
+			for _, post := range posts {
+				taken[post.Slug] = true
+			}
+			seen := make(map[string]bool)
+			for _, post := range posts {
+				if !seen[post.Slug] {
+					seen[post.Slug] = true
+					continue
+				}
+				candidate := post.Slug
+				for n := 2; taken[candidate]; n++ {
+					candidate = post.Slug + "-" + strconv.Itoa(n)
+				}
+				taken[candidate] = true
+				seen[candidate] = true
+				if err := tx.Exec("UPDATE posts SET slug = ? WHERE id = ?", candidate, post.ID).Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.Model(&postV1{}).AddUniqueIndex("idx_posts_slug", "slug").Error; err != nil {
+				return err
+			}
+			return tx.AutoMigrate(&redirectV4{}).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.DropTable(&redirectV4{}).Error; err != nil {
+				return err
+			}
+			return tx.Model(&postV1{}).RemoveIndex("idx_posts_slug").Error
+		},
+	},
+	{
+		Version: 5,
+		Name:    "add post format",
+		// Posts were written in Markdown if they have Markdown source, otherwise in HTML.
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&postV5{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec("UPDATE posts SET format = ? WHERE markdown <> ''", FormatMarkdown).Error; err != nil {
+				return err
+			}
+			return tx.Exec("UPDATE posts SET format = ? WHERE markdown = '' OR markdown IS NULL", FormatHTML).Error
+		},
+		// SQLite older than 3.35 cannot drop columns, so this fails there.
+		Down: func(tx *gorm.DB) error {
+			return tx.Model(&postV5{}).DropColumn("format").Error
+		},
+	},
+	{
+		Version: 6,
+		Name:    "sanitize post content",
+		// Content saved before sanitization existed goes through the policy new posts had then.
+		Up: func(tx *gorm.DB) error {
+			var embedHosts string
+			if Settings != nil {
+				embedHosts = Settings.EmbedHosts
+			}
+			var users []userV2
+			if err := tx.Find(&users).Error; err != nil && err != gorm.RecordNotFound {
+				return err
+			}
+			roles := make(map[int64]string)
+			for _, user := range users {
+				roles[user.ID] = user.Role
+			}
+			var posts []postV5
+			if err := tx.Find(&posts).Error; err != nil && err != gorm.RecordNotFound {
+				return err
+			}
+			for _, post := range posts {
+				content := sanitizePolicyV6(roles[post.Author], embedHosts).Sanitize(post.Content)
+				if content == post.Content {
+					continue
+				}
+				if err := tx.Exec("UPDATE posts SET content = ? WHERE id = ?", content, post.ID).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		// Removed markup is gone for good, there is nothing to undo.
+		Down: func(tx *gorm.DB) error {
+			return nil
+		},
+	},
+	{
+		Version: 7,
+		Name:    "add post reading time",
+		// Existing posts get their reading time estimated from the content they have now.
+		// Heading IDs and tables of contents appear when a post is next saved.
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&postV7{}).Error; err != nil {
+				return err
+			}
+			var posts []postV7
+			if err := tx.Find(&posts).Error; err != nil && err != gorm.RecordNotFound {
+				return err
+			}
+			for _, post := range posts {
+				if err := tx.Exec("UPDATE posts SET reading_time = ? WHERE id = ?", readingTimeV7(post.Content), post.ID).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		// SQLite older than 3.35 cannot drop columns, so this fails there.
+		Down: func(tx *gorm.DB) error {
+			return tx.Model(&postV7{}).DropColumn("reading_time").Error
+		},
+	},
+	{
+		Version: 8,
+		Name:    "create drafts",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&draftV8{}).Error; err != nil {
+				return err
+			}
+			return tx.Model(&draftV8{}).AddUniqueIndex("idx_drafts_author_post", "author", "post").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.DropTable(&draftV8{}).Error
+		},
+	},
+	{
+		Version: 9,
+		Name:    "create preview links",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&previewV9{}).Error; err != nil {
+				return err
+			}
+			return tx.Model(&previewV9{}).AddIndex("idx_previews_post", "post").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.DropTable(&previewV9{}).Error
+		},
+	},
+	{
+		Version: 10,
+		Name:    "add pages",
+		// Everything written so far is a post.
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&postV10{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec("UPDATE posts SET kind = ?, parent = 0, menu_order = 0", KindPost).Error; err != nil {
+				return err
+			}
+			return tx.Model(&postV10{}).AddIndex("idx_posts_kind", "kind").Error
+		},
+		// SQLite older than 3.35 cannot drop columns, so this fails there.
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Model(&postV10{}).RemoveIndex("idx_posts_kind").Error; err != nil {
+				return err
+			}
+			for _, column := range []string{"kind", "parent", "menu_order"} {
+				if err := tx.Model(&postV10{}).DropColumn(column).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version: 11,
+		Name:    "create series",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&seriesV11{}, &postV11{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&seriesV11{}).AddUniqueIndex("idx_series_slug", "slug").Error; err != nil {
+				return err
+			}
+			if err := tx.Exec("UPDATE posts SET series = 0, part = 0").Error; err != nil {
+				return err
+			}
+			return tx.Model(&postV11{}).AddIndex("idx_posts_series", "series").Error
+		},
+		// SQLite older than 3.35 cannot drop columns, so this fails there.
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Model(&postV11{}).RemoveIndex("idx_posts_series").Error; err != nil {
+				return err
+			}
+			for _, column := range []string{"series", "part"} {
+				if err := tx.Model(&postV11{}).DropColumn(column).Error; err != nil {
+					return err
+				}
+			}
+			return tx.DropTable(&seriesV11{}).Error
+		},
+	},
+	{
+		Version: 12,
+		Name:    "create webhooks",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&webhookV12{}, &webhookDeliveryV12{}).Error; err != nil {
+				return err
+			}
+			return tx.Model(&webhookDeliveryV12{}).AddIndex("idx_webhook_deliveries_webhook", "webhook").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.DropTable(&webhookDeliveryV12{}).Error; err != nil {
+				return err
+			}
+			return tx.DropTable(&webhookV12{}).Error
+		},
+	},
+	{
+		Version: 13,
+		Name:    "create access tokens and deleted posts",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&accessTokenV13{}, &deletedPostV13{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&accessTokenV13{}).AddUniqueIndex("idx_access_tokens_digest", "digest").Error; err != nil {
+				return err
+			}
+			return tx.Model(&deletedPostV13{}).AddIndex("idx_deleted_posts_slug", "slug").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.DropTable(&deletedPostV13{}).Error; err != nil {
+				return err
+			}
+			return tx.DropTable(&accessTokenV13{}).Error
+		},
+	},
+	{
+		Version: 14,
+		Name:    "create webmentions",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&webmentionV14{}).Error; err != nil {
+				return err
+			}
+			return tx.Model(&webmentionV14{}).AddIndex("idx_webmentions_post", "post").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.DropTable(&webmentionV14{}).Error
+		},
+	},
+	{
+		Version: 15,
+		Name:    "add activitypub actors and followers",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&actorKeyV15{}, &followerV15{}, &commentV15{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&actorKeyV15{}).AddUniqueIndex("idx_actor_keys_author", "author").Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&followerV15{}).AddUniqueIndex("idx_followers_author_actor", "author", "actor").Error; err != nil {
+				return err
+			}
+			return tx.Model(&commentV15{}).AddIndex("idx_comments_remote", "remote").Error
+		},
+		// SQLite older than 3.35 cannot drop columns, so this fails there.
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Model(&commentV15{}).RemoveIndex("idx_comments_remote").Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&commentV15{}).DropColumn("remote").Error; err != nil {
+				return err
+			}
+			if err := tx.DropTable(&followerV15{}).Error; err != nil {
+				return err
+			}
+			return tx.DropTable(&actorKeyV15{}).Error
+		},
+	},
+	{
+		Version: 16,
+		Name:    "add actors of remote comments",
+		// Replies received before have no actor, so only their author's server could change them; now nobody can.
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&commentV16{}).Error
+		},
+		// SQLite older than 3.35 cannot drop columns, so this fails there.
+		Down: func(tx *gorm.DB) error {
+			return tx.Model(&commentV16{}).DropColumn("actor").Error
+		},
+	},
+}
+
+// transactionalDDL reports whether the configured driver can roll back schema changes.
+// MySQL commits implicitly on every CREATE, ALTER and DROP statement.
+func transactionalDDL() bool {
+	return Config.Driver != "mysql"
+}
+
+// appliedMigrations returns the rows of schema_migrations keyed by version.
+func appliedMigrations() (map[int64]SchemaMigration, error) {
+	applied := make(map[int64]SchemaMigration)
+	if err := db.AutoMigrate(&SchemaMigration{}).Error; err != nil {
+		return applied, err
+	}
+	var rows []SchemaMigration
+	query := db.Find(&rows)
+	if query.Error != nil && query.Error != gorm.RecordNotFound {
+		return applied, query.Error
+	}
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// run applies the migration in the given direction and records the result in schema_migrations.
+// The whole migration is run in a transaction when the driver supports it.
+func (m Migration) run(up bool) (err error) {
+	tx := db
+	if transactionalDDL() {
+		tx = db.Begin()
+		if tx.Error != nil {
+			return tx.Error
+		}
+		defer func() {
+			if err != nil {
+				tx.Rollback()
+				return
+			}
+			err = tx.Commit().Error
+		}()
+	}
+	if up {
+		if err = m.Up(tx); err != nil {
+			return fmt.Errorf("migration %d (%s): %v", m.Version, m.Name, err)
+		}
+		return tx.Create(&SchemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now().Unix()}).Error
+	}
+	if err = m.Down(tx); err != nil {
+		return fmt.Errorf("migration %d (%s): %v", m.Version, m.Name, err)
+	}
+	return tx.Where("version = ?", m.Version).Delete(&SchemaMigration{}).Error
+}
+
+// MigrateUp applies all pending migrations up to and including version target.
+// Target 0 applies every pending migration. Returns the migrations which were applied.
+func MigrateUp(target int64) ([]Migration, error) {
+	var done []Migration
+	applied, err := appliedMigrations()
+	if err != nil {
+		return done, err
+	}
+	for _, m := range migrations {
+		if target > 0 && m.Version > target {
+			break
+		}
+		if _, exists := applied[m.Version]; exists {
+			continue
+		}
+		if err := m.run(true); err != nil {
+			return done, err
+		}
+		log.Println("applied migration", m.Version, m.Name)
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// MigrateDown rolls back the given number of most recently applied migrations.
+// Returns the migrations which were rolled back.
+func MigrateDown(steps int) ([]Migration, error) {
+	var done []Migration
+	applied, err := appliedMigrations()
+	if err != nil {
+		return done, err
+	}
+	for version := range applied {
+		if version > migrations[len(migrations)-1].Version {
+			return done, errors.New("database has migrations applied which this version of vertigo does not know about")
+		}
+	}
+	for i := len(migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		m := migrations[i]
+		if _, exists := applied[m.Version]; !exists {
+			continue
+		}
+		if err := m.run(false); err != nil {
+			return done, err
+		}
+		log.Println("rolled back migration", m.Version, m.Name)
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// MigrationStatus returns the state of every known migration.
+func MigrationStatus() ([]MigrationState, error) {
+	var states []MigrationState
+	applied, err := appliedMigrations()
+	if err != nil {
+		return states, err
+	}
+	for _, m := range migrations {
+		state := MigrationState{Version: m.Version, Name: m.Name}
+		if row, exists := applied[m.Version]; exists {
+			state.Applied = true
+			state.AppliedAt = row.AppliedAt
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
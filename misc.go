@@ -3,7 +3,6 @@ package main
 // root returns HTTP request "root".
 
 import (
-	"flag"
 	"io"
 	"io/ioutil"
 	"log"
@@ -17,39 +16,23 @@ import (
 	_ "github.com/mattn/go-sqlite3"
 )
 
-// FIXME: are these even used?
-//var driver = flag.String("driver", "sqlite3", "name of the database driver used, by default sqlite3")
-//var dbsource = flag.String("dbsource", "./vertigo.db", "connection string or path to database file")
-var settingssource = flag.String("settings", "./settings.json", "path to settings file")
-
 // For example, calling it with http.Request which has URL of /api/user/5348482a2142dfb84ca41085
 // would return "api". This function is used to route both JSON API and frontend requests in the same function.
 func root(r *http.Request) string {
 	return strings.Split(strings.TrimPrefix(r.URL.String(), "/"), "/")[0]
 }
 
-// NotFound is a shorthand JSON response for HTTP 404 errors.
-func NotFound() map[string]interface{} {
-	return map[string]interface{}{"error": http.StatusText(http.StatusNotFound)}
-}
-
+// urlHost returns the hostname of the blog without a trailing slash, so paths can be appended to it.
+// Hostnames saved before they were normalized may still end with one.
 func urlHost() string {
-	return Settings.Hostname
+	return strings.TrimRight(Settings.Hostname, "/")
 }
 
+// initDB opens the database described by Config.Driver and Config.DSN.
 func initDB() *gorm.DB {
+	log.Println("Using", Config.Driver)
 
-	if os.Getenv("DATABASE_URL") != "" {
-		os.Setenv("driver", "postgres")
-		os.Setenv("dbsource", os.Getenv("DATABASE_URL"))
-		log.Println("Using PostgreSQL")
-	} else {
-		os.Setenv("driver", "sqlite3")
-		os.Setenv("dbsource", "./vertigo.db")
-		log.Println("Using SQLite3")
-	}
-
-	db, err := gorm.Open(os.Getenv("driver"), os.Getenv("dbsource"))
+	db, err := gorm.Open(Config.Driver, Config.DSN)
 
 	if err != nil {
 		panic(err)
@@ -57,12 +40,7 @@ func initDB() *gorm.DB {
 
 	db.LogMode(false)
 
-	// Here database and tables are created in case they do not exist yet.
-	// If database or tables do exist, nothing will happen to the original ones.
-	db.CreateTable(&User{})
-	db.CreateTable(&Post{})
-	db.AutoMigrate(&User{}, &Post{})
-
+	// Tables are created and updated by migrations, see migrations.go.
 	return &db
 }
 
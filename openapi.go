@@ -0,0 +1,946 @@
+// Openapi.go generates the OpenAPI 3 document of the JSON API, served at /api/openapi.json.
+// The document is made by walking the routes registered in NewServer, so it cannot list routes
+// the server does not answer. What each route does is described in apiDocs, and the bodies it
+// reads and writes are described by reflecting the structs it binds, such as Post and User.
+// The /api page is rendered from the same document.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// OpenAPI is an OpenAPI 3 document. Only the parts the JSON API needs are defined.
+type OpenAPI struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       OpenAPIInfo                             `json:"info"`
+	Tags       []OpenAPITag                            `json:"tags"`
+	Paths      map[string]map[string]*OpenAPIOperation `json:"paths"`
+	Components OpenAPIComponents                       `json:"components"`
+	// Operations lists the operations of Paths in the order their routes were registered.
+	Operations []*OpenAPIOperation `json:"-"`
+}
+
+// OpenAPIInfo describes the API itself.
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// OpenAPITag groups operations, such as the ones of posts. The /api page has a section for each tag.
+type OpenAPITag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// OpenAPIOperation is a single method of a path, such as GET /api/v1/posts.
+type OpenAPIOperation struct {
+	Method      string                     `json:"-"`
+	Path        string                     `json:"-"`
+	Successor   string                     `json:"-"`
+	Tags        []string                   `json:"tags"`
+	Summary     string                     `json:"summary"`
+	Description string                     `json:"description,omitempty"`
+	Parameters  []OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]OpenAPIResponse `json:"responses"`
+	Security    []map[string][]string      `json:"security,omitempty"`
+	Deprecated  bool                       `json:"deprecated,omitempty"`
+}
+
+// OpenAPIParameter is a parameter given in the path or query string of a request.
+type OpenAPIParameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required,omitempty"`
+	Schema      *OpenAPISchema `json:"schema"`
+}
+
+// OpenAPIRequestBody describes the body of a request.
+type OpenAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]OpenAPIMediaType `json:"content"`
+}
+
+// OpenAPIResponse describes a response of an operation.
+type OpenAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+// OpenAPIMediaType is the schema of a body in a single media type, with an optional example.
+type OpenAPIMediaType struct {
+	Schema  *OpenAPISchema  `json:"schema,omitempty"`
+	Example json.RawMessage `json:"example,omitempty"`
+}
+
+// OpenAPISchema describes a JSON value. Schemas of structs are components referred to by Ref.
+type OpenAPISchema struct {
+	Ref        string                    `json:"$ref,omitempty"`
+	Type       string                    `json:"type,omitempty"`
+	Format     string                    `json:"format,omitempty"`
+	Enum       []string                  `json:"enum,omitempty"`
+	Items      *OpenAPISchema            `json:"items,omitempty"`
+	Properties map[string]*OpenAPISchema `json:"properties,omitempty"`
+	Required   []string                  `json:"required,omitempty"`
+	// Fields lists the names of Properties in the order of the fields of the struct.
+	Fields []string `json:"-"`
+}
+
+// OpenAPIComponents holds the schemas and security schemes operations refer to.
+type OpenAPIComponents struct {
+	Schemas         map[string]*OpenAPISchema        `json:"schemas"`
+	SecuritySchemes map[string]OpenAPISecurityScheme `json:"securitySchemes"`
+}
+
+// OpenAPISecurityScheme describes how requests are authenticated.
+type OpenAPISecurityScheme struct {
+	Type        string `json:"type"`
+	In          string `json:"in"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// apiDoc describes a route of the JSON API. Descriptions are Markdown.
+type apiDoc struct {
+	Tag         string
+	Summary     string
+	Description string
+	// Session is true for routes which require an active session.
+	Session bool
+	Query   []OpenAPIParameter
+	// Request is the struct bound from the body of the request, or an *OpenAPISchema of it.
+	Request interface{}
+	// Example is a JSON example of the body of the request.
+	Example string
+	// Response is the value written as the body of the response, or an *OpenAPISchema of it.
+	Response interface{}
+	// Produces is the media type of the response, application/json unless given.
+	Produces string
+	// Successor is the route which replaces a deprecated one, such as "GET /api/v1/users".
+	// Deprecated routes are described by their successor.
+	Successor string
+}
+
+// apiTags lists the tags of the API in the order of the sections of the /api page, with the
+// schemas each section shows.
+var apiTags = []struct {
+	OpenAPITag
+	schemas []string
+}{
+	{OpenAPITag{Name: "Users"}, []string{"User", "Login", "RecoveryRequest", "PasswordReset"}},
+	{OpenAPITag{Name: "Posts"}, []string{"Post", "Preview"}},
+	{OpenAPITag{Name: "Series", Description: "A series groups posts of one author, such as a tutorial published in several parts. Each part shows its place in the series with links to the previous and next part. Series have an index page at `/series/:slug` and feeds at `/series/:slug/rss` and `/series/:slug/atom`."}, []string{"Series"}},
+	{OpenAPITag{Name: "Drafts", Description: "The post editor autosaves unsaved changes into a draft, one per user and post. Drafts are identified by the ID of the post, or 0 for a new post. Saving or deleting the post discards its draft."}, []string{"Draft"}},
+	{OpenAPITag{Name: "Search"}, []string{"Search"}},
+	{OpenAPITag{Name: "Settings"}, []string{"Vertigo", "MailgunSettings"}},
+	{OpenAPITag{Name: "Backup"}, nil},
+	{OpenAPITag{Name: "Tokens", Description: "Access tokens let external clients act for a user without their password, such as Micropub clients which publish to `/micropub`. Clients send the token in header `Authorization: Bearer <token>`. Scopes are `create`, `update` and `delete` for the Micropub actions and `media` for uploading files to `/micropub/media`. A token is only returned when it is created."}, []string{"AccessToken"}},
+	{OpenAPITag{Name: "Webmentions", Description: "Webmentions tell the blog that a page on another site links to a post. Sites send them to `/webmention` as form parameters `source`, the URL of their page, and `target`, the URL of the post, which is answered with 202 Accepted. The source is then fetched in the background to verify that it links to the post, and its microformats tell the author and whether it is a `like`, `repost`, `bookmark`, `reply` or plain `mention`. Verified webmentions are shown under the post once its author approves them. Publishing or updating a post sends webmentions to the pages it links to in the same way."}, []string{"Webmention"}},
+	{OpenAPITag{Name: "Followers", Description: "Every author is an ActivityPub actor at `/activitypub/authors/:id`, which Mastodon and other fediverse servers find by WebFinger at `/.well-known/webfinger` as `@name@host`, where name is made of the name of the author. Followers are sent `Create`, `Update` and `Delete` activities of posts as they are published, edited and deleted, and their public replies become comments, which are shown under the post once its author approves them. Requests between servers are signed with HTTP Signatures."}, []string{"Follower", "Comment"}},
+	{OpenAPITag{Name: "Webhooks", Description: "Webhooks notify other systems, such as a CDN or a chat, when content changes. Only admins are allowed to manage them. Events are `post.published`, `post.unpublished`, `post.updated`, `post.deleted` and `user.created`, and a webhook with no `events` receives all of them.\n\n" +
+		"Each event is POSTed to the URL as a JSON `WebhookEvent` whose `data` is the post or user, with headers `X-Vertigo-Event` naming the event, `X-Vertigo-Delivery` its ID and `X-Vertigo-Signature` the HMAC-SHA256 of the body keyed with the secret, as `sha256=` followed by its hex digest. Compare the signature to your own before trusting the event. Deliveries which do not get a 2xx response in 10 seconds are retried 4 times, waiting 30 seconds and twice as long after each failure. Every attempt is recorded in the delivery log of the webhook, which keeps the latest 100."}, []string{"Webhook", "WebhookDelivery", "WebhookEvent"}},
+	{OpenAPITag{Name: "GraphQL", Description: "The GraphQL endpoint serves posts, their authors and tags, and search in a single request. Lists of posts are paginated with cursors: pass `endCursor` of `pageInfo` as argument `after` to get the next page. Mutations `createPost`, `updatePost`, `publishPost` and `unpublishPost` follow the same rules as the routes above and require active session. Errors carry the same codes as the routes above in their `extensions`.\n\n" +
+		"Lists of posts return 10 posts by default and 50 at most. Queries nested deeper than 10 levels, or with a complexity over 1000, are refused with 400 Bad Request and code `query_too_deep` or `query_too_complex`. The complexity is the number of fields the query returns, counting the fields of each post of a list as many times as the list has posts. Introspect the endpoint for its schema: introspection fields count toward the complexity and may be nested 15 levels deep, with at most 3 lists such as `types`, `fields` and `args` inside each other."}, []string{"GraphQLRequest"}},
+	{OpenAPITag{Name: "Specification"}, nil},
+}
+
+// apiPathParameters describes the parameters in the paths of routes, such as {slug}.
+var apiPathParameters = map[string]OpenAPIParameter{
+	"id":       {Description: "ID of the user, preview link, webhook, access token, webmention, follower or comment.", Schema: &OpenAPISchema{Type: "integer", Format: "int64"}},
+	"slug":     {Description: "Slug of the post or series.", Schema: &OpenAPISchema{Type: "string"}},
+	"post":     {Description: "ID of the post, or 0 for a new post.", Schema: &OpenAPISchema{Type: "integer", Format: "int64"}},
+	"recovery": {Description: "Recovery code sent by email.", Schema: &OpenAPISchema{Type: "string"}},
+}
+
+// formatParameter is the format query parameter of the routes which read posts.
+var formatParameter = OpenAPIParameter{
+	Name:        "format",
+	In:          "query",
+	Description: "Return only the Markdown source or only the HTML content of posts.",
+	Schema:      &OpenAPISchema{Type: "string", Enum: []string{"markdown", "html"}},
+}
+
+// fieldsParameter is the fields query parameter of the routes which read users or posts, see fields.go.
+var fieldsParameter = OpenAPIParameter{
+	Name:        "fields",
+	In:          "query",
+	Description: "Comma separated fields to return, such as id,title,slug. All fields by default.",
+	Schema:      &OpenAPISchema{Type: "string"},
+}
+
+// userParameters are the query parameters of the routes which read users.
+var userParameters = []OpenAPIParameter{
+	fieldsParameter,
+	{Name: "include", In: "query", Description: "Related resources to embed in each user. Only posts is supported.", Schema: &OpenAPISchema{Type: "string", Enum: []string{"posts"}}},
+	{Name: "fields[posts]", In: "query", Description: "Comma separated fields of embedded posts. All fields but content and markdown by default.", Schema: &OpenAPISchema{Type: "string"}},
+}
+
+// userSelection describes the query parameters of the routes which read users.
+const userSelection = "Posts of users are left out unless `?include=posts` is given, and embedded posts leave out `content` and `markdown` unless `fields[posts]` lists them. " +
+	"The deprecated routes under `/api/` select fields the same way.\n\n" +
+	"Unknown fields return 400 Bad Request with code `invalid_fields`, and unknown resources to include with code `invalid_include`."
+
+// successSchema is the schema of responses which only report success, such as {"success": "Post deleted"}.
+var successSchema = &OpenAPISchema{Ref: "#/components/schemas/Success"}
+
+// apiDocs describes every route of the JSON API, keyed by its method and path template.
+// Routes missing from here are left out of the OpenAPI document, which makes TestOpenAPI fail.
+var apiDocs = map[string]apiDoc{
+	"POST /api/graphql": {
+		Tag:         "GraphQL",
+		Summary:     "Runs a GraphQL query or mutation.",
+		Description: "Responds with the `data` and `errors` of the query. Requests which cannot be run at all, such as queries with syntax errors, return 400 Bad Request.",
+		Request:     GraphQLRequest{},
+		Example:     `{"query": "{ posts(first: 5) { edges { node { title slug author { name } tags { name } } } pageInfo { hasNextPage endCursor } } }"}`,
+		Response:    &OpenAPISchema{Type: "object"},
+	},
+	"GET /api/graphql": {
+		Tag:         "GraphQL",
+		Summary:     "Runs a GraphQL query given in query parameters.",
+		Description: "Mutations are refused with 405 Method Not Allowed, as they must be sent with POST.",
+		Query: []OpenAPIParameter{
+			{Name: "query", In: "query", Required: true, Description: "The GraphQL query.", Schema: &OpenAPISchema{Type: "string"}},
+			{Name: "variables", In: "query", Description: "Variables of the query as a JSON object.", Schema: &OpenAPISchema{Type: "string"}},
+			{Name: "operationName", In: "query", Description: "Operation to run, if the query has many.", Schema: &OpenAPISchema{Type: "string"}},
+		},
+		Response: &OpenAPISchema{Type: "object"},
+	},
+	"GET /api/openapi.json": {
+		Tag:         "Specification",
+		Summary:     "Displays this API as an OpenAPI 3 document.",
+		Description: "The document is generated from the routes the server registers, so it always lists every route of the API.",
+		Response:    &OpenAPISchema{Type: "object"},
+	},
+
+	"GET /api/v1/users": {
+		Tag:         "Users",
+		Summary:     "Displays all users and their data.",
+		Description: userSelection,
+		Query:       userParameters,
+		Response:    []User{},
+	},
+	"GET /api/v1/users/{id}": {
+		Tag:         "Users",
+		Summary:     "Displays data of a single user.",
+		Description: "Unpublished posts are only embedded for the user themselves. " + userSelection,
+		Query:       userParameters,
+		Response:    User{},
+	},
+	"POST /api/v1/users": {
+		Tag:         "Users",
+		Summary:     "Creates a new user.",
+		Description: "Required parameters are email and password. Passwords must be at least 8 characters long and mix letters with digits or other characters.",
+		Request:     User{},
+		Example:     `{"name": "Juuso", "password": "correct-horse", "email": "foo@example.com"}`,
+		Response:    User{},
+	},
+	"POST /api/v1/session": {
+		Tag:         "Users",
+		Summary:     "Logins a user and if successful, returns session cookie.",
+		Description: "Required parameters are email and password.",
+		Request:     Login{},
+		Example:     `{"email": "foo@example.com", "password": "foo"}`,
+		Response:    User{},
+	},
+	"DELETE /api/v1/session": {
+		Tag:      "Users",
+		Summary:  "Logs out and deletes the current session.",
+		Response: successSchema,
+	},
+	"POST /api/v1/recoveries": {
+		Tag:         "Users",
+		Summary:     "Sends a link to reset the password of a user.",
+		Description: "The link is sent to the given email address and expires in three hours. Answers 403 Forbidden with the code `recovery_disabled` if password recovery is disabled in settings.",
+		Request:     RecoveryRequest{},
+		Example:     `{"email": "foo@example.com"}`,
+		Response:    successSchema,
+	},
+	"POST /api/v1/recoveries/{id}/{recovery}": {
+		Tag:         "Users",
+		Summary:     "Resets the password of a user.",
+		Description: "Requires the user ID and recovery code of the link sent by `POST /api/v1/recoveries`. The new password follows the same rules as on registration.",
+		Request:     PasswordReset{},
+		Example:     `{"password": "new-password"}`,
+		Response:    successSchema,
+	},
+
+	"GET /api/v1/posts": {
+		Tag:         "Posts",
+		Summary:     "Displays all posts.",
+		Description: "Add `?format=markdown` to get only the Markdown source of each post or `?format=html` to get only its HTML content. Posts written in the HTML editor are converted to Markdown. Add `?kind=page` to list pages instead of blog posts. Add `?fields=` to return only some fields of each post, such as `?fields=id,title,slug,excerpt`.",
+		Query: []OpenAPIParameter{
+			formatParameter,
+			fieldsParameter,
+			{Name: "kind", In: "query", Description: "Kind of the posts to list, post by default.", Schema: &OpenAPISchema{Type: "string", Enum: []string{KindPost, KindPage}}},
+		},
+		Response: []Post{},
+	},
+	"GET /api/v1/posts/{slug}": {
+		Tag:     "Posts",
+		Summary: "Displays a single post.",
+		Description: "Accepts the same `format` and `fields` parameters as `/api/v1/posts`. Old slugs of renamed posts redirect to the current one with 301 Moved Permanently.\n\n" +
+			"Unpublished posts return 404 Not Found to everyone else than their author, unless a preview token is given with `?preview=`.",
+		Query: []OpenAPIParameter{
+			formatParameter,
+			fieldsParameter,
+			{Name: "preview", In: "query", Description: "Token of a preview link of an unpublished post.", Schema: &OpenAPISchema{Type: "string"}},
+		},
+		Response: Post{},
+	},
+	"POST /api/v1/posts": {
+		Tag:     "Posts",
+		Summary: "Creates a new post.",
+		Description: "Title is required and at most 200 characters long. The slug is made of the title unless one is given. If another post already uses it, -2, -3 and so on is appended.\n\n" +
+			"Format is one of `markdown`, `html` or `plain` and defaults to the one set in settings. HTML posts are written into `content`, Markdown and plain text posts into `markdown`, from which `content` is rendered. Giving a different format when updating a post converts it.\n\n" +
+			"Headings of Markdown posts get an ID made of their text, such as `getting-started` for `## Getting started`, or the one given with `## Getting started {#setup}`. A line containing only `[TOC]` is replaced with a table of contents linking to the headings. Footnotes are written as `[^1]` and defined with `[^1]: The note.`. Every post has a `readingtime`, the estimated minutes it takes to read.\n\n" +
+			"Kind is `post` by default. Pages, of kind `page`, are left out of the homepage and feeds and live at top-level URLs such as `/about`. A page may be placed below another page by giving its ID as `parent`, in which case its URL becomes `/about/team`. Published pages with a `menuorder` greater than zero are listed in the navigation menu, from the smallest order to the greatest. Pages cannot use slugs of the top-level routes, such as `user` or `feeds`.\n\n" +
+			"A post is made a part of one of its author's series by giving the ID of the series as `series`. Parts are ordered by `part`, and a post without one becomes the last part. When updating a post, `kind`, `parent`, `menuorder`, `series` and `part` are only changed if `kind` is given. A parent, menu order or series left out is then cleared, while a part left out keeps its number within the same series.\n\n" +
+			"Rendered content is sanitized against an allow-list of HTML: scripts, event handlers, styles and `javascript:` links are removed. Posts of admins may also contain iframes from the hosts listed in the `embedhosts` setting.",
+		Session:  true,
+		Request:  Post{},
+		Example:  `{"title": "My first post", "content": "This is my first post!"}`,
+		Response: Post{},
+	},
+	"POST /api/v1/posts/preview": {
+		Tag:         "Posts",
+		Summary:     "Renders a post without saving it, the same way creating it would.",
+		Description: "Accepts the same payload as `POST /api/v1/posts` and returns the post object with rendered `content`, `excerpt` and `readingtime`.",
+		Session:     true,
+		Request:     Post{},
+		Example:     `{"title": "My first post", "format": "markdown", "markdown": "This is *my* first post!"}`,
+		Response:    Post{},
+	},
+	"PATCH /api/v1/posts/{slug}": {
+		Tag:         "Posts",
+		Summary:     "Updates a post.",
+		Description: "Fields left out keep their current values. Giving a new slug renames the post and makes the old slug redirect to it. Drafts are also renamed when their title changes.",
+		Session:     true,
+		Request:     Post{},
+		Example:     `{"slug": "my-first-post-edited", "title": "My first post edited", "content": "This is my first post, edited."}`,
+		Response:    Post{},
+	},
+	"DELETE /api/v1/posts/{slug}": {
+		Tag:      "Posts",
+		Summary:  "Deletes a post.",
+		Session:  true,
+		Response: successSchema,
+	},
+	"PUT /api/v1/posts/{slug}/published": {
+		Tag:      "Posts",
+		Summary:  "Publishes a post.",
+		Session:  true,
+		Response: successSchema,
+	},
+	"DELETE /api/v1/posts/{slug}/published": {
+		Tag:      "Posts",
+		Summary:  "Unpublishes a post.",
+		Session:  true,
+		Response: successSchema,
+	},
+	"GET /api/v1/posts/{slug}/previews": {
+		Tag:         "Posts",
+		Summary:     "Lists the preview links of a post which have not expired.",
+		Description: "Only the author of the post is allowed. Anyone with the `url` of a preview link can read the post before it is published.",
+		Session:     true,
+		Response:    []Preview{},
+	},
+	"POST /api/v1/posts/{slug}/previews": {
+		Tag:         "Posts",
+		Summary:     "Creates a preview link of a post.",
+		Description: "Only the author of the post is allowed. The link expires in the given number of days, 7 by default and 90 at most.",
+		Session:     true,
+		Request: &OpenAPISchema{Type: "object", Fields: []string{"days"}, Properties: map[string]*OpenAPISchema{
+			"days": {Type: "integer"},
+		}},
+		Example:  `{"days": 14}`,
+		Response: Preview{},
+	},
+	"DELETE /api/v1/posts/{slug}/previews/{id}": {
+		Tag:         "Posts",
+		Summary:     "Revokes a preview link.",
+		Description: "Only the author of the post is allowed.",
+		Session:     true,
+		Response:    successSchema,
+	},
+
+	"GET /api/v1/series": {
+		Tag:      "Series",
+		Summary:  "Displays all series without their posts.",
+		Response: []Series{},
+	},
+	"GET /api/v1/series/{slug}": {
+		Tag:      "Series",
+		Summary:  "Displays a series with its published posts in `posts`, in order of their parts.",
+		Response: Series{},
+	},
+	"POST /api/v1/series": {
+		Tag:         "Series",
+		Summary:     "Creates a new series.",
+		Description: "The slug is made of the title unless one is given.",
+		Session:     true,
+		Request:     Series{},
+		Example:     `{"title": "Go from scratch", "description": "Learn Go in five parts."}`,
+		Response:    Series{},
+	},
+	"PATCH /api/v1/series/{slug}": {
+		Tag:         "Series",
+		Summary:     "Updates a series.",
+		Description: "Only its author is allowed. Fields left out keep their current values.",
+		Session:     true,
+		Request:     Series{},
+		Example:     `{"description": "Learn Go in six parts."}`,
+		Response:    Series{},
+	},
+	"DELETE /api/v1/series/{slug}": {
+		Tag:         "Series",
+		Summary:     "Deletes a series.",
+		Description: "Only its author is allowed. Its posts are kept as standalone posts.",
+		Session:     true,
+		Response:    successSchema,
+	},
+
+	"GET /api/v1/drafts/{post}": {
+		Tag:         "Drafts",
+		Summary:     "Displays the draft of the current user for a post.",
+		Description: "Returns 404 Not Found if there is none.",
+		Session:     true,
+		Response:    Draft{},
+	},
+	"PUT /api/v1/drafts/{post}": {
+		Tag:      "Drafts",
+		Summary:  "Replaces the draft of the current user for a post.",
+		Session:  true,
+		Request:  Draft{},
+		Example:  `{"title": "My first post", "format": "markdown", "markdown": "This is my first"}`,
+		Response: Draft{},
+	},
+	"DELETE /api/v1/drafts/{post}": {
+		Tag:      "Drafts",
+		Summary:  "Discards the draft of the current user for a post.",
+		Session:  true,
+		Response: successSchema,
+	},
+
+	"POST /api/v1/search": {
+		Tag:         "Search",
+		Summary:     "Uses site's search to find posts with given query.",
+		Description: "Add `?fields=` to return only some fields of each post, such as `?fields=id,title,slug,excerpt`.",
+		Query:       []OpenAPIParameter{fieldsParameter},
+		Request:     Search{},
+		Example:     `{"query": "first"}`,
+		Response:    []Post{},
+	},
+
+	"GET /api/v1/settings": {
+		Tag:      "Settings",
+		Summary:  "Displays settings given in installation wizard.",
+		Session:  true,
+		Response: Vertigo{},
+	},
+	"PUT /api/v1/settings": {
+		Tag:         "Settings",
+		Summary:     "Updates the settings with given data.",
+		Description: "Hostname must be an absolute URL, such as `http://example.com`. The Mailgun settings are only required if password recovery is enabled with `recovery`.",
+		Session:     true,
+		Request:     Vertigo{},
+		Example:     `{"hostname": "http://example.com", "name": "Foo Blog", "description": "Foo's test blog", "mailgun": {"mgdomain": "foo", "mgprikey": "foo"}}`,
+		Response:    successSchema,
+	},
+	"POST /api/v1/installation": {
+		Tag:         "Settings",
+		Summary:     "Saves the settings of a new site.",
+		Description: "Once the site is installed, works like `PUT /api/v1/settings`.",
+		Request:     Vertigo{},
+		Example:     `{"hostname": "http://example.com", "name": "Foo Blog", "description": "Foo's test blog", "mailgun": {"mgdomain": "foo", "mgprikey": "foo"}}`,
+		Response:    successSchema,
+	},
+
+	"GET /api/v1/backup": {
+		Tag:         "Backup",
+		Summary:     "Downloads a zip archive of all users, posts, series, settings and uploaded files.",
+		Description: "Only admins are allowed.",
+		Session:     true,
+		Produces:    "application/zip",
+		Response:    &OpenAPISchema{Type: "string", Format: "binary"},
+	},
+
+	"GET /api/v1/tokens": {
+		Tag:      "Tokens",
+		Summary:  "Lists the access tokens of the current user without the tokens themselves.",
+		Session:  true,
+		Response: []AccessToken{},
+	},
+	"POST /api/v1/tokens": {
+		Tag:         "Tokens",
+		Summary:     "Creates an access token for the current user.",
+		Description: "`scope` lists scopes separated by spaces, or is empty for all of them. The token is returned in `token` and cannot be read again.",
+		Session:     true,
+		Request:     AccessToken{},
+		Example:     `{"name": "Phone", "scope": "create media"}`,
+		Response:    AccessToken{},
+	},
+	"DELETE /api/v1/tokens/{id}": {
+		Tag:      "Tokens",
+		Summary:  "Revokes an access token of the current user.",
+		Session:  true,
+		Response: successSchema,
+	},
+
+	"GET /api/v1/webmentions": {
+		Tag:         "Webmentions",
+		Summary:     "Lists the webmentions of the posts of the current user, newest first.",
+		Description: "`status` is `pending` until the source is verified, then `verified` or `invalid`.",
+		Session:     true,
+		Response:    []Webmention{},
+	},
+	"PUT /api/v1/webmentions/{id}/approved": {
+		Tag:      "Webmentions",
+		Summary:  "Approves a webmention of a post of the current user, showing it under the post once verified.",
+		Session:  true,
+		Response: successSchema,
+	},
+	"DELETE /api/v1/webmentions/{id}/approved": {
+		Tag:      "Webmentions",
+		Summary:  "Hides an approved webmention of a post of the current user.",
+		Session:  true,
+		Response: successSchema,
+	},
+	"DELETE /api/v1/webmentions/{id}": {
+		Tag:      "Webmentions",
+		Summary:  "Deletes a webmention of a post of the current user.",
+		Session:  true,
+		Response: successSchema,
+	},
+
+	"GET /api/v1/followers": {
+		Tag:      "Followers",
+		Summary:  "Lists the fediverse followers of the current user, newest first.",
+		Session:  true,
+		Response: []Follower{},
+	},
+	"DELETE /api/v1/followers/{id}": {
+		Tag:         "Followers",
+		Summary:     "Removes a follower of the current user.",
+		Description: "The server of the follower is sent a `Reject` of their `Follow`.",
+		Session:     true,
+		Response:    successSchema,
+	},
+	"GET /api/v1/comments": {
+		Tag:         "Followers",
+		Summary:     "Lists the comments of the posts of the current user, newest first.",
+		Description: "Replies from the fediverse have the ID of their object in `remote` and of their author in `actor`, and are not `approved` until the author of the post approves them.",
+		Session:     true,
+		Response:    []Comment{},
+	},
+	"PUT /api/v1/comments/{id}/approved": {
+		Tag:      "Followers",
+		Summary:  "Approves a comment of a post of the current user, showing it under the post.",
+		Session:  true,
+		Response: successSchema,
+	},
+	"DELETE /api/v1/comments/{id}/approved": {
+		Tag:      "Followers",
+		Summary:  "Hides an approved comment of a post of the current user.",
+		Session:  true,
+		Response: successSchema,
+	},
+	"DELETE /api/v1/comments/{id}": {
+		Tag:      "Followers",
+		Summary:  "Deletes a comment of a post of the current user.",
+		Session:  true,
+		Response: successSchema,
+	},
+
+	"GET /api/v1/webhooks": {
+		Tag:      "Webhooks",
+		Summary:  "Lists all webhooks.",
+		Session:  true,
+		Response: []Webhook{},
+	},
+	"POST /api/v1/webhooks": {
+		Tag:         "Webhooks",
+		Summary:     "Adds a webhook.",
+		Description: "`url` must be an absolute http or https URL. A random secret is generated unless one is given. `events` is a comma separated list of events, or empty for all of them.",
+		Session:     true,
+		Request:     Webhook{},
+		Example:     `{"url": "https://example.com/hook", "events": "post.published,post.unpublished"}`,
+		Response:    Webhook{},
+	},
+	"GET /api/v1/webhooks/{id}": {
+		Tag:      "Webhooks",
+		Summary:  "Displays a webhook.",
+		Session:  true,
+		Response: Webhook{},
+	},
+	"PUT /api/v1/webhooks/{id}": {
+		Tag:         "Webhooks",
+		Summary:     "Replaces the URL, events and state of a webhook.",
+		Description: "The secret is kept unless a new one is given.",
+		Session:     true,
+		Request:     Webhook{},
+		Example:     `{"url": "https://example.com/hook", "events": "", "disabled": true}`,
+		Response:    Webhook{},
+	},
+	"DELETE /api/v1/webhooks/{id}": {
+		Tag:      "Webhooks",
+		Summary:  "Deletes a webhook with its delivery log.",
+		Session:  true,
+		Response: successSchema,
+	},
+	"GET /api/v1/webhooks/{id}/deliveries": {
+		Tag:      "Webhooks",
+		Summary:  "Lists the delivery log of a webhook, newest first.",
+		Session:  true,
+		Response: []WebhookDelivery{},
+	},
+	"POST /api/v1/webhooks/{id}/test": {
+		Tag:         "Webhooks",
+		Summary:     "Sends a `ping` event to a webhook and returns the delivery.",
+		Description: "The ping is sent even if the webhook is disabled, and is not retried.",
+		Session:     true,
+		Response:    WebhookDelivery{},
+	},
+
+	"GET /api/users":                            {Successor: "GET /api/v1/users"},
+	"GET /api/user/{id}":                        {Successor: "GET /api/v1/users/{id}"},
+	"POST /api/user":                            {Successor: "POST /api/v1/users"},
+	"POST /api/user/login":                      {Successor: "POST /api/v1/session"},
+	"GET /api/user/logout":                      {Successor: "DELETE /api/v1/session"},
+	"GET /api/posts":                            {Successor: "GET /api/v1/posts"},
+	"GET /api/post/{slug}":                      {Successor: "GET /api/v1/posts/{slug}"},
+	"POST /api/post":                            {Successor: "POST /api/v1/posts"},
+	"GET /api/post/{slug}/publish":              {Successor: "PUT /api/v1/posts/{slug}/published"},
+	"GET /api/post/{slug}/unpublish":            {Successor: "DELETE /api/v1/posts/{slug}/published"},
+	"POST /api/post/{slug}/edit":                {Successor: "PATCH /api/v1/posts/{slug}"},
+	"GET /api/post/{slug}/delete":               {Successor: "DELETE /api/v1/posts/{slug}"},
+	"GET /api/post/{slug}/previews":             {Successor: "GET /api/v1/posts/{slug}/previews"},
+	"POST /api/post/{slug}/previews":            {Successor: "POST /api/v1/posts/{slug}/previews"},
+	"GET /api/post/{slug}/previews/{id}/delete": {Successor: "DELETE /api/v1/posts/{slug}/previews/{id}"},
+	"POST /api/post/preview":                    {Successor: "POST /api/v1/posts/preview"},
+	"GET /api/series":                           {Successor: "GET /api/v1/series"},
+	"GET /api/series/{slug}":                    {Successor: "GET /api/v1/series/{slug}"},
+	"POST /api/series":                          {Successor: "POST /api/v1/series"},
+	"POST /api/series/{slug}/edit":              {Successor: "PATCH /api/v1/series/{slug}"},
+	"GET /api/series/{slug}/delete":             {Successor: "DELETE /api/v1/series/{slug}"},
+	"GET /api/draft/{post}":                     {Successor: "GET /api/v1/drafts/{post}"},
+	"POST /api/draft/{post}":                    {Successor: "PUT /api/v1/drafts/{post}"},
+	"GET /api/draft/{post}/delete":              {Successor: "DELETE /api/v1/drafts/{post}"},
+	"POST /api/post/search":                     {Successor: "POST /api/v1/search"},
+	"GET /api/settings":                         {Successor: "GET /api/v1/settings"},
+	"POST /api/settings":                        {Successor: "PUT /api/v1/settings"},
+	"GET /api/backup":                           {Successor: "GET /api/v1/backup"},
+	"POST /api/user/recover":                    {Successor: "POST /api/v1/recoveries"},
+	"POST /api/user/reset/{id}/{recovery}":      {Successor: "POST /api/v1/recoveries/{id}/{recovery}"},
+	"POST /api/installation":                    {Successor: "POST /api/v1/installation"},
+}
+
+// pathVariable matches variables of mux path templates, such as {slug} or {id:[0-9]+}.
+var pathVariable = regexp.MustCompile(`\{(\w+)(:[^}]*)?\}`)
+
+// openAPIPath returns the OpenAPI path of a mux path template, which has no patterns in its variables.
+func openAPIPath(template string) string {
+	return pathVariable.ReplaceAllString(template, "{$1}")
+}
+
+// GenerateOpenAPI returns the OpenAPI document of the routes under /api registered to router.
+// Routes without methods, such as /api itself, are pages and not part of the API.
+func GenerateOpenAPI(router *mux.Router) (OpenAPI, error) {
+	doc := OpenAPI{
+		OpenAPI: "3.0.3",
+		Info: OpenAPIInfo{
+			Title:       "Vertigo",
+			Version:     "v1",
+			Description: "Routes of the API live under `/api/v1/`. Request bodies are JSON and need the header `Content-Type: application/json`. Every error response is an `APIError`. Bodies which cannot be read answer 400 Bad Request, and bodies with invalid fields 422 Unprocessable Entity.",
+		},
+		Paths: make(map[string]map[string]*OpenAPIOperation),
+		Components: OpenAPIComponents{
+			Schemas: map[string]*OpenAPISchema{
+				"Success": {Type: "object", Fields: []string{"success"}, Properties: map[string]*OpenAPISchema{
+					"success": {Type: "string"},
+				}},
+			},
+			SecuritySchemes: map[string]OpenAPISecurityScheme{
+				"session": {Type: "apiKey", In: "cookie", Name: SESSIONNAME, Description: "Session cookie returned by `POST /api/v1/session`."},
+			},
+		},
+	}
+	for _, tag := range apiTags {
+		doc.Tags = append(doc.Tags, tag.OpenAPITag)
+	}
+	openAPISchema(reflect.TypeOf(APIError{}), doc.Components.Schemas)
+	// No route reads or writes the events webhooks receive, but receivers need their schema.
+	openAPISchema(reflect.TypeOf(WebhookEvent{}), doc.Components.Schemas)
+
+	err := router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil || !strings.HasPrefix(template, "/api") {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, method := range methods {
+			doc.addOperation(method, template)
+		}
+		return nil
+	})
+	return doc, err
+}
+
+// addOperation adds the route of method and template to the document if it is in apiDocs.
+func (doc *OpenAPI) addOperation(method, template string) {
+	route := method + " " + openAPIPath(template)
+	apidoc, ok := apiDocs[route]
+	if !ok {
+		return
+	}
+	successor := apidoc.Successor
+	if successor != "" {
+		apidoc = apiDocs[successor]
+		apidoc.Description = "Deprecated, use `" + successor + "` instead."
+	}
+
+	op := &OpenAPIOperation{
+		Method:      method,
+		Path:        openAPIPath(template),
+		Successor:   successor,
+		Tags:        []string{apidoc.Tag},
+		Summary:     apidoc.Summary,
+		Description: apidoc.Description,
+		Deprecated:  successor != "",
+	}
+	for _, match := range pathVariable.FindAllStringSubmatch(template, -1) {
+		param := apiPathParameters[match[1]]
+		param.Name = match[1]
+		param.In = "path"
+		param.Required = true
+		if param.Schema == nil {
+			param.Schema = &OpenAPISchema{Type: "string"}
+		}
+		op.Parameters = append(op.Parameters, param)
+	}
+	op.Parameters = append(op.Parameters, apidoc.Query...)
+	if apidoc.Session {
+		op.Security = []map[string][]string{{"session": {}}}
+	}
+	if apidoc.Request != nil {
+		op.RequestBody = &OpenAPIRequestBody{
+			Required: true,
+			Content: map[string]OpenAPIMediaType{
+				"application/json": {Schema: doc.schema(apidoc.Request), Example: json.RawMessage(apidoc.Example)},
+			},
+		}
+	}
+
+	produces := apidoc.Produces
+	if produces == "" {
+		produces = "application/json"
+	}
+	ok200 := OpenAPIResponse{Description: "OK"}
+	if apidoc.Response != nil {
+		ok200.Content = map[string]OpenAPIMediaType{produces: {Schema: doc.schema(apidoc.Response)}}
+	}
+	op.Responses = map[string]OpenAPIResponse{
+		"200": ok200,
+		"default": {
+			Description: "Error",
+			Content:     map[string]OpenAPIMediaType{"application/json": {Schema: &OpenAPISchema{Ref: "#/components/schemas/APIError"}}},
+		},
+	}
+
+	if doc.Paths[op.Path] == nil {
+		doc.Paths[op.Path] = make(map[string]*OpenAPIOperation)
+	}
+	doc.Paths[op.Path][strings.ToLower(method)] = op
+	doc.Operations = append(doc.Operations, op)
+}
+
+// schema returns the schema of v, which is either an *OpenAPISchema or a value to reflect.
+func (doc *OpenAPI) schema(v interface{}) *OpenAPISchema {
+	if schema, ok := v.(*OpenAPISchema); ok {
+		return schema
+	}
+	return openAPISchema(reflect.TypeOf(v), doc.Components.Schemas)
+}
+
+// openAPISchema returns the schema of values of type t as encoding/json writes them.
+// Structs are added to schemas by their name and referred to. Fields tagged binding:"required" are required.
+func openAPISchema(t reflect.Type, schemas map[string]*OpenAPISchema) *OpenAPISchema {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return openAPISchema(t.Elem(), schemas)
+	case reflect.Bool:
+		return &OpenAPISchema{Type: "boolean"}
+	case reflect.Int64, reflect.Uint64:
+		return &OpenAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &OpenAPISchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &OpenAPISchema{Type: "number"}
+	case reflect.String:
+		return &OpenAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &OpenAPISchema{Type: "string", Format: "byte"}
+		}
+		return &OpenAPISchema{Type: "array", Items: openAPISchema(t.Elem(), schemas)}
+	case reflect.Struct:
+		ref := &OpenAPISchema{Ref: "#/components/schemas/" + t.Name()}
+		if _, exists := schemas[t.Name()]; exists {
+			return ref
+		}
+		schema := &OpenAPISchema{Type: "object", Properties: make(map[string]*OpenAPISchema)}
+		// The schema is added before its fields, so that structs referring to each other are only reflected once.
+		schemas[t.Name()] = schema
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			schema.Properties[name] = openAPISchema(field.Type, schemas)
+			schema.Fields = append(schema.Fields, name)
+			if field.Tag.Get("binding") == "required" {
+				schema.Required = append(schema.Required, name)
+			}
+		}
+		return ref
+	}
+	return &OpenAPISchema{Type: "object"}
+}
+
+// TypeName or schema.TypeName returns the type of the schema for humans, such as "array of Post".
+// Used in "/api/index.tmpl".
+func (schema *OpenAPISchema) TypeName() string {
+	switch {
+	case schema.Ref != "":
+		return strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+	case schema.Items != nil:
+		return "array of " + schema.Items.TypeName()
+	}
+	return schema.Type
+}
+
+// IsRequired or schema.IsRequired reports whether the property name of the schema is required.
+// Used in "/api/index.tmpl".
+func (schema *OpenAPISchema) IsRequired(name string) bool {
+	for _, required := range schema.Required {
+		if required == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Example or op.Example returns the example of the request body indented for display, if there is one.
+// Used in "/api/index.tmpl".
+func (op *OpenAPIOperation) Example() string {
+	if op.RequestBody == nil {
+		return ""
+	}
+	example := op.RequestBody.Content["application/json"].Example
+	if len(example) == 0 {
+		return ""
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, example, "", "\t"); err != nil {
+		return string(example)
+	}
+	return out.String()
+}
+
+// Linkable or op.Linkable reports whether the operation can be opened in a browser,
+// which is the case for GET routes without variables in their path.
+// Used in "/api/index.tmpl".
+func (op *OpenAPIOperation) Linkable() bool {
+	return op.Method == "GET" && !strings.Contains(op.Path, "{")
+}
+
+// apiSection is a section of the /api page: a tag with its schemas and the operations which are not deprecated.
+type apiSection struct {
+	OpenAPITag
+	Schemas    []apiSchema
+	Operations []*OpenAPIOperation
+}
+
+// apiSchema is a named schema of a component.
+type apiSchema struct {
+	Name string
+	*OpenAPISchema
+}
+
+// Sections or doc.Sections returns the sections of the /api page in the order of apiTags.
+// Used in "/api/index.tmpl".
+func (doc OpenAPI) Sections() []apiSection {
+	var sections []apiSection
+	for _, tag := range apiTags {
+		section := apiSection{OpenAPITag: tag.OpenAPITag}
+		for _, name := range tag.schemas {
+			if schema, ok := doc.Components.Schemas[name]; ok {
+				section.Schemas = append(section.Schemas, apiSchema{name, schema})
+			}
+		}
+		for _, op := range doc.Operations {
+			if !op.Deprecated && op.Tags[0] == tag.Name {
+				section.Operations = append(section.Operations, op)
+			}
+		}
+		sections = append(sections, section)
+	}
+	return sections
+}
+
+// Deprecated or doc.Deprecated returns the deprecated operations.
+// Used in "/api/index.tmpl".
+func (doc OpenAPI) Deprecated() []*OpenAPIOperation {
+	var deprecated []*OpenAPIOperation
+	for _, op := range doc.Operations {
+		if op.Deprecated {
+			deprecated = append(deprecated, op)
+		}
+	}
+	return deprecated
+}
+
+// ReadOpenAPI returns a route which displays the OpenAPI document of the routes of router.
+func ReadOpenAPI(router *mux.Router) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		doc, err := GenerateOpenAPI(router)
+		if err != nil {
+			log.Println("openapi: ", err)
+			rend.JSON(w, http.StatusInternalServerError, InternalServerError())
+			return
+		}
+		rend.JSON(w, http.StatusOK, doc)
+	}
+}
+
+// ReadAPIIndex returns a route which renders the /api page from the OpenAPI document of router.
+func ReadAPIIndex(router *mux.Router) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		doc, err := GenerateOpenAPI(router)
+		if err != nil {
+			log.Println("api index: ", err)
+			rend.HTML(w, http.StatusInternalServerError, "error", err)
+			return
+		}
+		rend.HTML(w, http.StatusOK, "api/index", doc)
+	}
+}
@@ -6,4 +6,18 @@ type Page struct {
 	Session *sessions.Session
 	Data    interface{}
 	Err     string
+	// Errors holds the messages of invalid fields of a submitted form by their names.
+	Errors map[string]string
+}
+
+// PostForm is the data of the post editor: the post and the messages of its invalid fields.
+type PostForm struct {
+	Post
+	Errors map[string]string
+}
+
+// SeriesForm is the data of the series editor: the series and the messages of its invalid fields.
+type SeriesForm struct {
+	Series
+	Errors map[string]string
 }
@@ -0,0 +1,233 @@
+// Pages.go contains standalone pages, such as About or Contact. Pages are posts of kind "page":
+// they are written in the same editor and rendered the same way as posts, but they live at top-level
+// URLs such as /about, may be placed below a parent page, as in /about/team, and are listed in the
+// navigation menu instead of the homepage and feeds.
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+// Kinds of posts.
+const (
+	KindPost = "post"
+	KindPage = "page"
+)
+
+// MenuItem is a link of the navigation menu.
+type MenuItem struct {
+	Title    string
+	URL      string
+	Children []MenuItem
+}
+
+// validKind reports whether kind is one of the kinds of posts.
+func validKind(kind string) bool {
+	return kind == KindPost || kind == KindPage
+}
+
+// Path or post.Path returns the URL path of the post. Paths of pages are made of the slugs
+// of their parent pages and their own slug.
+func (post Post) Path() (string, error) {
+	if post.Kind != KindPage {
+		return "/post/" + post.Slug, nil
+	}
+	path := "/" + post.Slug
+	seen := map[int64]bool{post.ID: true}
+	for post.Parent != 0 && !seen[post.Parent] {
+		seen[post.Parent] = true
+		var parent Post
+		query := db.Where(&Post{ID: post.Parent}).First(&parent)
+		if query.Error == gorm.RecordNotFound {
+			break
+		}
+		if query.Error != nil {
+			return path, query.Error
+		}
+		if parent.Kind != KindPage {
+			break
+		}
+		path = "/" + parent.Slug + path
+		post = parent
+	}
+	return path, nil
+}
+
+// slugOfURL returns the slug of the post at rawurl, which is the last segment of its path whether
+// the post is a post or a page below others. Returns "not found" if rawurl is not a URL.
+func slugOfURL(rawurl string) (string, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil || rawurl == "" {
+		return "", errors.New("not found")
+	}
+	return path.Base(strings.TrimSuffix(u.Path, "/")), nil
+}
+
+// postAtURL returns the post at rawurl, see slugOfURL.
+func postAtURL(r *http.Request, rawurl string) (Post, error) {
+	var post Post
+	slug, err := slugOfURL(rawurl)
+	if err != nil {
+		return post, err
+	}
+	post.Slug = slug
+	return post.Get(r)
+}
+
+// checkParent or post.checkParent returns an error if post.Parent cannot be the parent of the post.
+// Only pages have parents, which have to be other pages not below the post itself.
+// The pages are looked up with tx, so that pages created in the same transaction are found.
+func (post Post) checkParent(tx *gorm.DB) error {
+	if post.Parent == 0 {
+		return nil
+	}
+	if post.Kind != KindPage {
+		return errors.New("invalid parent")
+	}
+	seen := make(map[int64]bool)
+	for id := post.Parent; id != 0 && !seen[id]; {
+		if id == post.ID {
+			return errors.New("invalid parent")
+		}
+		seen[id] = true
+		var parent Post
+		query := tx.Where(&Post{ID: id}).First(&parent)
+		if query.Error != nil {
+			if query.Error == gorm.RecordNotFound {
+				return errors.New("invalid parent")
+			}
+			return query.Error
+		}
+		if parent.Kind != KindPage {
+			return errors.New("invalid parent")
+		}
+		id = parent.Parent
+	}
+	return nil
+}
+
+// Pages returns every page, published or not, ordered by title.
+func Pages() ([]Post, error) {
+	pages := make([]Post, 0)
+	query := db.Order("title").Where("kind = ?", KindPage).Find(&pages)
+	if query.Error != nil && query.Error != gorm.RecordNotFound {
+		return pages, query.Error
+	}
+	return pages, nil
+}
+
+// Menu returns the navigation menu, which lists published pages with a menu order greater than zero
+// from the smallest order to the greatest. Pages are listed below their parent if it is in the menu too.
+func Menu() ([]MenuItem, error) {
+	var pages []Post
+	query := db.Where("kind = ? AND published = ? AND menu_order > ?", KindPage, true, 0).Find(&pages)
+	if query.Error != nil && query.Error != gorm.RecordNotFound {
+		return nil, query.Error
+	}
+	sort.Sort(byMenuOrder(pages))
+	listed := make(map[int64]bool)
+	for _, page := range pages {
+		listed[page.ID] = true
+	}
+	children := make(map[int64][]Post)
+	for _, page := range pages {
+		parent := page.Parent
+		if !listed[parent] {
+			parent = 0
+		}
+		children[parent] = append(children[parent], page)
+	}
+	return menuItems(children, 0, make(map[int64]bool))
+}
+
+func menuItems(children map[int64][]Post, parent int64, seen map[int64]bool) ([]MenuItem, error) {
+	var items []MenuItem
+	for _, page := range children[parent] {
+		if seen[page.ID] {
+			continue
+		}
+		seen[page.ID] = true
+		url, err := page.Path()
+		if err != nil {
+			return items, err
+		}
+		below, err := menuItems(children, page.ID, seen)
+		if err != nil {
+			return items, err
+		}
+		items = append(items, MenuItem{Title: page.Title, URL: url, Children: below})
+	}
+	return items, nil
+}
+
+// byMenuOrder sorts pages by their menu order, and pages of the same order by title.
+type byMenuOrder []Post
+
+func (p byMenuOrder) Len() int      { return len(p) }
+func (p byMenuOrder) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+func (p byMenuOrder) Less(i, j int) bool {
+	if p[i].MenuOrder != p[j].MenuOrder {
+		return p[i].MenuOrder < p[j].MenuOrder
+	}
+	return p[i].Title < p[j].Title
+}
+
+// ReadPage is a route which displays the page at the requested path, such as /about or /about/team.
+// The page is found by the last slug of the path. Other paths to it, such as the ones left behind
+// when a page is moved below another one, redirect to the current path with 301 Moved Permanently.
+func ReadPage(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(mux.Vars(r)["path"], "/")
+	slugs := strings.Split(path, "/")
+
+	var page Post
+	page.Slug = slugs[len(slugs)-1]
+	page, err := page.Get(r)
+	if err != nil {
+		if err.Error() != "not found" {
+			log.Println("readpage: ", err)
+			rend.JSON(w, http.StatusInternalServerError, InternalServerError())
+			return
+		}
+		// The page may have been renamed, in which case the old slug redirects to the new one.
+		if page, err = page.Redirected(); err != nil {
+			rend.JSON(w, http.StatusNotFound, NotFound())
+			return
+		}
+	}
+	if page.Kind != KindPage || !page.Visible(r) {
+		rend.JSON(w, http.StatusNotFound, NotFound())
+		return
+	}
+
+	canonical, err := page.Path()
+	if err != nil {
+		log.Println("readpage path: ", err)
+		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
+		return
+	}
+	if canonical != "/"+path {
+		if r.URL.RawQuery != "" {
+			canonical += "?" + r.URL.RawQuery
+		}
+		http.Redirect(w, r, canonical, http.StatusMovedPermanently)
+		return
+	}
+
+	if page.Published {
+		go page.Increment(r)
+	} else {
+		// Previews must not end up in search engines or shared caches.
+		w.Header().Set("X-Robots-Tag", "noindex")
+		w.Header().Set("Cache-Control", "private, no-store")
+	}
+	rend.HTML(w, http.StatusOK, "post/display", page)
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"net/http"
+)
+
+/*
+This is an autogenerated file by autobindings
+*/
+
+import (
+	"github.com/mholt/binding"
+)
+
+func (p *PasswordReset) FieldMap() binding.FieldMap {
+	return binding.FieldMap{
+		&p.Password: "password",
+	}
+}
+
+func (p *PasswordReset) Validate(req *http.Request, errs binding.Errors) binding.Errors {
+	errs = requireField(errs, "password", p.Password, "Password is required.")
+	if p.Password != "" && !strongPassword(p.Password) {
+		errs.Add([]string{"password"}, ErrWeakPassword, "Password must be at least 8 characters long and mix letters with digits or other characters.")
+	}
+    return errs
+}
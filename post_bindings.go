@@ -0,0 +1,36 @@
+package main
+
+import (
+	//"log"
+	"net/http"
+)
+
+/*
+This is an autogenerated file by autobindings
+*/
+
+import (
+	"github.com/mholt/binding"
+)
+
+func (p *Post) FieldMap() binding.FieldMap {
+	return binding.FieldMap{
+		&p.Content:   "content",
+		&p.Format:    "format",
+		&p.Kind:      "kind",
+		&p.Markdown:  "markdown",
+		&p.MenuOrder: "menuorder",
+		&p.Parent:    "parent",
+		&p.Part:      "part",
+		&p.Series:    "series",
+		&p.Slug:      "slug",
+		&p.Tags:      "tags",
+		&p.Title:     "title",
+	}
+}
+
+func (p *Post) Validate(req *http.Request, errs binding.Errors) binding.Errors {
+	//log.Println("bindingvalidate: ", p)
+	errs = limitLength(errs, "title", p.Title, maxTitleLength, "Title is too long.")
+    return errs
+}
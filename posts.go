@@ -14,30 +14,38 @@ import (
 	"github.com/gorilla/mux"
 
 	_ "github.com/go-sql-driver/mysql"
-	"github.com/gosimple/slug"
 	"github.com/jinzhu/gorm"
 	"github.com/kennygrant/sanitize"
 	_ "github.com/lib/pq"
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/mholt/binding"
 	"github.com/russross/blackfriday"
 )
 
 type Post struct {
-	ID        int64  `json:"id" gorm:"primary_key:yes"`
-	Title     string `json:"title" form:"title" binding:"required"`
-	Content   string `json:"content" form:"content" sql:"type:text"`
-	Markdown  string `json:"markdown" form:"markdown" sql:"type:text"`
-	Tags      string `json:"tags" form:"tags" sql:"type:text"`
-	Date      int64  `json:"date"`
-	Slug      string `json:"slug"`
-	Author    int64  `json:"author"`
-	Excerpt   string `json:"excerpt"`
-	Viewcount uint   `json:"viewcount"`
-	Published bool   `json:"-"`
+	ID          int64  `json:"id" gorm:"primary_key:yes"`
+	Title       string `json:"title" form:"title" binding:"required"`
+	Content     string `json:"content" form:"content" sql:"type:text"`
+	Markdown    string `json:"markdown" form:"markdown" sql:"type:text"`
+	Format      string `json:"format" form:"format"`
+	Tags        string `json:"tags" form:"tags" sql:"type:text"`
+	Date        int64  `json:"date"`
+	Slug        string `json:"slug" form:"slug"`
+	Kind        string `json:"kind" form:"kind"`
+	Parent      int64  `json:"parent" form:"parent"`
+	MenuOrder   int    `json:"menuorder" form:"menuorder"`
+	Series      int64  `json:"series" form:"series"`
+	Part        int    `json:"part" form:"part"`
+	Author      int64  `json:"author"`
+	Excerpt     string `json:"excerpt"`
+	ReadingTime int    `json:"readingtime"`
+	Viewcount   uint   `json:"viewcount"`
+	Published   bool   `json:"-"`
 }
 
 // Search struct is basically just a type check to make sure people don't add anything nasty to
 // on-site search queries.
+//go:generate autobindings search
 type Search struct {
 	Query string `json:"query" form:"query" binding:"required"`
 	Score float64
@@ -48,18 +56,29 @@ type Search struct {
 // Normally you'd use this function as your "/" route.
 func Homepage(w http.ResponseWriter, r *http.Request) {
 	if Settings.Firstrun {
-		rend.HTML(w, http.StatusOK, "installation/wizard", nil)
+		rend.HTML(w, http.StatusOK, "installation/wizard", Page{Data: Vertigo{Recovery: true}})
 		return
 	}
 	var post Post
 	posts, err := post.GetAll(r)
 	if err != nil {
 		log.Println("homepage err: ", err)
-		rend.JSON(w, http.StatusInternalServerError, map[string]interface{}{"error": "Internal server error"})
+		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
 		return
 	}
 	SessionGetValue(r, "id")
-	rend.HTML(w, http.StatusOK, "home", posts)
+	rend.HTML(w, http.StatusOK, "home", postsOfKind(posts, KindPost))
+}
+
+// postsOfKind returns the ones of posts which are of kind.
+func postsOfKind(posts []Post, kind string) []Post {
+	filtered := make([]Post, 0)
+	for _, post := range posts {
+		if post.Kind == kind {
+			filtered = append(filtered, post)
+		}
+	}
+	return filtered
 }
 
 // Excerpt generates 15 word excerpt from given input.
@@ -78,21 +97,44 @@ func Excerpt(input string) string {
 
 // SearchPost is a route which returns all posts and aggregates the ones which contain
 // the POSTed search query in either Title or Content field.
+// JSON call accepts query parameter fields, see fields.go.
 func SearchPost(w http.ResponseWriter, r *http.Request) {
-	var search Search
-
-	r.ParseForm()
-	search.Query = r.PostFormValue("query")
+	var selection Selection
+	if root(r) == "api" {
+		var err error
+		if selection, err = ParseSelection(r, Post{}, nil); err != nil {
+			rend.JSON(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	input := new(Search)
+	if errs := binding.Bind(r, input); len(errs) > 0 {
+		switch root(r) {
+		case "api":
+			rend.JSON(w, validationStatus(errs), ValidationError(errs))
+		case "post":
+			rend.HTML(w, validationStatus(errs), "search", []Post{})
+		}
+		return
+	}
 
+	var search Search
+	search.Query = input.Query
 	search, err := search.Get(r)
 	if err != nil {
 		log.Println("search post: ", err)
-		rend.JSON(w, http.StatusInternalServerError, map[string]interface{}{"error": "Internal server error"})
+		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
 		return
 	}
 	switch root(r) {
 	case "api":
-		rend.JSON(w, http.StatusOK, search.Posts)
+		selected, err := selection.Posts(search.Posts)
+		if err != nil {
+			log.Println("search post select: ", err)
+			rend.JSON(w, http.StatusInternalServerError, InternalServerError())
+			return
+		}
+		rend.JSON(w, http.StatusOK, selected)
 		return
 	case "post":
 		rend.HTML(w, http.StatusOK, "search", search.Posts)
@@ -115,13 +157,8 @@ func (search Search) Get(r *http.Request) (Search, error) {
 		if post.Published {
 			// posts are searched for a match in both content and title, so here
 			// we declare two scanners for them
-			content := bufio.NewScanner(strings.NewReader(post.Content))
+			content := bufio.NewScanner(strings.NewReader(post.SearchText()))
 			title := bufio.NewScanner(strings.NewReader(post.Title))
-			// Blackfriday makes smartypants corrections some characters, which break the search
-			if Settings.Markdown {
-				content = bufio.NewScanner(strings.NewReader(post.Markdown))
-				title = bufio.NewScanner(strings.NewReader(post.Title))
-			}
 			content.Split(bufio.ScanWords)
 			title.Split(bufio.ScanWords)
 			// content is scanned trough Jaro-Winkler distance with
@@ -153,23 +190,80 @@ func (search Search) Get(r *http.Request) (Search, error) {
 	return search, nil
 }
 
+// NewPost is a route which displays the editor for a new post.
+// Query parameter format chooses the editor, by default it is the one of the format set in settings.
+// Query parameter kind=page starts a page instead of a post.
+func NewPost(w http.ResponseWriter, r *http.Request) {
+	var post Post
+	post.Format = r.URL.Query().Get("format")
+	if !validFormat(post.Format) {
+		post.Format = defaultFormat()
+	}
+	post.Kind = r.URL.Query().Get("kind")
+	if !validKind(post.Kind) {
+		post.Kind = KindPost
+	}
+	// The author tells the editor which series the post can be added to.
+	var user User
+	if user, err := user.Session(r); err == nil {
+		post.Author = user.ID
+	}
+	rend.HTML(w, http.StatusOK, "post/new", PostForm{Post: post})
+}
+
 // CreatePost is a route which creates a new post according to the posted data.
 // API response contains the created post object and normal request redirects to "/user" page.
 // Does not publish the post automatically. See PublishPost for more.
 func CreatePost(w http.ResponseWriter, r *http.Request) {
-	var post Post
+	input := new(Post)
+	errs := binding.Bind(r, input)
+	// Title is only required here, as posts being updated keep the title they have.
+	errs = requireField(errs, "title", input.Title, "Title is required.")
+	if len(errs) > 0 {
+		switch root(r) {
+		case "api":
+			rend.JSON(w, validationStatus(errs), ValidationError(errs))
+		case "post":
+			var user User
+			if user, err := user.Session(r); err == nil {
+				input.Author = user.ID
+			}
+			if !validFormat(input.Format) {
+				input.Format = defaultFormat()
+			}
+			if !validKind(input.Kind) {
+				input.Kind = KindPost
+			}
+			rend.HTML(w, validationStatus(errs), "post/new", PostForm{Post: *input, Errors: fieldErrors(errs)})
+		}
+		return
+	}
 
-	r.ParseForm()
-	post.Title = r.PostFormValue("title")
-	post.Markdown = r.PostFormValue("markdown")
-	post.Content = r.PostFormValue("content")
+	// JSON binding decodes straight into the struct, so only the editable fields are copied.
+	var post Post
+	post.Title = input.Title
+	post.Markdown = input.Markdown
+	post.Content = input.Content
+	post.Format = input.Format
+	post.Tags = input.Tags
+	post.Slug = input.Slug
+	post.Kind = input.Kind
+	post.Parent = input.Parent
+	post.MenuOrder = input.MenuOrder
+	post.Series = input.Series
+	post.Part = input.Part
 
 	post, err := post.Insert(r)
 	if err != nil {
 		log.Println("create post: ", err)
-		rend.JSON(w, http.StatusInternalServerError, map[string]interface{}{"error": "Internal server error"})
+		status, apierr := postError(err)
+		rend.JSON(w, status, apierr)
 		return
 	}
+	// The post is saved, so the autosaved draft of the editor is no longer needed.
+	if err := deleteDraft(db, post.Author, 0); err != nil {
+		log.Println("create post draft: ", err)
+	}
 
 	switch root(r) {
 	case "api":
@@ -181,34 +275,103 @@ func CreatePost(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// PreviewPost is a route which renders the posted title and source the same way Post.Insert would,
+// without saving anything. Returns the rendered post object. Requires session cookie.
+func PreviewPost(w http.ResponseWriter, r *http.Request) {
+	input := new(Post)
+	if errs := binding.Bind(r, input); len(errs) > 0 {
+		rend.JSON(w, validationStatus(errs), ValidationError(errs))
+		return
+	}
+
+	var user User
+	user, err := user.Session(r)
+	if err != nil {
+		log.Println("previewpost session: ", err)
+		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
+		return
+	}
+
+	var post Post
+	post.Title = input.Title
+	post.Markdown = input.Markdown
+	post.Content = input.Content
+	post.Format = input.Format
+	post.Tags = input.Tags
+	if post.Format == "" {
+		post.Format = defaultFormat()
+	}
+	post.Author = user.ID
+	post, err = post.Render()
+	if err != nil {
+		if err.Error() == "unsupported format" {
+			rend.JSON(w, http.StatusBadRequest, BadRequest("invalid_format", "Format must be markdown, html or plain."))
+			return
+		}
+		log.Println("previewpost render: ", err)
+		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
+		return
+	}
+	rend.JSON(w, http.StatusOK, post)
+}
+
 // ReadPosts is a route which returns all posts without merged owner data (although the object does include author field)
 // Not available on frontend, so therefore it only returns a JSON response.
+// Query parameter format=html or format=markdown limits the posts to a single representation of their content.
+// Query parameter kind=page lists pages instead of posts.
+// Query parameter fields, such as fields=id,title,slug, returns only the listed fields of each post.
 func ReadPosts(w http.ResponseWriter, r *http.Request) {
 	var post Post
 	published := make([]Post, 0)
+	kind := r.URL.Query().Get("kind")
+	if kind == "" {
+		kind = KindPost
+	}
+	if !validKind(kind) {
+		rend.JSON(w, http.StatusBadRequest, BadRequest("invalid_kind", "Kind must be post or page."))
+		return
+	}
+	selection, err := ParseSelection(r, post, nil)
+	if err != nil {
+		rend.JSON(w, http.StatusBadRequest, err)
+		return
+	}
 	posts, err := post.GetAll(r)
 	if err != nil {
 		log.Println("readposts: ", err)
-		rend.JSON(w, http.StatusInternalServerError, map[string]interface{}{"error": "Internal server error"})
+		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
 		return
 	}
-	for _, post := range posts {
+	for _, post := range postsOfKind(posts, kind) {
 		if post.Published {
+			post, err = postFormat(post, r.URL.Query().Get("format"))
+			if err != nil {
+				rend.JSON(w, http.StatusBadRequest, BadRequest("invalid_format", "Format must be either html or markdown."))
+				return
+			}
 			published = append(published, post)
 		}
 	}
-	rend.JSON(w, http.StatusOK, published)
+	selected, err := selection.Posts(published)
+	if err != nil {
+		log.Println("readposts select: ", err)
+		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
+		return
+	}
+	rend.JSON(w, http.StatusOK, selected)
 }
 
 // ReadPost is a route which returns post with given post.Slug.
 // Returns post data on JSON call and displays a formatted page on frontend.
+// Unpublished posts are only shown to their author, or with a preview token in query parameter preview.
+// JSON call accepts the same format and fields query parameters as ReadPosts.
 func ReadPost(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	slug := vars["slug"]
 
 	var post Post
 	if slug == "new" {
-		rend.JSON(w, http.StatusBadRequest, map[string]interface{}{"error": "There can't be a post called 'new'."})
+		rend.JSON(w, http.StatusBadRequest, BadRequest("reserved_slug", "There can't be a post called 'new'."))
 		return
 	}
 	post.Slug = slug
@@ -216,16 +379,73 @@ func ReadPost(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		//log.Println("readpost: ", err)
 		if err.Error() == "not found" {
+			// The post may have been renamed, in which case the old slug redirects to the new one.
+			// The query is kept, so that preview links keep working too.
+			if post, err := post.Redirected(); err == nil && post.Visible(r) {
+				query := ""
+				if r.URL.RawQuery != "" {
+					query = "?" + r.URL.RawQuery
+				}
+				switch root(r) {
+				case "api":
+					http.Redirect(w, r, apiPostURL(r, post.Slug)+query, http.StatusMovedPermanently)
+					return
+				case "post":
+					http.Redirect(w, r, "/post/"+post.Slug+query, http.StatusMovedPermanently)
+					return
+				}
+			}
 			rend.JSON(w, http.StatusNotFound, NotFound())
 			return
 		}
-		rend.JSON(w, http.StatusInternalServerError, map[string]interface{}{"error": "Internal server error"})
+		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
 		return
 	}
-	go post.Increment(r)
+	// Unpublished posts do not exist for anyone else than their author and holders of a preview link.
+	if !post.Visible(r) {
+		rend.JSON(w, http.StatusNotFound, NotFound())
+		return
+	}
+	// Pages are displayed at their own path, see pages.go.
+	if post.Kind == KindPage && root(r) == "post" {
+		path, err := post.Path()
+		if err != nil {
+			log.Println("readpost path: ", err)
+			rend.JSON(w, http.StatusInternalServerError, InternalServerError())
+			return
+		}
+		if r.URL.RawQuery != "" {
+			path += "?" + r.URL.RawQuery
+		}
+		http.Redirect(w, r, path, http.StatusMovedPermanently)
+		return
+	}
+	if post.Published {
+		go post.Increment(r)
+	} else {
+		// Previews must not end up in search engines or shared caches.
+		w.Header().Set("X-Robots-Tag", "noindex")
+		w.Header().Set("Cache-Control", "private, no-store")
+	}
 	switch root(r) {
 	case "api":
-		rend.JSON(w, http.StatusOK, post)
+		post, err = postFormat(post, r.URL.Query().Get("format"))
+		if err != nil {
+			rend.JSON(w, http.StatusBadRequest, BadRequest("invalid_format", "Format must be either html or markdown."))
+			return
+		}
+		selection, err := ParseSelection(r, post, nil)
+		if err != nil {
+			rend.JSON(w, http.StatusBadRequest, err)
+			return
+		}
+		selected, err := selection.Post(post)
+		if err != nil {
+			log.Println("readpost select: ", err)
+			rend.JSON(w, http.StatusInternalServerError, InternalServerError())
+			return
+		}
+		rend.JSON(w, http.StatusOK, selected)
 		return
 	case "post":
 		rend.HTML(w, http.StatusOK, "post/display", post)
@@ -245,13 +465,16 @@ func EditPost(w http.ResponseWriter, r *http.Request) {
 	post, err := post.Get(r)
 	if err != nil {
 		log.Println("editpost: ", err)
-		rend.JSON(w, http.StatusInternalServerError, map[string]interface{}{"error": "Internal server error"})
+		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
 		return
 	}
-	rend.HTML(w, http.StatusOK, "post/edit", post)
+	rend.HTML(w, http.StatusOK, "post/edit", PostForm{Post: post})
 }
 
-// UpdatePost is a route which updates a post defined by martini parameter "title" with posted data.
+// UpdatePost is a route which updates a post defined by mux parameter "slug" with posted data.
+// Fields which are left empty keep their current values. A changed slug leaves the old one redirecting
+// to the post. Drafts follow their title when no slug is given, published posts keep their address.
+// A changed format converts the posted source from the previous format into the new one.
 // Requires session cookie. JSON request returns the updated post object, frontend call will redirect to "/user".
 func UpdatePost(w http.ResponseWriter, r *http.Request) {
 	var post Post
@@ -266,7 +489,7 @@ func UpdatePost(w http.ResponseWriter, r *http.Request) {
 			rend.JSON(w, http.StatusNotFound, NotFound())
 			return
 		}
-		rend.JSON(w, http.StatusInternalServerError, map[string]interface{}{"error": "Internal server error"})
+		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
 		return
 	}
 
@@ -274,19 +497,35 @@ func UpdatePost(w http.ResponseWriter, r *http.Request) {
 	user, err = user.Session(r)
 	if err != nil {
 		log.Println("updatepost session: ", err)
-		rend.JSON(w, http.StatusInternalServerError, map[string]interface{}{"error": "Internal server error"})
+		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
 		return
 	}
 
-	if post.Author == user.ID {
-		post, err = post.Update(r)
-		if err != nil {
-			log.Println("updatepost post: ", err)
-			rend.JSON(w, http.StatusInternalServerError, map[string]interface{}{"error": "Internal server error"})
-			return
+	if post.Author != user.ID {
+		rend.JSON(w, http.StatusUnauthorized, Unauthorized())
+		return
+	}
+	input := new(Post)
+	if errs := binding.Bind(r, input); len(errs) > 0 {
+		switch root(r) {
+		case "api":
+			rend.JSON(w, validationStatus(errs), ValidationError(errs))
+		case "post":
+			if input.Title != "" {
+				post.Title = input.Title
+			}
+			if input.Markdown != "" {
+				post.Markdown = input.Markdown
+			}
+			rend.HTML(w, validationStatus(errs), "post/edit", PostForm{Post: post, Errors: fieldErrors(errs)})
 		}
-	} else {
-		rend.JSON(w, http.StatusUnauthorized, map[string]interface{}{"error": "Unauthorized"})
+		return
+	}
+	post, err = post.Edit(r, *input)
+	if err != nil {
+		log.Println("updatepost: ", err)
+		status, apierr := postError(err)
+		rend.JSON(w, status, apierr)
 		return
 	}
 
@@ -316,28 +555,15 @@ func PublishPost(w http.ResponseWriter, r *http.Request) {
 			rend.JSON(w, http.StatusNotFound, NotFound())
 			return
 		}
-		rend.JSON(w, http.StatusInternalServerError, map[string]interface{}{"error": "Internal server error"})
+		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
 		return
 	}
 
-	var user User
-	user, err = user.Session(r)
+	post, err = post.Publish(r)
 	if err != nil {
-		log.Println("publishpost user: ", err)
-		rend.JSON(w, http.StatusInternalServerError, map[string]interface{}{"error": "Internal server error"})
-		return
-	}
-
-	if post.Author == user.ID {
-		post.Published = true
-		post, err = post.Update(r)
-		if err != nil {
-			log.Println("publishpost post: ", err)
-			rend.JSON(w, http.StatusInternalServerError, map[string]interface{}{"error": "Internal server error"})
-			return
-		}
-	} else {
-		rend.JSON(w, http.StatusUnauthorized, map[string]interface{}{"error": "Unauthorized"})
+		log.Println("publishpost: ", err)
+		status, apierr := postError(err)
+		rend.JSON(w, status, apierr)
 		return
 	}
 
@@ -368,7 +594,7 @@ func UnpublishPost(w http.ResponseWriter, r *http.Request) {
 			rend.JSON(w, http.StatusNotFound, NotFound())
 			return
 		}
-		rend.JSON(w, http.StatusInternalServerError, map[string]interface{}{"error": "Internal server error"})
+		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
 		return
 	}
 
@@ -376,7 +602,7 @@ func UnpublishPost(w http.ResponseWriter, r *http.Request) {
 	user, err = user.Session(r)
 	if err != nil {
 		log.Println("unpublishpost user: ", err)
-		rend.JSON(w, http.StatusInternalServerError, map[string]interface{}{"error": "Internal server error"})
+		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
 		return
 	}
 
@@ -384,11 +610,11 @@ func UnpublishPost(w http.ResponseWriter, r *http.Request) {
 		err = post.Unpublish(r)
 		if err != nil {
 			log.Println("unpublishpost post: ", err)
-			rend.JSON(w, http.StatusInternalServerError, map[string]interface{}{"error": "Internal server error"})
+			rend.JSON(w, http.StatusInternalServerError, InternalServerError())
 			return
 		}
 	} else {
-		rend.JSON(w, http.StatusUnauthorized, map[string]interface{}{"error": "Unauthorized"})
+		rend.JSON(w, http.StatusUnauthorized, Unauthorized())
 		return
 	}
 
@@ -419,7 +645,7 @@ func DeletePost(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		log.Println("deletepost post: ", err)
-		rend.JSON(w, http.StatusInternalServerError, map[string]interface{}{"error": "Internal server error"})
+		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
 		return
 	}
 
@@ -427,10 +653,10 @@ func DeletePost(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		log.Println("deletepost delete: ", err)
 		if err.Error() == "unauthorized" {
-			rend.JSON(w, http.StatusUnauthorized, map[string]interface{}{"error": "Unauthorized"})
+			rend.JSON(w, http.StatusUnauthorized, Unauthorized())
 			return
 		}
-		rend.JSON(w, http.StatusInternalServerError, map[string]interface{}{"error": "Internal server error"})
+		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
 		return
 	}
 	switch root(r) {
@@ -445,7 +671,10 @@ func DeletePost(w http.ResponseWriter, r *http.Request) {
 
 // Insert or post.Insert inserts Post object into database.
 // Requires active session cookie
-// Fills post.Author, post.Date, post.Excerpt, post.Slug and post.Published automatically.
+// Fills post.Author, post.Date, post.Content, post.Excerpt and post.Published automatically.
+// post.Format defaults to Markdown or HTML depending on whether Markdown is enabled in settings.
+// post.Slug is made of the given slug or, if there is none, the title, and suffixed with -2, -3 and so on
+// if another post already uses it. A post added to a series without a part number becomes its last part.
 // Returns Post and error object.
 func (post Post) Insert(r *http.Request) (Post, error) {
 	var user User
@@ -453,16 +682,38 @@ func (post Post) Insert(r *http.Request) (Post, error) {
 	if err != nil {
 		return post, err
 	}
-	// if post.Content is empty, the user has used Markdown editor
-	if Settings.Markdown {
-		post.Content = string(blackfriday.MarkdownCommon([]byte(cleanup(post.Markdown))))
-	} else {
-		post.Content = cleanup(post.Content)
+	if post.Format == "" {
+		post.Format = defaultFormat()
+	}
+	if post.Kind == "" {
+		post.Kind = KindPost
+	}
+	if !validKind(post.Kind) {
+		return post, errors.New("unsupported kind")
+	}
+	if post.Kind == KindPost {
+		post.Parent = 0
+		post.MenuOrder = 0
+	}
+	if err := post.checkParent(db); err != nil {
+		return post, err
 	}
 	post.Author = user.ID
+	if post, err = post.checkSeries(); err != nil {
+		return post, err
+	}
+	post, err = post.Render()
+	if err != nil {
+		return post, err
+	}
 	post.Date = time.Now().Unix()
-	post.Excerpt = Excerpt(post.Content)
-	post.Slug = slug.Make(post.Title)
+	if post.Slug == "" {
+		post.Slug = post.Title
+	}
+	post.Slug, err = uniqueSlug(post.Slug, 0)
+	if err != nil {
+		return post, err
+	}
 	post.Published = false
 	query := db.Create(&post)
 	if query.Error != nil {
@@ -472,6 +723,137 @@ func (post Post) Insert(r *http.Request) (Post, error) {
 	return post, nil
 }
 
+// postError returns the status and JSON response for an error of Post.Insert, Post.Edit or Post.Publish.
+func postError(err error) (int, APIError) {
+	switch err.Error() {
+	case "unsupported format":
+		return http.StatusBadRequest, BadRequest("invalid_format", "Format must be markdown, html or plain.")
+	case "unsupported kind":
+		return http.StatusBadRequest, BadRequest("invalid_kind", "Kind must be post or page.")
+	case "invalid parent":
+		return http.StatusBadRequest, BadRequest("invalid_parent", "Parent must be another page which is not below this one.")
+	case "invalid series", "invalid part":
+		return http.StatusBadRequest, seriesBadRequest(err)
+	case "unauthorized":
+		return http.StatusUnauthorized, Unauthorized()
+	case "not found":
+		return http.StatusNotFound, NotFound()
+	}
+	return http.StatusInternalServerError, InternalServerError()
+}
+
+// Edit or post.Edit updates the post with the fields of input which are not empty, as described
+// by UpdatePost, and discards the autosaved draft of the post. Only the author of the post may edit it.
+// Returns the updated post and an error, such as "unauthorized" or "unsupported format", see postError.
+func (post Post) Edit(r *http.Request, input Post) (Post, error) {
+	var user User
+	user, err := user.Session(r)
+	if err != nil {
+		return post, err
+	}
+	if post.Author != user.ID {
+		return post, errors.New("unauthorized")
+	}
+	previous := post.Content
+	newslug := input.Slug
+	if newslug == "" && !post.Published && input.Title != "" && input.Title != post.Title {
+		newslug = input.Title
+	}
+	if input.Title != "" {
+		post.Title = input.Title
+	}
+	if input.Markdown != "" {
+		post.Markdown = input.Markdown
+	}
+	if input.Content != "" {
+		post.Content = input.Content
+	}
+	if input.Tags != "" {
+		post.Tags = input.Tags
+	}
+	if input.Format != "" {
+		if post, err = post.Render(); err == nil {
+			post, err = post.Convert(input.Format)
+		}
+		if err != nil {
+			return post, err
+		}
+	}
+	// Kind, parent, menu order, series and part place the post on the site, so they are given together.
+	if input.Kind != "" {
+		if !validKind(input.Kind) {
+			return post, errors.New("unsupported kind")
+		}
+		post.Kind = input.Kind
+		post.Parent = input.Parent
+		post.MenuOrder = input.MenuOrder
+		// Parts keep their number within the same series unless a new one is given.
+		if input.Series != post.Series || input.Part != 0 {
+			post.Part = input.Part
+		}
+		post.Series = input.Series
+		if post.Kind == KindPost {
+			post.Parent = 0
+			post.MenuOrder = 0
+		}
+		if err := post.checkParent(db); err != nil {
+			return post, err
+		}
+		if post, err = post.checkSeries(); err != nil {
+			return post, err
+		}
+		// Update leaves the placement alone, and Updates with a struct would skip the zero values
+		// which clear it, so it is written here on its own.
+		placement := map[string]interface{}{"parent": post.Parent, "menu_order": post.MenuOrder, "series": post.Series, "part": post.Part}
+		if err := db.Model(&post).Updates(placement).Error; err != nil {
+			return post, err
+		}
+		// Pages live at the top level, where some slugs are taken by routes.
+		if newslug == "" && post.Kind == KindPage && reservedSlugs[post.Slug] {
+			newslug = post.Slug
+		}
+	}
+	if newslug != "" {
+		if post, err = post.Rename(newslug); err != nil {
+			return post, err
+		}
+	}
+	if post, err = post.Update(r); err != nil {
+		return post, err
+	}
+	if err := deleteDraft(db, user.ID, post.ID); err != nil {
+		log.Println("edit post draft: ", err)
+	}
+	TriggerWebhooks(EventPostUpdated, post)
+	SendWebmentions(post, previous)
+	if post.Published {
+		Federate(ActivityUpdate, post)
+	}
+	return post, nil
+}
+
+// Publish or post.Publish publishes a post, making it appear on frontpage and search.
+// Only the author of the post may publish it.
+// Returns the published post and an error, "unauthorized" if the post is someone else's.
+func (post Post) Publish(r *http.Request) (Post, error) {
+	var user User
+	user, err := user.Session(r)
+	if err != nil {
+		return post, err
+	}
+	if post.Author != user.ID {
+		return post, errors.New("unauthorized")
+	}
+	post.Published = true
+	if post, err = post.Update(r); err != nil {
+		return post, err
+	}
+	TriggerWebhooks(EventPostPublished, post)
+	SendWebmentions(post, "")
+	Federate(ActivityCreate, post)
+	return post, nil
+}
+
 // Get or post.Get returns post according to given post.Slug.
 // Requires db session as a parameter.
 // Returns Post and error object.
@@ -501,6 +883,40 @@ func (post Post) GetAll(r *http.Request) ([]Post, error) {
 	return posts, nil
 }
 
+// Markdown is rendered with the flags and extensions of blackfriday.MarkdownCommon,
+// plus footnotes and IDs for every heading.
+const (
+	markdownHTMLFlags = blackfriday.HTML_USE_XHTML |
+		blackfriday.HTML_USE_SMARTYPANTS |
+		blackfriday.HTML_SMARTYPANTS_FRACTIONS |
+		blackfriday.HTML_SMARTYPANTS_DASHES |
+		blackfriday.HTML_SMARTYPANTS_LATEX_DASHES |
+		blackfriday.HTML_FOOTNOTE_RETURN_LINKS
+
+	markdownExtensions = blackfriday.EXTENSION_NO_INTRA_EMPHASIS |
+		blackfriday.EXTENSION_TABLES |
+		blackfriday.EXTENSION_FENCED_CODE |
+		blackfriday.EXTENSION_AUTOLINK |
+		blackfriday.EXTENSION_STRIKETHROUGH |
+		blackfriday.EXTENSION_SPACE_HEADERS |
+		blackfriday.EXTENSION_HEADER_IDS |
+		blackfriday.EXTENSION_AUTO_HEADER_IDS |
+		blackfriday.EXTENSION_FOOTNOTES |
+		blackfriday.EXTENSION_BACKSLASH_LINE_BREAK |
+		blackfriday.EXTENSION_DEFINITION_LISTS
+)
+
+// renderMarkdown renders Markdown source of a post into HTML, highlighting fenced code blocks.
+// Headings get IDs generated from their text, unless given one with {#id}, so links to them
+// keep working as long as the heading stays the same.
+// Everything that turns Markdown into post content, including importers, goes through here.
+func renderMarkdown(markdown string) string {
+	renderer := highlightRenderer{blackfriday.HtmlRendererWithParameters(markdownHTMLFlags, "", "", blackfriday.HtmlRendererParameters{
+		FootnoteReturnLinkContents: "&#8617;",
+	})}
+	return string(blackfriday.Markdown([]byte(markdown), renderer, markdownExtensions))
+}
+
 // This function brings sanity to contenteditable. It mainly removes unnecessary <br> lines from the input source.
 // Part of the sanitize package, but this one fixes issues with <code> blocks having &nbsp;'s all over.
 // https://github.com/kennygrant/sanitize/blob/master/sanitize.go#L106
@@ -519,21 +935,20 @@ func cleanup(s string) string {
 }
 
 // Update or post.Update updates parameter "entry" with data given in parameter "post".
+// Content is rendered again according to post.Format.
 // Requires active session cookie.
 // Returns updated Post object and an error object.
 func (post Post) Update(r *http.Request) (Post, error) {
 	// entry is required apparently, only way i can get it to actually update.
-	var entry = post
-	if Settings.Markdown {
-		entry.Markdown = cleanup(post.Markdown)
-		entry.Content = string(blackfriday.MarkdownCommon([]byte(post.Markdown)))
-	} else {
-		entry.Content = cleanup(post.Content)
-		// this closure would need a call to convert HTML to Markdown
-		// see https://github.com/9uuso/vertigo/issues/7
-		// entry.Markdown = Markdown of entry.Content
+	entry, err := post.Render()
+	if err != nil {
+		return post, err
 	}
-	entry.Excerpt = Excerpt(post.Content)
+	// The placement of a page or series part is only written by Edit when it is given, so that
+	// saving a stale copy of the post cannot move it. Updates skips the zero values.
+	entry.Parent, entry.MenuOrder, entry.Series, entry.Part = 0, 0, 0, 0
+	// Views are counted by Increment alone.
+	entry.Viewcount = 0
 	query := db.Where(&Post{Slug: post.Slug}).First(&post).Updates(entry)
 	if query.Error != nil {
 		if query.Error == gorm.RecordNotFound {
@@ -560,6 +975,9 @@ func (post Post) Unpublish(r *http.Request) error {
 			}
 			return query.Error
 		}
+		post.Published = false
+		TriggerWebhooks(EventPostUnpublished, post)
+		Federate(ActivityDelete, post)
 	} else {
 		return errors.New("unauthorized")
 	}
@@ -575,26 +993,50 @@ func (post Post) Delete(r *http.Request) error {
 	if err != nil {
 		return err
 	}
-	if post.Author == user.ID {
-		query := db.Where(&Post{Slug: post.Slug}).Delete(&post)
-		if query.Error != nil {
-			if query.Error == gorm.RecordNotFound {
-				return errors.New("not found")
-			}
-			return query.Error
-		}
-	} else {
+	if post.Author != user.ID {
 		return errors.New("unauthorized")
 	}
+	return deletePost(post)
+}
+
+// deletePost deletes post with its comments, redirects, drafts, preview links and webmentions, and tells
+// webhooks and, if it was published, followers of its author. Post.Delete and `vertigo post delete` share it.
+func deletePost(post Post) error {
+	query := db.Where(&Post{Slug: post.Slug}).Delete(&post)
+	if query.Error != nil {
+		if query.Error == gorm.RecordNotFound {
+			return errors.New("not found")
+		}
+		return query.Error
+	}
+	if err := deleteComments(db, post.ID); err != nil {
+		return err
+	}
+	if err := deleteRedirects(db, post.ID); err != nil {
+		return err
+	}
+	if err := deleteDrafts(db, post.ID); err != nil {
+		return err
+	}
+	if err := deletePreviews(db, post.ID); err != nil {
+		return err
+	}
+	if err := deleteWebmentions(db, post.ID); err != nil {
+		return err
+	}
+	TriggerWebhooks(EventPostDeleted, post)
+	if post.Published {
+		Federate(ActivityDelete, post)
+	}
 	return nil
 }
 
 // Increment or post.Increment increases viewcount of a post according to its post.ID
 // It is supposed to be run as a gouroutine, so therefore it does not return anything.
+// Only the counter is written, so that a view neither renders the post nor overwrites an edit.
 func (post Post) Increment(r *http.Request) {
-	post.Viewcount++
-	_, err := post.Update(r)
-	if err != nil {
-		log.Println("analytics error:", err)
+	query := db.Exec("UPDATE posts SET viewcount = viewcount + 1 WHERE id = ?", post.ID)
+	if query.Error != nil {
+		log.Println("analytics error:", query.Error)
 	}
 }
@@ -0,0 +1,275 @@
+// Previews.go lets authors share unpublished posts for review without giving out an account.
+// A preview link carries a token naming the post and the time the link expires, signed with the
+// cookie hash of the site. The links are also recorded in the database, so that the author can list
+// them and revoke one before it expires.
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+// Preview is a preview link of an unpublished post.
+type Preview struct {
+	ID      int64  `json:"id" gorm:"primary_key:yes"`
+	Post    int64  `json:"post"`
+	Date    int64  `json:"date"`
+	Expires int64  `json:"expires"`
+	URL     string `json:"url" sql:"-"`
+}
+
+// Lifetime of preview links in days, unless the author asks for another one.
+const (
+	defaultPreviewDays = 7
+	maxPreviewDays     = 90
+)
+
+// Visible or post.Visible reports whether the request may read the post. Published posts are public,
+// unpublished ones are visible to their author and to requests with a valid preview token.
+func (post Post) Visible(r *http.Request) bool {
+	if post.Published {
+		return true
+	}
+	var user User
+	if user, err := user.Session(r); err == nil && user.ID == post.Author {
+		return true
+	}
+	if token := r.URL.Query().Get("preview"); token != "" {
+		return post.previewAllowed(token)
+	}
+	return false
+}
+
+// previewAllowed reports whether token is a preview token of the post which is neither expired nor revoked.
+func (post Post) previewAllowed(token string) bool {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return false
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(previewSignature(post.ID, parts[0]+"."+parts[1]))) {
+		return false
+	}
+	id, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return false
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || time.Now().Unix() >= expires {
+		return false
+	}
+	var preview Preview
+	if err := db.Where("id = ? AND post = ?", id, post.ID).First(&preview).Error; err != nil {
+		return false
+	}
+	return preview.Expires == expires
+}
+
+// previewSignature signs payload of a preview token of post with ID id.
+func previewSignature(id int64, payload string) string {
+	mac := hmac.New(sha256.New, []byte(Settings.CookieHash))
+	mac.Write([]byte("preview." + strconv.FormatInt(id, 10) + "." + payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Token or preview.Token returns the token which gives access to the previewed post.
+func (preview Preview) Token() string {
+	payload := strconv.FormatInt(preview.ID, 10) + "." + strconv.FormatInt(preview.Expires, 10)
+	return payload + "." + previewSignature(preview.Post, payload)
+}
+
+// Previews or post.Previews returns the preview links of the post which have not expired, newest first.
+func (post Post) Previews() ([]Preview, error) {
+	previews := make([]Preview, 0)
+	query := db.Order("date desc").Where("post = ? AND expires > ?", post.ID, time.Now().Unix()).Find(&previews)
+	if query.Error != nil && query.Error != gorm.RecordNotFound {
+		return previews, query.Error
+	}
+	for i := range previews {
+		previews[i].URL = post.previewURL(previews[i])
+	}
+	return previews, nil
+}
+
+// NewPreview or post.NewPreview creates a preview link of the post which expires in the given number of days.
+// Expired links of the post are removed at the same time.
+func (post Post) NewPreview(days int) (Preview, error) {
+	var preview Preview
+	if days < 1 || days > maxPreviewDays {
+		return preview, errors.New("invalid expiry")
+	}
+	now := time.Now()
+	if err := db.Where("post = ? AND expires <= ?", post.ID, now.Unix()).Delete(Preview{}).Error; err != nil {
+		return preview, err
+	}
+	preview.Post = post.ID
+	preview.Date = now.Unix()
+	preview.Expires = now.AddDate(0, 0, days).Unix()
+	if err := db.Create(&preview).Error; err != nil {
+		return preview, err
+	}
+	preview.URL = post.previewURL(preview)
+	return preview, nil
+}
+
+// RevokePreview or post.RevokePreview deletes the preview link of the post with ID id.
+func (post Post) RevokePreview(id int64) error {
+	query := db.Where("id = ? AND post = ?", id, post.ID).Delete(Preview{})
+	if query.Error != nil {
+		return query.Error
+	}
+	if query.RowsAffected == 0 {
+		return errors.New("not found")
+	}
+	return nil
+}
+
+func (post Post) previewURL(preview Preview) string {
+	return "/post/" + post.Slug + "?preview=" + preview.Token()
+}
+
+// deletePreviews deletes all preview links of post with ID id.
+func deletePreviews(tx *gorm.DB, id int64) error {
+	return tx.Where("post = ?", id).Delete(Preview{}).Error
+}
+
+// authorPost returns the post of mux parameter "slug" if the session user wrote it.
+func authorPost(r *http.Request) (Post, error) {
+	var post Post
+	post.Slug = mux.Vars(r)["slug"]
+	post, err := post.Get(r)
+	if err != nil {
+		return post, err
+	}
+	var user User
+	user, err = user.Session(r)
+	if err != nil {
+		return post, err
+	}
+	if post.Author != user.ID {
+		return post, errors.New("unauthorized")
+	}
+	return post, nil
+}
+
+// previewError writes the response for an error returned by authorPost or preview methods.
+func previewError(w http.ResponseWriter, err error) {
+	switch err.Error() {
+	case "not found":
+		rend.JSON(w, http.StatusNotFound, NotFound())
+	case "unauthorized":
+		rend.JSON(w, http.StatusUnauthorized, Unauthorized())
+	case "invalid expiry":
+		rend.JSON(w, http.StatusBadRequest, BadRequest("invalid_expiry", "Days must be between 1 and "+strconv.Itoa(maxPreviewDays)+"."))
+	default:
+		log.Println("preview: ", err)
+		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
+	}
+}
+
+// ReadPreviews is a route which lists the preview links of a post. Requires session cookie of the author.
+// JSON request returns the links, frontend call displays them with a form to create more.
+func ReadPreviews(w http.ResponseWriter, r *http.Request) {
+	post, err := authorPost(r)
+	if err != nil {
+		previewError(w, err)
+		return
+	}
+	previews, err := post.Previews()
+	if err != nil {
+		previewError(w, err)
+		return
+	}
+	switch root(r) {
+	case "api":
+		rend.JSON(w, http.StatusOK, previews)
+		return
+	case "post":
+		rend.HTML(w, http.StatusOK, "post/previews", map[string]interface{}{"Post": post, "Previews": previews, "Days": defaultPreviewDays})
+		return
+	}
+}
+
+// CreatePreview is a route which creates a preview link of a post. Parameter "days" sets its lifetime,
+// seven days by default. Requires session cookie of the author.
+// JSON request returns the created link, frontend call redirects back to the list of links.
+func CreatePreview(w http.ResponseWriter, r *http.Request) {
+	post, err := authorPost(r)
+	if err != nil {
+		previewError(w, err)
+		return
+	}
+	days := defaultPreviewDays
+	switch root(r) {
+	case "api":
+		var input struct {
+			Days int `json:"days"`
+		}
+		// An empty body keeps the default lifetime.
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil && err != io.EOF {
+			rend.JSON(w, http.StatusBadRequest, BadRequest("invalid_body", "The request body could not be parsed."))
+			return
+		}
+		if input.Days != 0 {
+			days = input.Days
+		}
+	case "post":
+		if value := r.PostFormValue("days"); value != "" {
+			days, err = strconv.Atoi(value)
+			if err != nil {
+				days = 0
+			}
+		}
+	}
+	preview, err := post.NewPreview(days)
+	if err != nil {
+		previewError(w, err)
+		return
+	}
+	switch root(r) {
+	case "api":
+		rend.JSON(w, http.StatusOK, preview)
+		return
+	case "post":
+		http.Redirect(w, r, "/post/"+post.Slug+"/previews", http.StatusFound)
+		return
+	}
+}
+
+// RevokePreview is a route which revokes the preview link "id" of a post. Requires session cookie of the author.
+// JSON request returns `HTTP 200 {"success": "Preview link revoked"}`, frontend call redirects back to the list of links.
+func RevokePreview(w http.ResponseWriter, r *http.Request) {
+	post, err := authorPost(r)
+	if err != nil {
+		previewError(w, err)
+		return
+	}
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		rend.JSON(w, http.StatusBadRequest, BadRequest("invalid_id", "The preview ID could not be parsed from the request URL."))
+		return
+	}
+	if err := post.RevokePreview(id); err != nil {
+		previewError(w, err)
+		return
+	}
+	switch root(r) {
+	case "api":
+		rend.JSON(w, http.StatusOK, map[string]interface{}{"success": "Preview link revoked"})
+		return
+	case "post":
+		http.Redirect(w, r, "/post/"+post.Slug+"/previews", http.StatusFound)
+		return
+	}
+}
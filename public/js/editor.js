@@ -0,0 +1,141 @@
+// Editor.js adds live preview, autosave and recovery of drafts to the post editor.
+// The editor form tells the ID of the post it edits in data-post, 0 for a new post, and the format
+// its source is written in in data-format.
+// Changes are saved into a draft on the server every few seconds, and the draft is offered
+// back when the editor is opened again before the post was saved.
+(function () {
+	var form = document.forms.new;
+	var fields = form.elements;
+	var text = document.getElementById("text");
+	var preview = document.getElementById("preview");
+	var status = document.getElementById("draft-status");
+	var recovery = document.getElementById("draft-recovery");
+	var draftURL = "/api/v1/drafts/" + form.getAttribute("data-post");
+	var interval = 5000;
+	var changed = false;
+	var saving = false;
+	var pending = null;
+
+	// html tells whether the source is edited as HTML in contenteditable instead of a textarea.
+	var html = text.tagName.toLowerCase() !== "textarea";
+
+	function request(method, url, body, done) {
+		var xhr = new XMLHttpRequest();
+		xhr.open(method, url);
+		xhr.setRequestHeader("Content-Type", "application/json");
+		xhr.onload = function () {
+			var data = null;
+			try {
+				data = JSON.parse(xhr.responseText);
+			} catch (e) {}
+			done(xhr.status, data);
+		};
+		xhr.onerror = function () {
+			done(0, null);
+		};
+		xhr.send(body === null ? null : JSON.stringify(body));
+	}
+
+	// source returns the fields of the editor as they are saved into a draft.
+	function source() {
+		var post = {
+			title: fields.namedItem("title").value,
+			slug: fields.namedItem("slug").value,
+			format: form.getAttribute("data-format")
+		};
+		if (html) {
+			post.content = text.innerHTML;
+		} else {
+			post.markdown = text.value;
+		}
+		return post;
+	}
+
+	function restore(draft) {
+		fields.namedItem("title").value = draft.title;
+		fields.namedItem("slug").value = draft.slug;
+		if (html) {
+			text.innerHTML = draft.content || "";
+		} else {
+			text.value = draft.markdown || "";
+		}
+	}
+
+	function autosave() {
+		if (!changed || saving) {
+			return;
+		}
+		changed = false;
+		saving = true;
+		request("PUT", draftURL, source(), function (code, draft) {
+			saving = false;
+			if (code !== 200) {
+				changed = true;
+				status.textContent = "Draft could not be saved.";
+				return;
+			}
+			status.textContent = "Draft saved at " + new Date(draft.date * 1000).toLocaleTimeString() + ".";
+		});
+	}
+
+	function refreshPreview() {
+		var post = source();
+		request("POST", "/api/v1/posts/preview", post, function (code, rendered) {
+			if (code !== 200) {
+				preview.textContent = rendered && rendered.error ? rendered.error : "Preview is not available.";
+				return;
+			}
+			preview.innerHTML = "<h1></h1>" + rendered.content;
+			preview.firstChild.textContent = rendered.title;
+		});
+	}
+
+	// edited marks the draft changed and refreshes the preview once typing pauses.
+	function edited() {
+		changed = true;
+		if (preview.hidden) {
+			return;
+		}
+		clearTimeout(pending);
+		pending = setTimeout(refreshPreview, 500);
+	}
+
+	// Offer the draft left from an earlier visit, if there is one.
+	request("GET", draftURL, null, function (code, draft) {
+		if (code !== 200) {
+			return;
+		}
+		recovery.querySelector("time").textContent = new Date(draft.date * 1000).toLocaleString();
+		recovery.hidden = false;
+		recovery.querySelector(".restore").onclick = function () {
+			restore(draft);
+			recovery.hidden = true;
+			edited();
+		};
+		recovery.querySelector(".discard").onclick = function () {
+			request("DELETE", draftURL, null, function () {});
+			recovery.hidden = true;
+		};
+	});
+
+	fields.namedItem("title").addEventListener("input", edited);
+	fields.namedItem("slug").addEventListener("input", edited);
+	text.addEventListener("input", edited);
+	setInterval(autosave, interval);
+
+	document.getElementById("preview-toggle").onclick = function () {
+		preview.hidden = !preview.hidden;
+		if (!preview.hidden) {
+			refreshPreview();
+		}
+	};
+
+	// Copy data from contenteditable to textarea for POST to gather the data correctly.
+	// The draft is discarded by the server once the post is saved.
+	form.addEventListener("submit", function () {
+		changed = false;
+		if (html) {
+			fields.namedItem("content").value = text.innerHTML;
+		}
+	});
+})();
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"net/http"
+)
+
+/*
+This is an autogenerated file by autobindings
+*/
+
+import (
+	"github.com/mholt/binding"
+)
+
+func (r *RecoveryRequest) FieldMap() binding.FieldMap {
+	return binding.FieldMap{
+		&r.Email: "email",
+	}
+}
+
+func (r *RecoveryRequest) Validate(req *http.Request, errs binding.Errors) binding.Errors {
+	errs = requireField(errs, "email", r.Email, "Email is required.")
+	if r.Email != "" && !validEmail(r.Email) {
+		errs.Add([]string{"email"}, ErrInvalidEmail, "Email is not a valid email address.")
+	}
+    return errs
+}
@@ -0,0 +1,73 @@
+// Sanitizer.go contains the allow-list of HTML which posts may contain. Every post is sanitized
+// after rendering, whatever its format, so that authors cannot run scripts in the browsers of readers
+// or of other authors. Admins may additionally embed iframes from the hosts listed in settings.
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+)
+
+// sanitizePolicy returns the HTML policy for posts written by a user with role.
+func sanitizePolicy(role string) *bluemonday.Policy {
+	policy := bluemonday.UGCPolicy()
+	// Authors write the blog rather than comment on it, so their links are not marked nofollow.
+	policy.RequireNoFollowOnLinks(false)
+	// Fenced code blocks of Markdown carry their language as a class and are highlighted,
+	// see highlight.go.
+	policy.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+-]+$`)).OnElements("code")
+	policy.AllowAttrs("class").Matching(regexp.MustCompile(`^highlight$`)).OnElements("pre")
+	policy.AllowAttrs("class").Matching(highlightClasses).OnElements("span")
+	// Footnotes of Markdown.
+	policy.AllowAttrs("class").Matching(regexp.MustCompile(`^footnote-ref$`)).OnElements("sup")
+	policy.AllowAttrs("class").Matching(regexp.MustCompile(`^footnotes$`)).OnElements("div")
+	policy.AllowAttrs("class").Matching(regexp.MustCompile(`^footnote-return$`)).OnElements("a")
+	policy.AllowAttrs("rel").Matching(regexp.MustCompile(`^footnote$`)).OnElements("a")
+	if role == RoleAdmin {
+		if hosts := embedHosts(); hosts != nil {
+			policy.AllowAttrs("src").Matching(hosts).OnElements("iframe")
+			policy.AllowAttrs("width", "height").Matching(embedSize).OnElements("iframe")
+			policy.AllowAttrs("frameborder").Matching(bluemonday.Integer).OnElements("iframe")
+			policy.AllowAttrs("allowfullscreen", "title").OnElements("iframe")
+		}
+	}
+	return policy
+}
+
+// embedSize matches width and height of embedded iframes, such as 560 or 100%.
+var embedSize = regexp.MustCompile(`^[0-9]+%?$`)
+
+// embedHosts returns a pattern matching iframe URLs of the hosts in Settings.EmbedHosts,
+// or nil if there are none.
+func embedHosts() *regexp.Regexp {
+	var hosts []string
+	for _, host := range strings.FieldsFunc(Settings.EmbedHosts, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n' || r == '\t' || r == '\r'
+	}) {
+		hosts = append(hosts, regexp.QuoteMeta(strings.ToLower(host)))
+	}
+	if len(hosts) == 0 {
+		return nil
+	}
+	return regexp.MustCompile(`(?i)^(https?:)?//(` + strings.Join(hosts, "|") + `)(/|$)`)
+}
+
+// sanitizeContent removes everything from rendered post content s that a user with role may not use.
+func sanitizeContent(s, role string) string {
+	return sanitizePolicy(role).Sanitize(s)
+}
+
+// authorRole or post.authorRole returns role of the author of the post.
+// Posts of unknown authors get the most restrictive role.
+func (post Post) authorRole() string {
+	if post.Author == 0 {
+		return RoleAuthor
+	}
+	var user User
+	if err := db.Where(&User{ID: post.Author}).First(&user).Error; err != nil {
+		return RoleAuthor
+	}
+	return user.Role
+}
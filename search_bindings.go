@@ -0,0 +1,25 @@
+package main
+
+import (
+	"net/http"
+)
+
+/*
+This is an autogenerated file by autobindings
+*/
+
+import (
+	"github.com/mholt/binding"
+)
+
+func (s *Search) FieldMap() binding.FieldMap {
+	return binding.FieldMap{
+		&s.Query: "query",
+	}
+}
+
+func (s *Search) Validate(req *http.Request, errs binding.Errors) binding.Errors {
+	errs = requireField(errs, "query", s.Query, "Search query is required.")
+	errs = limitLength(errs, "query", s.Query, maxTitleLength, "Search query is too long.")
+    return errs
+}
@@ -0,0 +1,457 @@
+// Series.go groups posts into series, such as a tutorial published in several parts.
+// Each post of a series has a part number, by which the posts are ordered. Posts show which part
+// of the series they are with links to the previous and next part, and every series has its
+// own page listing the parts and RSS and Atom feeds of them.
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/feeds"
+	"github.com/gorilla/mux"
+	"github.com/gosimple/slug"
+	"github.com/jinzhu/gorm"
+	"github.com/mholt/binding"
+)
+
+// Series is an ordered group of posts written by the same author.
+type Series struct {
+	ID          int64  `json:"id" gorm:"primary_key:yes"`
+	Title       string `json:"title" form:"title" binding:"required"`
+	Slug        string `json:"slug" form:"slug"`
+	Description string `json:"description" form:"description" sql:"type:text"`
+	Author      int64  `json:"author"`
+	Date        int64  `json:"date"`
+	Posts       []Post `json:"posts" sql:"-"`
+}
+
+func (Series) TableName() string { return "series" }
+
+// SeriesNav tells which part of its series a post is.
+// Used in "/post/display.tmpl".
+type SeriesNav struct {
+	Series   Series
+	Part     int
+	Total    int
+	Previous *Post
+	Next     *Post
+}
+
+// Get or series.Get returns the series with series.Slug, without its posts.
+func (series Series) Get() (Series, error) {
+	query := db.Where(&Series{Slug: series.Slug}).First(&series)
+	if query.Error != nil {
+		if query.Error == gorm.RecordNotFound {
+			return series, errors.New("not found")
+		}
+		return series, query.Error
+	}
+	return series, nil
+}
+
+// GetPosts or series.GetPosts returns the posts of the series in order of their parts.
+// Unpublished posts are left out unless all is set.
+func (series Series) GetPosts(all bool) ([]Post, error) {
+	posts := make([]Post, 0)
+	query := db.Order("part, date").Where("series = ? AND kind = ?", series.ID, KindPost)
+	if !all {
+		query = query.Where("published = ?", true)
+	}
+	if err := query.Find(&posts).Error; err != nil && err != gorm.RecordNotFound {
+		return posts, err
+	}
+	return posts, nil
+}
+
+// AllSeries returns the series of the user with ID author, or every series if author is 0, ordered by title.
+func AllSeries(author int64) ([]Series, error) {
+	series := make([]Series, 0)
+	query := db.Order("title")
+	if author != 0 {
+		query = query.Where("author = ?", author)
+	}
+	if err := query.Find(&series).Error; err != nil && err != gorm.RecordNotFound {
+		return series, err
+	}
+	return series, nil
+}
+
+// Insert or series.Insert creates the series for the session user. The slug is made unique
+// the same way as slugs of posts.
+func (series Series) Insert(r *http.Request) (Series, error) {
+	var user User
+	user, err := user.Session(r)
+	if err != nil {
+		return series, err
+	}
+	if series.Slug == "" {
+		series.Slug = series.Title
+	}
+	series.Slug, err = uniqueSeriesSlug(series.Slug, 0)
+	if err != nil {
+		return series, err
+	}
+	series.Author = user.ID
+	series.Date = time.Now().Unix()
+	if err := db.Create(&series).Error; err != nil {
+		return series, err
+	}
+	return series, nil
+}
+
+// Update or series.Update saves the title, slug and description of the series.
+func (series Series) Update() (Series, error) {
+	var err error
+	series.Slug, err = uniqueSeriesSlug(series.Slug, series.ID)
+	if err != nil {
+		return series, err
+	}
+	query := db.Model(&series).Updates(map[string]interface{}{"title": series.Title, "slug": series.Slug, "description": series.Description})
+	if query.Error != nil {
+		return series, query.Error
+	}
+	return series, nil
+}
+
+// Delete or series.Delete deletes the series. Its posts are kept as standalone posts.
+func (series Series) Delete() error {
+	tx := db.Begin()
+	if err := tx.Exec("UPDATE posts SET series = 0, part = 0 WHERE series = ?", series.ID).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Delete(&series).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// uniqueSeriesSlug returns a slug made of s which no other series than the one with ID id uses.
+func uniqueSeriesSlug(s string, id int64) (string, error) {
+	base := slug.Make(s)
+	if base == "" {
+		base = "series"
+	}
+	candidate := base
+	for n := 2; ; n++ {
+		if candidate != "new" {
+			var series Series
+			query := db.Where("slug = ? AND id <> ?", candidate, id).First(&series)
+			if query.Error == gorm.RecordNotFound {
+				return candidate, nil
+			}
+			if query.Error != nil {
+				return "", query.Error
+			}
+		}
+		candidate = base + "-" + strconv.Itoa(n)
+	}
+}
+
+// checkSeries or post.checkSeries returns an error if the post cannot be a part of series post.Series.
+// Only posts can be parts of a series, and only of the series of their own author.
+// A post without a part number becomes the last part.
+func (post Post) checkSeries() (Post, error) {
+	if post.Series == 0 {
+		post.Part = 0
+		return post, nil
+	}
+	if post.Kind == KindPage {
+		return post, errors.New("invalid series")
+	}
+	var series Series
+	query := db.Where(&Series{ID: post.Series}).First(&series)
+	if query.Error != nil {
+		if query.Error == gorm.RecordNotFound {
+			return post, errors.New("invalid series")
+		}
+		return post, query.Error
+	}
+	if series.Author != post.Author {
+		return post, errors.New("invalid series")
+	}
+	if post.Part < 0 {
+		return post, errors.New("invalid part")
+	}
+	if post.Part == 0 {
+		var last Post
+		query := db.Order("part desc").Where("series = ? AND id <> ?", post.Series, post.ID).First(&last)
+		if query.Error != nil && query.Error != gorm.RecordNotFound {
+			return post, query.Error
+		}
+		post.Part = last.Part + 1
+	}
+	return post, nil
+}
+
+// SeriesNav or post.SeriesNav returns the place of the post in its series, or nil if it is not part of one.
+// Parts are counted among the published posts of the series, and the post itself if it is not published.
+func (post Post) SeriesNav() (*SeriesNav, error) {
+	if post.Series == 0 || post.Kind != KindPost {
+		return nil, nil
+	}
+	var series Series
+	query := db.Where(&Series{ID: post.Series}).First(&series)
+	if query.Error != nil {
+		if query.Error == gorm.RecordNotFound {
+			return nil, nil
+		}
+		return nil, query.Error
+	}
+	posts, err := series.GetPosts(true)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range posts {
+		if !p.Published && p.ID != post.ID {
+			continue
+		}
+		series.Posts = append(series.Posts, p)
+	}
+	nav := &SeriesNav{Series: series, Total: len(series.Posts)}
+	for i, p := range series.Posts {
+		if p.ID != post.ID {
+			continue
+		}
+		nav.Part = i + 1
+		if i > 0 {
+			nav.Previous = &series.Posts[i-1]
+		}
+		if i < len(series.Posts)-1 {
+			nav.Next = &series.Posts[i+1]
+		}
+	}
+	return nav, nil
+}
+
+// sessionSeries returns the series of mux parameter "slug" if the session user created it.
+func sessionSeries(r *http.Request) (Series, error) {
+	var series Series
+	series.Slug = mux.Vars(r)["slug"]
+	series, err := series.Get()
+	if err != nil {
+		return series, err
+	}
+	var user User
+	user, err = user.Session(r)
+	if err != nil {
+		return series, err
+	}
+	if series.Author != user.ID {
+		return series, errors.New("unauthorized")
+	}
+	return series, nil
+}
+
+// seriesError writes the response for an error returned by series methods.
+func seriesError(w http.ResponseWriter, err error) {
+	switch err.Error() {
+	case "not found":
+		rend.JSON(w, http.StatusNotFound, NotFound())
+	case "unauthorized":
+		rend.JSON(w, http.StatusUnauthorized, Unauthorized())
+	default:
+		log.Println("series: ", err)
+		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
+	}
+}
+
+// seriesBadRequest returns the response for an error of post.checkSeries caused by the request.
+func seriesBadRequest(err error) APIError {
+	if err.Error() == "invalid part" {
+		return BadRequest("invalid_part", "Part must not be negative.")
+	}
+	return BadRequest("invalid_series", "Series must be one of your own series, and pages cannot be in one.")
+}
+
+// ReadAllSeries is a route which returns every series without their posts.
+// Not available on frontend, so therefore it only returns a JSON response.
+func ReadAllSeries(w http.ResponseWriter, r *http.Request) {
+	series, err := AllSeries(0)
+	if err != nil {
+		seriesError(w, err)
+		return
+	}
+	rend.JSON(w, http.StatusOK, series)
+}
+
+// ReadSeries is a route which returns the series of mux parameter "slug" with its published posts in order.
+// JSON call returns the series object, frontend call displays the index page of the series.
+func ReadSeries(w http.ResponseWriter, r *http.Request) {
+	var series Series
+	series.Slug = mux.Vars(r)["slug"]
+	series, err := series.Get()
+	if err != nil {
+		seriesError(w, err)
+		return
+	}
+	series.Posts, err = series.GetPosts(false)
+	if err != nil {
+		seriesError(w, err)
+		return
+	}
+	switch root(r) {
+	case "api":
+		rend.JSON(w, http.StatusOK, series)
+		return
+	case "series":
+		rend.HTML(w, http.StatusOK, "series/display", series)
+		return
+	}
+}
+
+// NewSeries is a route which displays the form for a new series.
+func NewSeries(w http.ResponseWriter, r *http.Request) {
+	rend.HTML(w, http.StatusOK, "series/edit", SeriesForm{})
+}
+
+// CreateSeries is a route which creates a new series according to the posted data. Requires session cookie.
+// JSON request returns the created series object, frontend call redirects to "/user".
+func CreateSeries(w http.ResponseWriter, r *http.Request) {
+	input := new(Series)
+	if errs := binding.Bind(r, input); len(errs) > 0 {
+		switch root(r) {
+		case "api":
+			rend.JSON(w, validationStatus(errs), ValidationError(errs))
+		case "series":
+			rend.HTML(w, validationStatus(errs), "series/edit", SeriesForm{Series: *input, Errors: fieldErrors(errs)})
+		}
+		return
+	}
+	if input.Title == "" {
+		rend.JSON(w, http.StatusBadRequest, BadRequest("invalid_title", "Series must have a title."))
+		return
+	}
+
+	var series Series
+	series.Title = input.Title
+	series.Slug = input.Slug
+	series.Description = input.Description
+	series, err := series.Insert(r)
+	if err != nil {
+		seriesError(w, err)
+		return
+	}
+	switch root(r) {
+	case "api":
+		rend.JSON(w, http.StatusOK, series)
+		return
+	case "series":
+		http.Redirect(w, r, "/user", http.StatusFound)
+		return
+	}
+}
+
+// EditSeries is a route which displays the form for editing the series of mux parameter "slug".
+// Requires session cookie of the author.
+func EditSeries(w http.ResponseWriter, r *http.Request) {
+	series, err := sessionSeries(r)
+	if err != nil {
+		seriesError(w, err)
+		return
+	}
+	if series.Posts, err = series.GetPosts(true); err != nil {
+		seriesError(w, err)
+		return
+	}
+	rend.HTML(w, http.StatusOK, "series/edit", SeriesForm{Series: series})
+}
+
+// UpdateSeries is a route which updates the series of mux parameter "slug" with posted data.
+// Fields which are left empty keep their current values. Requires session cookie of the author.
+// JSON request returns the updated series object, frontend call redirects to "/user".
+func UpdateSeries(w http.ResponseWriter, r *http.Request) {
+	series, err := sessionSeries(r)
+	if err != nil {
+		seriesError(w, err)
+		return
+	}
+	input := new(Series)
+	if errs := binding.Bind(r, input); len(errs) > 0 {
+		switch root(r) {
+		case "api":
+			rend.JSON(w, validationStatus(errs), ValidationError(errs))
+		case "series":
+			if input.Title != "" {
+				series.Title = input.Title
+			}
+			rend.HTML(w, validationStatus(errs), "series/edit", SeriesForm{Series: series, Errors: fieldErrors(errs)})
+		}
+		return
+	}
+	if input.Title != "" {
+		series.Title = input.Title
+	}
+	if input.Slug != "" {
+		series.Slug = input.Slug
+	}
+	if input.Description != "" {
+		series.Description = input.Description
+	}
+	series, err = series.Update()
+	if err != nil {
+		seriesError(w, err)
+		return
+	}
+	switch root(r) {
+	case "api":
+		rend.JSON(w, http.StatusOK, series)
+		return
+	case "series":
+		http.Redirect(w, r, "/user", http.StatusFound)
+		return
+	}
+}
+
+// DeleteSeries is a route which deletes the series of mux parameter "slug". Its posts are kept.
+// Requires session cookie of the author.
+// JSON request returns `HTTP 200 {"success": "Series deleted"}`, frontend call redirects to "/user".
+func DeleteSeries(w http.ResponseWriter, r *http.Request) {
+	series, err := sessionSeries(r)
+	if err != nil {
+		seriesError(w, err)
+		return
+	}
+	if err := series.Delete(); err != nil {
+		seriesError(w, err)
+		return
+	}
+	switch root(r) {
+	case "api":
+		rend.JSON(w, http.StatusOK, map[string]interface{}{"success": "Series deleted"})
+		return
+	case "series":
+		http.Redirect(w, r, "/user", http.StatusFound)
+		return
+	}
+}
+
+// ReadSeriesFeed renders RSS or Atom feed of the published posts of the series of mux parameter "slug".
+// The feed type is the last element of the path, as in /series/:slug/atom.
+func ReadSeriesFeed(w http.ResponseWriter, r *http.Request) {
+	var series Series
+	series.Slug = mux.Vars(r)["slug"]
+	series, err := series.Get()
+	if err != nil {
+		seriesError(w, err)
+		return
+	}
+	posts, err := series.GetPosts(false)
+	if err != nil {
+		seriesError(w, err)
+		return
+	}
+	feed := &feeds.Feed{
+		Title:       Settings.Name + ": " + series.Title,
+		Link:        &feeds.Link{Href: urlHost() + "/series/" + series.Slug},
+		Description: series.Description,
+	}
+	parts := strings.Split(r.URL.Path, "/")
+	writeFeed(w, feed, posts, parts[len(parts)-1])
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"net/http"
+)
+
+/*
+This is an autogenerated file by autobindings
+*/
+
+import (
+	"github.com/mholt/binding"
+)
+
+func (s *Series) FieldMap() binding.FieldMap {
+	return binding.FieldMap{
+		&s.Description: "description",
+		&s.Slug:        "slug",
+		&s.Title:       "title",
+	}
+}
+
+func (s *Series) Validate(req *http.Request, errs binding.Errors) binding.Errors {
+	errs = limitLength(errs, "title", s.Title, maxTitleLength, "Title is too long.")
+    return errs
+}
@@ -7,7 +7,12 @@ import (
 	"github.com/gorilla/sessions"
 )
 
-var store = sessions.NewCookieStore([]byte(Settings.CookieHash))
+var store *sessions.CookieStore
+
+// newCookieStore returns session store keyed with the CookieHash of current Settings.
+func newCookieStore() *sessions.CookieStore {
+	return sessions.NewCookieStore([]byte(Settings.CookieHash))
+}
 
 const SESSIONNAME string = "vertigosession"
 
@@ -84,11 +89,29 @@ func SessionIsAlive(r *http.Request) bool {
 	return true
 }
 
+// AdminPage only lets through requests with a session of a user with the admin role.
+func AdminPage(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var user User
+		user, err := user.Session(r)
+		if err != nil {
+			rend.JSON(w, http.StatusUnauthorized, Unauthorized())
+			return
+		}
+		if !user.IsAdmin() {
+			rend.JSON(w, http.StatusForbidden, Forbidden())
+			return
+		}
+		h.ServeHTTP(w, r)
+		return
+	})
+}
+
 func ProtectedPage(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if !SessionIsAlive(r) {
 			SessionDelete(w, r, "id")
-			rend.JSON(w, http.StatusUnauthorized, map[string]interface{}{"error": "Unauthorized"})
+			rend.JSON(w, http.StatusUnauthorized, Unauthorized())
 			return
 		}
 		h.ServeHTTP(w, r)
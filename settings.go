@@ -35,11 +35,16 @@ type Vertigo struct {
 	Markdown           bool            `json:"markdown" form:"markdown"`
 	Description        string          `json:"description" form:"description" binding:"required"`
 	Mailer             MailgunSettings `json:"mailgun"`
+	Recovery           bool            `json:"recovery" form:"recovery"`
 	Disqus             string          `json:"disqus" form:"disqus"`
 	GoogleAnalytics    string          `json:"ga" form:"ga"`
+	EmbedHosts         string          `json:"embedhosts" form:"embedhosts"`
+	CodeTheme          string          `json:"codetheme" form:"codetheme"`
+	LineNumbers        bool            `json:"linenumbers" form:"linenumbers"`
 }
 
 // MailgunSettings holds the API keys necessary to send account recovery email.
+// They are only required if Recovery is enabled.
 // You can find the necessary values for these structures in https://mailgun.com/cp
 type MailgunSettings struct {
 	Domain     string `json:"mgdomain" form:"mgdomain" binding:"required"`
@@ -54,13 +59,13 @@ func init() {
 // You can call it globally anywhere by simply using the Settings keyword. For example
 // fmt.Println(Settings.Name) will print out your site's name.
 // As mentioned in the Vertigo struct, be careful when dealing with the Firstun and CookieHash values.
-var Settings = VertigoSettings()
+// It is loaded from Config.Settings by Setup.
+var Settings *Vertigo
 
-// VertigoSettings populates the global namespace with data from settings.json.
+// VertigoSettings populates the global namespace with data from the settings file at Config.Settings.
 // If the file does not exist, it creates it.
 func VertigoSettings() *Vertigo {
-	//settingsfile := fmt.Sprint(settingssource)
-	settingsfile := "settings.json"
+	settingsfile := Config.Settings
 	_, err := os.OpenFile(settingsfile, os.O_RDWR|os.O_CREATE, 0600)
 	if err != nil {
 		panic(err)
@@ -91,14 +96,20 @@ func VertigoSettings() *Vertigo {
 	if err := json.Unmarshal(data, &settings); err != nil {
 		panic(err)
 	}
+	// Settings files from before password recovery could be turned off keep it on if Mailgun is set up.
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err == nil {
+		if _, exists := fields["recovery"]; !exists {
+			settings.Recovery = settings.Mailer.Domain != "" && settings.Mailer.PrivateKey != ""
+		}
+	}
 	return settings
 }
 
 // Save or Settings.Save is a method which replaces the global Settings structure with the structure is is called with.
 // It has builtin variable declaration which prevents you from overwriting CookieHash field.
 func (settings *Vertigo) Save() error {
-	//settingsfile := fmt.Sprint(settingssource)
-	settingsfile := "settings.json"
+	settingsfile := Config.Settings
 	data, err := ioutil.ReadFile(settingsfile)
 	if err != nil {
 		return err
@@ -156,8 +167,20 @@ func UpdateBlogSettings(w http.ResponseWriter, r *http.Request) {
 	*/
 
 	settings := new(Vertigo)
-	if errs := binding.Bind(r, settings); errs != nil {
-		log.Println(errs)
+	if errs := binding.Bind(r, settings); len(errs) > 0 {
+		switch root(r) {
+		case "api":
+			rend.JSON(w, validationStatus(errs), ValidationError(errs))
+		case "user":
+			settings.CookieHash = ""
+			if Settings.Firstrun {
+				rend.HTML(w, validationStatus(errs), "installation/wizard", Page{Data: *settings, Errors: fieldErrors(errs)})
+				return
+			}
+			session, _ := store.Get(r, SESSIONNAME)
+			rend.HTML(w, validationStatus(errs), "settings", Page{Session: session, Data: *settings, Errors: fieldErrors(errs)})
+		}
+		return
 	}
 
 	if Settings.Firstrun == false {
@@ -165,7 +188,7 @@ func UpdateBlogSettings(w http.ResponseWriter, r *http.Request) {
 		user, err := user.Session(r)
 		if err != nil {
 			//log.Println("updateblogsettings not first run: ", err)
-			rend.JSON(w, http.StatusNotAcceptable, map[string]interface{}{"error": "You are not allowed to change the settings this time."})
+			rend.JSON(w, http.StatusNotAcceptable, APIError{Message: "You are not allowed to change the settings this time.", Code: "installed"})
 			return
 		}
 		settings.CookieHash = Settings.CookieHash
@@ -173,7 +196,7 @@ func UpdateBlogSettings(w http.ResponseWriter, r *http.Request) {
 		err = settings.Save()
 		if err != nil {
 			//log.Println("updateblogsettings save: ", err)
-			rend.JSON(w, http.StatusInternalServerError, map[string]interface{}{"error": http.StatusText(http.StatusInternalServerError)})
+			rend.JSON(w, http.StatusInternalServerError, InternalServerError())
 			return
 		}
 		switch root(r) {
@@ -190,7 +213,7 @@ func UpdateBlogSettings(w http.ResponseWriter, r *http.Request) {
 	err = settings.Save()
 	if err != nil {
 		log.Println("updateblogsettings first run: ", err)
-		rend.JSON(w, http.StatusInternalServerError, map[string]interface{}{"error": http.StatusText(http.StatusInternalServerError)})
+		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
 		return
 	}
 	switch root(r) {
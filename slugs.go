@@ -0,0 +1,117 @@
+// Slugs.go keeps post slugs unique and remembers old slugs of renamed posts,
+// so that links to them keep working.
+package main
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gosimple/slug"
+	"github.com/jinzhu/gorm"
+)
+
+// Redirect points an old slug to the post which used to have it.
+type Redirect struct {
+	ID   int64  `json:"id" gorm:"primary_key:yes"`
+	Slug string `json:"slug" sql:"unique"`
+	Post int64  `json:"post"`
+}
+
+// reservedSlugs would clash with routes under /post/, or at the top level where pages live.
+var reservedSlugs = map[string]bool{
+	"new":         true,
+	"activitypub": true,
+	"search":      true,
+	"api":         true,
+	"css":         true,
+	"feeds":       true,
+	"js":          true,
+	"micropub":    true,
+	"post":        true,
+	"series":      true,
+	"uploads":     true,
+	"user":        true,
+	"webmention":  true,
+}
+
+// uniqueSlug returns a slug made of s which no other post than the one with ID id uses.
+// Taken slugs get a numeric suffix, so the second "hello-world" becomes "hello-world-2".
+func uniqueSlug(s string, id int64) (string, error) {
+	base := slug.Make(s)
+	if base == "" {
+		base = "post"
+	}
+	candidate := base
+	for n := 2; ; n++ {
+		if !reservedSlugs[candidate] {
+			var post Post
+			query := db.Where("slug = ? AND id <> ?", candidate, id).First(&post)
+			if query.Error == gorm.RecordNotFound {
+				return candidate, nil
+			}
+			if query.Error != nil {
+				return "", query.Error
+			}
+		}
+		candidate = base + "-" + strconv.Itoa(n)
+	}
+}
+
+// Rename or post.Rename changes slug of the post to an unique version of s.
+// The old slug is kept as a redirect to the post.
+// Returns the post with its new slug.
+func (post Post) Rename(s string) (Post, error) {
+	newslug, err := uniqueSlug(s, post.ID)
+	if err != nil {
+		return post, err
+	}
+	if newslug == post.Slug {
+		return post, nil
+	}
+	tx := db.Begin()
+	// The new slug may have been used by this or another post before.
+	if err := tx.Where("slug = ?", newslug).Delete(Redirect{}).Error; err != nil {
+		tx.Rollback()
+		return post, err
+	}
+	if post.Slug != "" {
+		if err := tx.Create(&Redirect{Slug: post.Slug, Post: post.ID}).Error; err != nil {
+			tx.Rollback()
+			return post, err
+		}
+	}
+	if err := tx.Model(&post).Update("slug", newslug).Error; err != nil {
+		tx.Rollback()
+		return post, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return post, err
+	}
+	post.Slug = newslug
+	return post, nil
+}
+
+// Redirected or post.Redirected returns the post which used to have slug post.Slug.
+func (post Post) Redirected() (Post, error) {
+	var redirect Redirect
+	query := db.Where(&Redirect{Slug: post.Slug}).First(&redirect)
+	if query.Error != nil {
+		if query.Error == gorm.RecordNotFound {
+			return post, errors.New("not found")
+		}
+		return post, query.Error
+	}
+	query = db.Where(&Post{ID: redirect.Post}).First(&post)
+	if query.Error != nil {
+		if query.Error == gorm.RecordNotFound {
+			return post, errors.New("not found")
+		}
+		return post, query.Error
+	}
+	return post, nil
+}
+
+// deleteRedirects deletes all redirects to post with ID id.
+func deleteRedirects(tx *gorm.DB, id int64) error {
+	return tx.Where("post = ?", id).Delete(Redirect{}).Error
+}
@@ -17,7 +17,7 @@ func StrictWWWFormUrlEncoded(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ok := StrictContentType(r, "application/x-www-form-urlencoded")
 		if !ok && r.Method != "GET" {
-			w.WriteHeader(http.StatusUnsupportedMediaType)
+			rend.JSON(w, http.StatusUnsupportedMediaType, APIError{Message: "Content-Type must be application/x-www-form-urlencoded.", Code: ErrUnsupportedMediaType})
 			return
 		}
 		h.ServeHTTP(w, r)
@@ -30,7 +30,7 @@ func StrictJSON(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ok := StrictContentType(r, "application/json")
 		if !ok && r.Method != "GET" {
-			w.WriteHeader(http.StatusUnsupportedMediaType)
+			rend.JSON(w, http.StatusUnsupportedMediaType, APIError{Message: "Content-Type must be application/json.", Code: ErrUnsupportedMediaType})
 			return
 		}
 		h.ServeHTTP(w, r)
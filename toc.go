@@ -0,0 +1,72 @@
+// Toc.go helps readers find their way around long posts. Markdown posts can ask for a table of
+// contents by writing [TOC] on a line of its own, which is replaced with links to the headings of
+// the post. Every post also gets an estimate of the minutes it takes to read.
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"github.com/kennygrant/sanitize"
+)
+
+// tocMarker is what Blackfriday renders a [TOC] line into.
+const tocMarker = "<p>[TOC]</p>"
+
+// wordsPerMinute is the reading speed reading time is estimated with.
+const wordsPerMinute = 200
+
+// headingPattern matches headings of rendered Markdown, which all have an ID.
+var headingPattern = regexp.MustCompile(`(?s)<h([1-6]) id="([^"]+)">(.*?)</h[1-6]>`)
+
+// tagPattern matches a single HTML tag.
+var tagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// tableOfContents replaces the first [TOC] line of rendered Markdown content with a nested list
+// of links to its headings. Content without the marker is returned as it is.
+// Content must already be sanitized, as heading text is copied into the links as it is.
+func tableOfContents(content string) string {
+	if !strings.Contains(content, tocMarker) {
+		return content
+	}
+	var buf bytes.Buffer
+	var levels []byte
+	buf.WriteString(`<nav class="toc">` + "\n")
+	for _, heading := range headingPattern.FindAllStringSubmatch(content, -1) {
+		level := heading[1][0]
+		switch {
+		case len(levels) == 0:
+			buf.WriteString("<ul>\n")
+			levels = append(levels, level)
+		case level > levels[len(levels)-1]:
+			buf.WriteString("\n<ul>\n")
+			levels = append(levels, level)
+		default:
+			for len(levels) > 1 && level < levels[len(levels)-1] {
+				buf.WriteString("</li>\n</ul>\n")
+				levels = levels[:len(levels)-1]
+			}
+			buf.WriteString("</li>\n")
+		}
+		buf.WriteString(`<li><a href="#`)
+		buf.WriteString(heading[2])
+		buf.WriteString(`">`)
+		buf.WriteString(strings.TrimSpace(tagPattern.ReplaceAllString(heading[3], "")))
+		buf.WriteString("</a>")
+	}
+	for range levels {
+		buf.WriteString("</li>\n</ul>\n")
+	}
+	buf.WriteString("</nav>")
+	return strings.Replace(content, tocMarker, buf.String(), 1)
+}
+
+// readingTime returns the minutes it takes to read HTML content, rounded up.
+func readingTime(content string) int {
+	words := len(strings.Fields(sanitize.HTML(content)))
+	if words == 0 {
+		return 0
+	}
+	return (words + wordsPerMinute - 1) / wordsPerMinute
+}
@@ -0,0 +1,259 @@
+// Tokens.go lets users give external clients, such as Micropub apps, access to their account
+// without their password. Users create access tokens on /user/tokens with the scopes the client needs,
+// and the client sends the token in header `Authorization: Bearer <token>`. Tokens are shown once
+// when they are created and only their SHA-256 digest is stored.
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+	"github.com/mholt/binding"
+)
+
+// Scopes of access tokens, named as in IndieAuth.
+const (
+	ScopeCreate = "create"
+	ScopeUpdate = "update"
+	ScopeDelete = "delete"
+	ScopeMedia  = "media"
+)
+
+// tokenScopes lists the scopes access tokens can have.
+var tokenScopes = []string{ScopeCreate, ScopeUpdate, ScopeDelete, ScopeMedia}
+
+// AccessToken gives its holder access to the account of user Owner within its scopes. Scope lists the scopes
+// separated by spaces, and is given all of them if empty. Token is only set when the token is created.
+// Used is the time the token was last used, or 0 if never.
+//go:generate autobindings accesstoken
+type AccessToken struct {
+	ID     int64  `json:"id" gorm:"primary_key:yes"`
+	Owner  int64  `json:"owner"`
+	Name   string `json:"name" form:"name" binding:"required"`
+	Scope  string `json:"scope" form:"scope"`
+	Digest string `json:"-"`
+	Token  string `json:"token,omitempty" sql:"-"`
+	Date   int64  `json:"date"`
+	Used   int64  `json:"used"`
+}
+
+// validScope reports whether access tokens can have scope.
+func validScope(scope string) bool {
+	return containsField(tokenScopes, scope)
+}
+
+// tokenDigest returns the digest of token which is stored in place of the token.
+func tokenDigest(token string) string {
+	digest := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(digest[:])
+}
+
+// Allows or token.Allows reports whether the token has scope.
+func (token AccessToken) Allows(scope string) bool {
+	return containsField(strings.Fields(token.Scope), scope)
+}
+
+// Insert or token.Insert generates a new token for token.Owner and saves its digest.
+// Returns the token with token.Token set.
+func (token AccessToken) Insert() (AccessToken, error) {
+	secret, err := randomSecret()
+	if err != nil {
+		return token, err
+	}
+	token.Token = secret
+	token.Digest = tokenDigest(secret)
+	token.Scope = strings.Join(strings.Fields(token.Scope), " ")
+	if token.Scope == "" {
+		token.Scope = strings.Join(tokenScopes, " ")
+	}
+	token.Date = time.Now().Unix()
+	token.Used = 0
+	if err := db.Create(&token).Error; err != nil {
+		return token, err
+	}
+	return token, nil
+}
+
+// UserTokens returns the access tokens of the user with ID id, newest first.
+func UserTokens(id int64) ([]AccessToken, error) {
+	tokens := make([]AccessToken, 0)
+	query := db.Order("id desc").Where("owner = ?", id).Find(&tokens)
+	if query.Error != nil && query.Error != gorm.RecordNotFound {
+		return tokens, query.Error
+	}
+	return tokens, nil
+}
+
+// Delete or token.Delete revokes the token with token.ID of token.Owner.
+// Returns "not found" if the user has no such token.
+func (token AccessToken) Delete() error {
+	query := db.Where("id = ? AND owner = ?", token.ID, token.Owner).Delete(AccessToken{})
+	if query.Error != nil {
+		return query.Error
+	}
+	if query.RowsAffected == 0 {
+		return errors.New("not found")
+	}
+	return nil
+}
+
+// bearerToken returns the access token of r, given in header Authorization or, as Micropub
+// allows, in form parameter access_token. Returns an empty string if there is none.
+func bearerToken(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		if len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+			return strings.TrimSpace(header[7:])
+		}
+		return ""
+	}
+	return r.PostFormValue("access_token")
+}
+
+// AuthenticateToken returns the access token of r and lets the rest of the request act as its owner,
+// so that methods such as Post.Insert which read the user from the session work as they do for
+// the user. No session cookie is written.
+// Returns "unauthorized" if r has no token and "forbidden" if the token is unknown.
+func AuthenticateToken(r *http.Request) (AccessToken, error) {
+	var token AccessToken
+	secret := bearerToken(r)
+	if secret == "" {
+		return token, errors.New("unauthorized")
+	}
+	query := db.Where("digest = ?", tokenDigest(secret)).First(&token)
+	if query.Error != nil {
+		if query.Error == gorm.RecordNotFound {
+			return token, errors.New("forbidden")
+		}
+		return token, query.Error
+	}
+	if err := db.Model(&token).UpdateColumn("used", time.Now().Unix()).Error; err != nil {
+		log.Println("access token: ", err)
+	}
+	// The session of a request is cached for the rest of it, so the value is seen by user.Session.
+	session, _ := store.Get(r, SESSIONNAME)
+	session.Values["id"] = token.Owner
+	return token, nil
+}
+
+// tokenError writes the response for an error returned by access token methods.
+func tokenError(w http.ResponseWriter, err error) {
+	switch err.Error() {
+	case "not found":
+		rend.JSON(w, http.StatusNotFound, NotFound())
+	case "invalid id":
+		rend.JSON(w, http.StatusBadRequest, BadRequest("invalid_id", "The token ID could not be parsed from the request URL."))
+	default:
+		log.Println("access token: ", err)
+		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
+	}
+}
+
+// tokensPage renders the access tokens page of user, with created the token which was just created,
+// if any, form filling the form to create one and errs the errors of the form.
+func tokensPage(w http.ResponseWriter, status int, user User, created AccessToken, form AccessToken, errs binding.Errors) {
+	tokens, err := UserTokens(user.ID)
+	if err != nil {
+		tokenError(w, err)
+		return
+	}
+	data := map[string]interface{}{"Tokens": tokens, "Created": created, "Form": form, "Scopes": tokenScopes, "Endpoint": urlHost() + "/micropub"}
+	rend.HTML(w, status, "user/tokens", Page{Data: data, Errors: fieldErrors(errs)})
+}
+
+// ReadTokens is a route which lists the access tokens of the current user. Requires session cookie.
+// JSON request returns the tokens without their secrets, frontend call displays them with a form to create more.
+func ReadTokens(w http.ResponseWriter, r *http.Request) {
+	var user User
+	user, err := user.Session(r)
+	if err != nil {
+		rend.JSON(w, http.StatusUnauthorized, Unauthorized())
+		return
+	}
+	switch root(r) {
+	case "api":
+		tokens, err := UserTokens(user.ID)
+		if err != nil {
+			tokenError(w, err)
+			return
+		}
+		rend.JSON(w, http.StatusOK, tokens)
+		return
+	case "user":
+		tokensPage(w, http.StatusOK, user, AccessToken{}, AccessToken{}, nil)
+		return
+	}
+}
+
+// CreateToken is a route which creates an access token for the current user. Name is required.
+// Requires session cookie.
+// JSON request returns the token with its secret in token, frontend call shows it on the tokens page.
+// The secret cannot be read again.
+func CreateToken(w http.ResponseWriter, r *http.Request) {
+	var user User
+	user, err := user.Session(r)
+	if err != nil {
+		rend.JSON(w, http.StatusUnauthorized, Unauthorized())
+		return
+	}
+	input := new(AccessToken)
+	errs := binding.Bind(r, input)
+	errs = requireField(errs, "name", input.Name, "Name is required.")
+	if len(errs) > 0 {
+		switch root(r) {
+		case "api":
+			rend.JSON(w, validationStatus(errs), ValidationError(errs))
+		case "user":
+			tokensPage(w, validationStatus(errs), user, AccessToken{}, *input, errs)
+		}
+		return
+	}
+	token, err := AccessToken{Owner: user.ID, Name: input.Name, Scope: input.Scope}.Insert()
+	if err != nil {
+		tokenError(w, err)
+		return
+	}
+	switch root(r) {
+	case "api":
+		rend.JSON(w, http.StatusOK, token)
+		return
+	case "user":
+		tokensPage(w, http.StatusOK, user, token, AccessToken{}, nil)
+		return
+	}
+}
+
+// DeleteToken is a route which revokes the access token "id" of the current user. Requires session cookie.
+// JSON request returns `HTTP 200 {"success": "Token revoked"}`, frontend call redirects back to the tokens page.
+func DeleteToken(w http.ResponseWriter, r *http.Request) {
+	var user User
+	user, err := user.Session(r)
+	if err != nil {
+		rend.JSON(w, http.StatusUnauthorized, Unauthorized())
+		return
+	}
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		tokenError(w, errors.New("invalid id"))
+		return
+	}
+	if err := (AccessToken{ID: id, Owner: user.ID}).Delete(); err != nil {
+		tokenError(w, err)
+		return
+	}
+	switch root(r) {
+	case "api":
+		rend.JSON(w, http.StatusOK, map[string]interface{}{"success": "Token revoked"})
+		return
+	case "user":
+		http.Redirect(w, r, "/user/tokens", http.StatusFound)
+		return
+	}
+}
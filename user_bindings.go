@@ -26,5 +26,14 @@ func (u *User) FieldMap() binding.FieldMap {
 
 func (u *User) Validate(req *http.Request, errs binding.Errors) binding.Errors {
 	//log.Println("bindingvalidate: ", u)
+	errs = limitLength(errs, "name", u.Name, maxNameLength, "Name is too long.")
+	errs = requireField(errs, "email", u.Email, "Email is required.")
+	if u.Email != "" && !validEmail(u.Email) {
+		errs.Add([]string{"email"}, ErrInvalidEmail, "Email is not a valid email address.")
+	}
+	errs = requireField(errs, "password", u.Password, "Password is required.")
+	if u.Password != "" && !strongPassword(u.Password) {
+		errs.Add([]string{"password"}, ErrWeakPassword, "Password must be at least 8 characters long and mix letters with digits or other characters.")
+	}
     return errs
 }
@@ -25,11 +25,44 @@ type User struct {
 	Email    string `json:"email,omitempty" form:"email" binding:"required" sql:"unique"`
 	Password string `json:"password,omitempty" form:"password" sql:"-"`
 	Avatar   string `json:"avatar" form:"avatar"`
+	Role     string `json:"role"`
 	Recovery string `json:"-"`
 	Digest   []byte `json:"-"`
 	Posts    []Post `json:"posts"`
 }
 
+// Login is what a user logs in with. Passwords are not checked for strength here, so that
+// passwords set before the rules were introduced keep working.
+//go:generate autobindings login
+type Login struct {
+	Email    string `json:"email" form:"email"`
+	Password string `json:"password" form:"password"`
+}
+
+// RecoveryRequest asks for a password recovery email to be sent to Email.
+//go:generate autobindings recoveryrequest
+type RecoveryRequest struct {
+	Email string `json:"email" form:"email"`
+}
+
+// PasswordReset is the new password set through a password recovery link.
+//go:generate autobindings passwordreset
+type PasswordReset struct {
+	Password string `json:"password" form:"password"`
+}
+
+// Roles a user can have. The first user to register becomes an admin, everyone
+// after that is an author. Roles can be changed with `vertigo user set-role`.
+const (
+	RoleAdmin  = "admin"
+	RoleAuthor = "author"
+)
+
+// IsAdmin or user.IsAdmin returns whether the user has administrator rights.
+func (user User) IsAdmin() bool {
+	return user.Role == RoleAdmin
+}
+
 // Session or user.Session returns user.ID from client session cookie.
 // The returned object has post data merged.
 func (user User) Session(r *http.Request) (User, error) {
@@ -71,6 +104,17 @@ func (user User) Get() (User, error) {
 	return user, nil
 }
 
+// publishedPosts returns the published ones of posts, so that drafts are not listed publicly.
+func publishedPosts(posts []Post) []Post {
+	published := make([]Post, 0)
+	for _, post := range posts {
+		if post.Published {
+			published = append(published, post)
+		}
+	}
+	return published
+}
+
 // CreateUser is a route which creates a new user struct according to posted parameters.
 // Requires session cookie.
 // Returns created user struct for API requests and redirects to "/user" on frontend ones.
@@ -79,39 +123,49 @@ func CreateUser(w http.ResponseWriter, r *http.Request) {
 		log.Println("Denied a new registration.")
 		switch root(r) {
 		case "api":
-			rend.JSON(w, http.StatusForbidden, map[string]interface{}{"error": "New registrations are not allowed at this time."})
+			rend.JSON(w, http.StatusForbidden, APIError{Message: "New registrations are not allowed at this time.", Code: "registrations_closed"})
 			return
 		case "user":
-			rend.HTML(w, http.StatusForbidden, "user/login", "New registrations are not allowed at this time.")
+			rend.HTML(w, http.StatusForbidden, "user/login", Page{Data: Login{}, Err: "New registrations are not allowed at this time."})
 			return
 		}
 	}
 
 	newuser := new(User)
-	if errs := binding.Bind(r, newuser); errs != nil {
-		log.Println(errs)
+	if errs := binding.Bind(r, newuser); len(errs) > 0 {
+		switch root(r) {
+		case "api":
+			rend.JSON(w, validationStatus(errs), ValidationError(errs))
+		case "user":
+			newuser.Password = ""
+			rend.HTML(w, validationStatus(errs), "user/register", Page{Data: *newuser, Errors: fieldErrors(errs)})
+		}
+		return
 	}
+	// JSON binding decodes straight into the struct, so make sure nobody can register as an admin.
+	newuser.Role = ""
 
 	user, err := newuser.Insert(r)
 	if err != nil {
 		if err.Error() == "UNIQUE constraint failed: users.email" {
-			rend.JSON(w, http.StatusConflict, map[string]interface{}{"error": "Email already in use"})
+			rend.JSON(w, http.StatusConflict, APIError{Message: "Email already in use", Code: "email_taken"})
 			return
 		}
-		rend.JSON(w, http.StatusInternalServerError, map[string]interface{}{"error": "Internal server error"})
+		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
 		return
 	}
 	user, err = newuser.Login(r)
 	if err != nil {
-		rend.JSON(w, http.StatusInternalServerError, map[string]interface{}{"error": "Internal server error"})
+		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
 		return
 	}
 
 	SessionSetValue(w, r, "id", user.ID)
+	user.Password = ""
+	TriggerWebhooks(EventUserCreated, user)
 
 	switch root(r) {
 	case "api":
-		user.Password = ""
 		rend.JSON(w, http.StatusOK, user)
 		return
 	case "user":
@@ -127,13 +181,13 @@ func CreateUser(w http.ResponseWriter, r *http.Request) {
 // 	user, err := user.Login(r)
 // 	if err != nil {
 // 		log.Println(err)
-// 		rend.JSON(w, http.StatusInternalServerError, map[string]interface{}{"error": "Internal server error"})
+// 		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
 // 		return
 // 	}
 // 	err = user.Delete(r)
 // 	if err != nil {
 // 		log.Println(err)
-// 		rend.JSON(w, http.StatusInternalServerError, map[string]interface{}{"error": "Internal server error"})
+// 		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
 // 		return
 // 	}
 // 	switch root(r) {
@@ -146,22 +200,29 @@ func CreateUser(w http.ResponseWriter, r *http.Request) {
 // 		rend.HTML(w, http.StatusOK, "User successfully deleted", nil)
 // 		return
 // 	}
-// 	rend.JSON(w, http.StatusInternalServerError, map[string]interface{}{"error": "Internal server error"})
+// 	rend.JSON(w, http.StatusInternalServerError, InternalServerError())
 // }
 
 // ReadUser is a route which fetches user according to parameter "id" on API side and according to retrieved
 // session cookie on frontend side.
-// Returns user struct with all posts merged to object on API call. Frontend call will render user "home" page, "user/index.tmpl".
+// Returns user struct on API call. Posts are embedded with query parameter include=posts, unpublished ones
+// only to the user themselves, and query parameters fields and fields[posts] select fields, see fields.go.
+// Frontend call will render user "home" page, "user/index.tmpl".
 func ReadUser(w http.ResponseWriter, r *http.Request) {
 	var user User
 	vars := mux.Vars(r)
 
 	switch root(r) {
 	case "api":
+		selection, err := ParseSelection(r, user, userEmbeddings)
+		if err != nil {
+			rend.JSON(w, http.StatusBadRequest, err)
+			return
+		}
 		id, err := strconv.Atoi(vars["id"])
 		if err != nil {
 			//log.Println("readuser id: ", err)
-			rend.JSON(w, http.StatusBadRequest, map[string]interface{}{"error": "The user ID could not be parsed from the request URL."})
+			rend.JSON(w, http.StatusBadRequest, BadRequest("invalid_id", "The user ID could not be parsed from the request URL."))
 			return
 		}
 		user.ID = int64(id)
@@ -171,10 +232,20 @@ func ReadUser(w http.ResponseWriter, r *http.Request) {
 				rend.JSON(w, http.StatusNotFound, NotFound())
 				return
 			}
-			rend.JSON(w, http.StatusInternalServerError, map[string]interface{}{"error": "Internal server error"})
+			rend.JSON(w, http.StatusInternalServerError, InternalServerError())
 			return
 		}
-		rend.JSON(w, http.StatusOK, user)
+		var session User
+		if session, err = session.Session(r); err != nil || session.ID != user.ID {
+			user.Posts = publishedPosts(user.Posts)
+		}
+		selected, err := selection.User(user)
+		if err != nil {
+			log.Println("readuser select: ", err)
+			rend.JSON(w, http.StatusInternalServerError, InternalServerError())
+			return
+		}
+		rend.JSON(w, http.StatusOK, selected)
 		return
 	case "user":
 		user, err := user.Session(r)
@@ -189,17 +260,31 @@ func ReadUser(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// ReadUsers is a route only available on API side, which fetches all users with post data merged.
-// Returns complete list of users on success.
+// ReadUsers is a route only available on API side, which fetches all users.
+// Returns complete list of users on success. Accepts the same query parameters as ReadUser.
 func ReadUsers(w http.ResponseWriter, r *http.Request) {
 	var user User
+	selection, err := ParseSelection(r, user, userEmbeddings)
+	if err != nil {
+		rend.JSON(w, http.StatusBadRequest, err)
+		return
+	}
 	users, err := user.GetAll(r)
 	if err != nil {
 		log.Println("readusers: ", err)
-		rend.JSON(w, http.StatusInternalServerError, err)
+		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
 		return
 	}
-	rend.JSON(w, http.StatusOK, users)
+	for i := range users {
+		users[i].Posts = publishedPosts(users[i].Posts)
+	}
+	selected, err := selection.Users(users)
+	if err != nil {
+		log.Println("readusers select: ", err)
+		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
+		return
+	}
+	rend.JSON(w, http.StatusOK, selected)
 }
 
 // LoginUser is a route which compares plaintext password sent with POST request with
@@ -209,25 +294,31 @@ func ReadUsers(w http.ResponseWriter, r *http.Request) {
 // On frontend call it redirects the client to "/user" page.
 func LoginUser(w http.ResponseWriter, r *http.Request) {
 
-	newuser := new(User)
-	errs := binding.Bind(r, newuser)
-	if errs != nil {
-		log.Println(errs)
+	login := new(Login)
+	if errs := binding.Bind(r, login); len(errs) > 0 {
+		switch root(r) {
+		case "api":
+			rend.JSON(w, validationStatus(errs), ValidationError(errs))
+		case "user":
+			rend.HTML(w, validationStatus(errs), "user/login", Page{Data: Login{Email: login.Email}, Errors: fieldErrors(errs)})
+		}
+		return
 	}
+	newuser := User{Email: login.Email, Password: login.Password}
 
 	switch root(r) {
 	case "api":
 		user, err := newuser.Login(r)
 		if err != nil {
 			if err.Error() == "wrong username or password" {
-				rend.JSON(w, http.StatusUnauthorized, map[string]interface{}{"error": "Wrong username or password."})
+				rend.JSON(w, http.StatusUnauthorized, APIError{Message: "Wrong username or password.", Code: "wrong_password"})
 				return
 			}
 			if err.Error() == "not found" {
-				rend.JSON(w, http.StatusUnauthorized, map[string]interface{}{"error": "User with that email does not exist."})
+				rend.JSON(w, http.StatusUnauthorized, APIError{Message: "User with that email does not exist.", Code: "unknown_email"})
 				return
 			}
-			rend.JSON(w, http.StatusInternalServerError, map[string]interface{}{"error": "Internal server error"})
+			rend.JSON(w, http.StatusInternalServerError, InternalServerError())
 			return
 		}
 		SessionSetValue(w, r, "id", user.ID)
@@ -238,14 +329,14 @@ func LoginUser(w http.ResponseWriter, r *http.Request) {
 		user, err := newuser.Login(r)
 		if err != nil {
 			if err.Error() == "wrong username or password" {
-				rend.HTML(w, http.StatusUnauthorized, "user/login", "Wrong username or password.")
+				rend.HTML(w, http.StatusUnauthorized, "user/login", Page{Data: Login{Email: login.Email}, Err: "Wrong username or password."})
 				return
 			}
 			if err.Error() == "not found" {
-				rend.HTML(w, http.StatusUnauthorized, "user/login", "User with that email does not exist.")
+				rend.HTML(w, http.StatusUnauthorized, "user/login", Page{Data: Login{Email: login.Email}, Errors: map[string]string{"email": "User with that email does not exist."}})
 				return
 			}
-			rend.HTML(w, http.StatusInternalServerError, "user/login", "Internal server error. Please try again.")
+			rend.HTML(w, http.StatusInternalServerError, "user/login", Page{Data: Login{Email: login.Email}, Err: "Internal server error. Please try again."})
 			return
 		}
 		SessionSetValue(w, r, "id", user.ID)
@@ -255,20 +346,38 @@ func LoginUser(w http.ResponseWriter, r *http.Request) {
 }
 
 // RecoverUser is a route of the first step of account recovery, which sends out the recovery
-// email etc. associated function calls.
+// email etc. associated function calls. Answers 403 Forbidden if recovery is disabled in settings.
 func RecoverUser(w http.ResponseWriter, r *http.Request) {
-	var user User
-	r.ParseForm()
-	user.Email = r.PostFormValue("email")
+	if !Settings.Recovery {
+		switch root(r) {
+		case "api":
+			rend.JSON(w, http.StatusForbidden, APIError{Message: "Password recovery is disabled.", Code: "recovery_disabled"})
+		case "user":
+			rend.HTML(w, http.StatusForbidden, "user/recover", Page{Data: RecoveryRequest{}, Err: "Password recovery is disabled."})
+		}
+		return
+	}
+	input := new(RecoveryRequest)
+	if errs := binding.Bind(r, input); len(errs) > 0 {
+		switch root(r) {
+		case "api":
+			rend.JSON(w, validationStatus(errs), ValidationError(errs))
+		case "user":
+			rend.HTML(w, validationStatus(errs), "user/recover", Page{Data: *input, Errors: fieldErrors(errs)})
+		}
+		return
+	}
 
+	var user User
+	user.Email = input.Email
 	user, err := user.Recover(r)
 	if err != nil {
 		log.Println("recoveruser recover: ", err)
 		if err.Error() == "not found" {
-			rend.JSON(w, http.StatusUnauthorized, map[string]interface{}{"error": "User with that email does not exist."})
+			rend.JSON(w, http.StatusUnauthorized, APIError{Message: "User with that email does not exist.", Code: "unknown_email"})
 			return
 		}
-		rend.JSON(w, http.StatusInternalServerError, map[string]interface{}{"error": "Internal server error"})
+		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
 		return
 	}
 	switch root(r) {
@@ -285,14 +394,11 @@ func RecoverUser(w http.ResponseWriter, r *http.Request) {
 // account recovery emails.
 func ResetUserPassword(w http.ResponseWriter, r *http.Request) {
 	var user User
-	r.ParseForm()
-	user.Password = r.PostFormValue("password")
-
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
 		log.Println("resetuserpassword id: ", err)
-		rend.JSON(w, http.StatusBadRequest, map[string]interface{}{"error": "User ID could not be parsed from request URL."})
+		rend.JSON(w, http.StatusBadRequest, BadRequest("invalid_id", "User ID could not be parsed from request URL."))
 		return
 	}
 	user.ID = int64(id)
@@ -302,10 +408,10 @@ func ResetUserPassword(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		log.Println("resetuserpassword get: ", err)
 		if err.Error() == "not found" {
-			rend.JSON(w, http.StatusBadRequest, map[string]interface{}{"error": "User with that ID does not exist."})
+			rend.JSON(w, http.StatusBadRequest, BadRequest("invalid_id", "User with that ID does not exist."))
 			return
 		}
-		rend.JSON(w, http.StatusInternalServerError, map[string]interface{}{"error": "Internal server error"})
+		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
 		return
 	}
 	// this ensures that accounts won't be compromised by posting recovery string as empty,
@@ -313,15 +419,26 @@ func ResetUserPassword(w http.ResponseWriter, r *http.Request) {
 	UUID := uuid.Parse(vars["recovery"])
 	if UUID == nil {
 		log.Println("there was a problem trying to verify password reset UUID for", entry.Email)
-		rend.JSON(w, http.StatusBadRequest, map[string]interface{}{"error": "Could not parse UUID from the request."})
+		rend.JSON(w, http.StatusBadRequest, BadRequest("invalid_recovery", "Could not parse UUID from the request."))
 		return
 	}
 	if entry.Recovery == vars["recovery"] {
+		input := new(PasswordReset)
+		if errs := binding.Bind(r, input); len(errs) > 0 {
+			switch root(r) {
+			case "api":
+				rend.JSON(w, validationStatus(errs), ValidationError(errs))
+			case "user":
+				rend.HTML(w, validationStatus(errs), "user/reset", Page{Data: PasswordReset{}, Errors: fieldErrors(errs)})
+			}
+			return
+		}
+		user.Password = input.Password
 		entry.Password = user.Password
 		digest, err := GenerateHash(entry.Password)
 		if err != nil {
 			log.Println("resertuserpassword genhash: ", err)
-			rend.JSON(w, http.StatusInternalServerError, map[string]interface{}{"error": "Internal server error"})
+			rend.JSON(w, http.StatusInternalServerError, InternalServerError())
 			return
 		}
 		entry.Digest = digest
@@ -329,7 +446,7 @@ func ResetUserPassword(w http.ResponseWriter, r *http.Request) {
 		_, err = user.Update(r)
 		if err != nil {
 			log.Println("resetuserpassword update: ", err)
-			rend.JSON(w, http.StatusInternalServerError, map[string]interface{}{"error": "Internal server error"})
+			rend.JSON(w, http.StatusInternalServerError, InternalServerError())
 			return
 		}
 		switch root(r) {
@@ -487,12 +604,24 @@ func (user User) GetByEmail() (User, error) {
 
 // Insert or user.Insert inserts a new User struct into the database.
 // The function creates .Digest hash from .Password.
+// If .Role is not set, the first user in the database becomes an admin and the rest authors.
 func (user User) Insert(r *http.Request) (User, error) {
 	digest, err := GenerateHash(user.Password)
 	if err != nil {
 		return user, err
 	}
 	user.Digest = digest
+	if user.Role == "" {
+		var count int
+		query := db.Model(&User{}).Count(&count)
+		if query.Error != nil {
+			return user, query.Error
+		}
+		user.Role = RoleAuthor
+		if count == 0 {
+			user.Role = RoleAdmin
+		}
+	}
 	user.Posts = make([]Post, 0)
 	query := db.Create(&user)
 	if query.Error != nil {
@@ -529,7 +658,7 @@ func (user User) SendRecoverMail() error {
 	id := strconv.Itoa(int(user.ID))
 	urlhost := urlHost()
 
-	m := mailgun.NewMessage("Password Reset <postmaster@"+Settings.Mailer.Domain+">", "Password Reset", "Somebody requested password recovery on this email. You may reset your password through this link: "+urlhost+"user/reset/"+id+"/"+user.Recovery, "Recipient <"+user.Email+">")
+	m := mailgun.NewMessage("Password Reset <postmaster@"+Settings.Mailer.Domain+">", "Password Reset", "Somebody requested password recovery on this email. You may reset your password through this link: "+urlhost+"/user/reset/"+id+"/"+user.Recovery, "Recipient <"+user.Email+">")
 	if _, _, err := gun.Send(m); err != nil {
 		return err
 	}
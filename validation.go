@@ -0,0 +1,129 @@
+// Validation.go contains the rules the Validate methods of bound structs check their fields against,
+// and how routes answer requests which break them. Requests which cannot be read at all answer
+// 400 Bad Request, and requests with invalid fields 422 Unprocessable Entity, listing the fields.
+// Forms are rendered again with the submitted values and an error next to each invalid field.
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mholt/binding"
+)
+
+// Limits of fields.
+const (
+	maxTitleLength    = 200
+	maxNameLength     = 100
+	minPasswordLength = 8
+)
+
+// Classifications of binding.Error for fields which break the rules. They are the codes of FieldError as is.
+const (
+	ErrInvalidEmail    = "invalid_email"
+	ErrInvalidEvent    = "invalid_event"
+	ErrInvalidHostname = "invalid_hostname"
+	ErrInvalidScope    = "invalid_scope"
+	ErrInvalidURL      = "invalid_url"
+	ErrTooLong         = "too_long"
+	ErrWeakPassword    = "weak_password"
+)
+
+// emailPattern matches addresses with a local part, an @ and a domain with a dot, which is all
+// an address can be checked for without sending mail to it.
+var emailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s.]+$`)
+
+// validEmail reports whether email looks like an email address.
+func validEmail(email string) bool {
+	return emailPattern.MatchString(email)
+}
+
+// strongPassword reports whether password is long enough and mixes at least two of lowercase
+// letters, uppercase letters, digits and other characters.
+func strongPassword(password string) bool {
+	if utf8.RuneCountInString(password) < minPasswordLength {
+		return false
+	}
+	var lower, upper, digit, other int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			other = 1
+		}
+	}
+	return lower+upper+digit+other >= 2
+}
+
+// validHostname reports whether hostname is an absolute http or https URL, such as http://example.com.
+// Paths are rejected, as URLs are built by appending "/..." to the hostname, but a trailing slash is allowed.
+func validHostname(hostname string) bool {
+	u, err := url.Parse(hostname)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && (u.Path == "" || u.Path == "/") && u.RawQuery == "" && u.Fragment == ""
+}
+
+// validHTTPURL reports whether s is an absolute http or https URL, such as of a webhook.
+func validHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// requireField adds a binding.RequiredError for field to errs if value is empty.
+func requireField(errs binding.Errors, field, value, message string) binding.Errors {
+	if value == "" {
+		errs.Add([]string{field}, binding.RequiredError, message)
+	}
+	return errs
+}
+
+// limitLength adds an error for field to errs if value is longer than max characters.
+func limitLength(errs binding.Errors, field, value string, max int, message string) binding.Errors {
+	if utf8.RuneCountInString(value) > max {
+		errs.Add([]string{field}, ErrTooLong, message)
+	}
+	return errs
+}
+
+// validationStatus returns the status of the response to a request binding complained about:
+// 400 Bad Request if the body could not be read, 422 Unprocessable Entity if its fields are invalid.
+func validationStatus(errs binding.Errors) int {
+	for _, err := range errs {
+		switch err.Classification {
+		case binding.DeserializationError, binding.ContentTypeError, binding.TypeError:
+			return http.StatusBadRequest
+		}
+	}
+	return http.StatusUnprocessableEntity
+}
+
+// fieldErrors returns the first message of errs for each field, which forms show next to the field.
+// Errors of the whole request are under the empty field name.
+func fieldErrors(errs binding.Errors) map[string]string {
+	messages := make(map[string]string)
+	for _, err := range errs {
+		fields := err.FieldNames
+		if len(fields) == 0 {
+			fields = []string{""}
+		}
+		for _, field := range fields {
+			if _, exists := messages[field]; !exists {
+				messages[field] = err.Message
+			}
+		}
+	}
+	return messages
+}
@@ -3,6 +3,7 @@ package main
 import (
 	//"log"
 	"net/http"
+	"strings"
 )
 
 /*
@@ -16,19 +17,43 @@ import (
 func (v *Vertigo) FieldMap() binding.FieldMap {
 	return binding.FieldMap{
 		&v.AllowRegistrations: "allowregistrations",
+		&v.CodeTheme:          "codetheme",
 		&v.CookieHash:         "cookiehash,omitempty",
 		&v.Description:        "description",
 		&v.Disqus:             "disqus",
+		&v.EmbedHosts:         "embedhosts",
 		&v.Firstrun:           "firstrun,omitempty",
 		&v.GoogleAnalytics:    "ga",
 		&v.Hostname:           "hostname",
+		&v.LineNumbers:        "linenumbers",
 		&v.Mailer:             "mailgun",
 		&v.Markdown:           "markdown",
 		&v.Name:               "name",
+		&v.Recovery:           "recovery",
 	}
 }
 
 func (v *Vertigo) Validate(req *http.Request, errs binding.Errors) binding.Errors {
 	//log.Println("bindingvalidate: ", v)
+	// Forms post the Mailgun settings as fields of their own, which are not bound into v.Mailer.
+	if domain := req.PostFormValue("mgdomain"); domain != "" {
+		v.Mailer.Domain = domain
+	}
+	if key := req.PostFormValue("mgprikey"); key != "" {
+		v.Mailer.PrivateKey = key
+	}
+	errs = requireField(errs, "name", v.Name, "Blog name is required.")
+	errs = requireField(errs, "description", v.Description, "Blog description is required.")
+	// URLs are built by appending "/..." to the hostname, so it is stored without a trailing slash.
+	v.Hostname = strings.TrimRight(v.Hostname, "/")
+	errs = requireField(errs, "hostname", v.Hostname, "Hostname is required.")
+	if v.Hostname != "" && !validHostname(v.Hostname) {
+		errs.Add([]string{"hostname"}, ErrInvalidHostname, "Hostname must be an absolute URL, such as http://example.com.")
+	}
+	// Recovery emails are sent through Mailgun, so it is only needed if recovery is enabled.
+	if v.Recovery {
+		errs = requireField(errs, "mgdomain", v.Mailer.Domain, "Mailgun domain is required for password recovery.")
+		errs = requireField(errs, "mgprikey", v.Mailer.PrivateKey, "Mailgun API key is required for password recovery.")
+	}
     return errs
 }
\ No newline at end of file
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+/*
+This is an autogenerated file by autobindings
+*/
+
+import (
+	"github.com/mholt/binding"
+)
+
+func (w *Webhook) FieldMap() binding.FieldMap {
+	return binding.FieldMap{
+		&w.Disabled: "disabled",
+		&w.Events:   "events",
+		&w.Secret:   "secret",
+		&w.URL:      "url",
+	}
+}
+
+func (w *Webhook) Validate(req *http.Request, errs binding.Errors) binding.Errors {
+	// The checkboxes of the form send each event as a value of its own.
+	if events := req.PostForm["events"]; len(events) > 1 {
+		w.Events = strings.Join(events, ",")
+	}
+	if w.URL != "" && !validHTTPURL(w.URL) {
+		errs.Add([]string{"url"}, ErrInvalidURL, "URL must be an absolute http or https URL.")
+	}
+	for _, event := range splitList(w.Events) {
+		if !validWebhookEvent(event) {
+			errs.Add([]string{"events"}, ErrInvalidEvent, "Events must be some of "+strings.Join(webhookEvents, ", ")+".")
+			break
+		}
+	}
+    return errs
+}
@@ -0,0 +1,497 @@
+// Webhooks.go notifies other systems when the content of the blog changes, such as a CDN which
+// purges a published post or a chat which announces it. Admins register webhooks with a URL,
+// a secret and the events they want. Each event is POSTed to the URL as a JSON WebhookEvent, signed
+// with HMAC-SHA256 of the body keyed with the secret in header X-Vertigo-Signature. Events are delivered
+// in the background and retried with a doubling delay until the receiver answers with 2xx.
+// Every attempt is recorded in the delivery log of the webhook with the status code of the response.
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"code.google.com/p/go-uuid/uuid"
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+	"github.com/mholt/binding"
+)
+
+// Events webhooks can subscribe to. EventPing is only sent by the test button and is delivered
+// to every webhook regardless of its events.
+const (
+	EventPostPublished   = "post.published"
+	EventPostUnpublished = "post.unpublished"
+	EventPostUpdated     = "post.updated"
+	EventPostDeleted     = "post.deleted"
+	EventUserCreated     = "user.created"
+	EventPing            = "ping"
+)
+
+// webhookEvents lists the events webhooks can subscribe to.
+var webhookEvents = []string{EventPostPublished, EventPostUnpublished, EventPostUpdated, EventPostDeleted, EventUserCreated}
+
+// Deliveries are attempted webhookAttempts times, waiting webhookRetryDelay before the second attempt
+// and twice as long before each one after it. Receivers which do not answer in webhookTimeout fail the attempt.
+// The delivery log keeps the latest maxWebhookDeliveries attempts of each webhook.
+var (
+	webhookAttempts      = 5
+	webhookRetryDelay    = 30 * time.Second
+	webhookTimeout       = 10 * time.Second
+	maxWebhookDeliveries = 100
+)
+
+// webhookClient sends the requests of webhooks.
+var webhookClient = &http.Client{Timeout: webhookTimeout}
+
+// Webhook is a URL which is notified of events. Events lists the events it subscribes to,
+// comma separated, or is empty for all of them.
+//go:generate autobindings webhook
+type Webhook struct {
+	ID       int64  `json:"id" gorm:"primary_key:yes"`
+	URL      string `json:"url" form:"url" binding:"required"`
+	Secret   string `json:"secret" form:"secret"`
+	Events   string `json:"events" form:"events"`
+	Disabled bool   `json:"disabled" form:"disabled"`
+	Date     int64  `json:"date"`
+}
+
+// WebhookDelivery is an attempt to deliver an event to a webhook. Attempts of the same event
+// share the ID of the event. Status is the status code of the response, or 0 if there was none.
+type WebhookDelivery struct {
+	ID       int64  `json:"id" gorm:"primary_key:yes"`
+	Webhook  int64  `json:"webhook"`
+	Event    string `json:"event"`
+	EventID  string `json:"event_id"`
+	Attempt  int    `json:"attempt"`
+	Status   int    `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration int64  `json:"duration"`
+	Date     int64  `json:"date"`
+}
+
+func (WebhookDelivery) TableName() string { return "webhook_deliveries" }
+
+// WebhookEvent is the body of the requests of webhooks. Data is the post or user the event is about.
+type WebhookEvent struct {
+	ID    string      `json:"id"`
+	Event string      `json:"event"`
+	Date  int64       `json:"date"`
+	Data  interface{} `json:"data"`
+}
+
+// WebhookLog is a webhook with its latest deliveries, as the webhooks page lists them.
+type WebhookLog struct {
+	Webhook
+	Deliveries []WebhookDelivery
+}
+
+// validWebhookEvent reports whether webhooks can subscribe to event.
+func validWebhookEvent(event string) bool {
+	return containsField(webhookEvents, event)
+}
+
+// Subscribed or webhook.Subscribed reports whether the webhook wants to be notified of event.
+func (webhook Webhook) Subscribed(event string) bool {
+	events := splitList(webhook.Events)
+	return event == EventPing || len(events) == 0 || containsField(events, event)
+}
+
+// webhookSignature returns the value of header X-Vertigo-Signature of a request with body
+// to a webhook with secret.
+func webhookSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliveries counts the deliveries to webhooks and followers running in the background, so that
+// commands can wait for them before exiting.
+var deliveries sync.WaitGroup
+
+// TriggerWebhooks delivers event about data to every webhook which subscribes to it.
+// Returns immediately, delivering the event in the background.
+func TriggerWebhooks(event string, data interface{}) {
+	deliveries.Add(1)
+	go func() {
+		defer deliveries.Done()
+		webhooks, err := AllWebhooks()
+		if err != nil {
+			log.Println("webhooks: ", err)
+			return
+		}
+		payload := WebhookEvent{ID: uuid.New(), Event: event, Date: time.Now().Unix(), Data: data}
+		for _, webhook := range webhooks {
+			if !webhook.Disabled && webhook.Subscribed(event) {
+				deliveries.Add(1)
+				go func(webhook Webhook) {
+					defer deliveries.Done()
+					webhook.Deliver(payload)
+				}(webhook)
+			}
+		}
+	}()
+}
+
+// Deliver or webhook.Deliver sends event to the webhook until it succeeds or webhookAttempts
+// attempts have failed, waiting longer after each failure.
+func (webhook Webhook) Deliver(event WebhookEvent) {
+	delay := webhookRetryDelay
+	for attempt := 1; attempt <= webhookAttempts; attempt++ {
+		delivery, err := webhook.Send(event, attempt)
+		if err != nil {
+			log.Println("webhook delivery: ", err)
+			return
+		}
+		if delivery.Succeeded() {
+			return
+		}
+		if attempt < webhookAttempts {
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+}
+
+// Send or webhook.Send makes a single attempt to deliver event to the webhook and records it
+// in the delivery log. Returns the recorded attempt, and an error only if it could not be recorded.
+func (webhook Webhook) Send(event WebhookEvent, attempt int) (WebhookDelivery, error) {
+	delivery := WebhookDelivery{Webhook: webhook.ID, Event: event.Event, EventID: event.ID, Attempt: attempt, Date: time.Now().Unix()}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return delivery, err
+	}
+	request, err := http.NewRequest("POST", webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return delivery, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "Vertigo-Webhook")
+	request.Header.Set("X-Vertigo-Event", event.Event)
+	request.Header.Set("X-Vertigo-Delivery", event.ID)
+	request.Header.Set("X-Vertigo-Signature", webhookSignature(webhook.Secret, body))
+
+	start := time.Now()
+	response, err := webhookClient.Do(request)
+	delivery.Duration = int64(time.Since(start) / time.Millisecond)
+	if err != nil {
+		delivery.Error = err.Error()
+	} else {
+		// The body is read so that the connection can be reused.
+		io.Copy(ioutil.Discard, io.LimitReader(response.Body, 1<<16))
+		response.Body.Close()
+		delivery.Status = response.StatusCode
+		if !delivery.Succeeded() {
+			delivery.Error = response.Status
+		}
+	}
+	if err := db.Create(&delivery).Error; err != nil {
+		return delivery, err
+	}
+	return delivery, webhook.pruneDeliveries()
+}
+
+// Succeeded or delivery.Succeeded reports whether the receiver answered with 2xx.
+func (delivery WebhookDelivery) Succeeded() bool {
+	return delivery.Status >= 200 && delivery.Status < 300
+}
+
+// Ping or webhook.Ping sends a ping event to the webhook once, without retrying.
+// The ping has the webhook itself as its data, without the secret.
+func (webhook Webhook) Ping() (WebhookDelivery, error) {
+	data := webhook
+	data.Secret = ""
+	return webhook.Send(WebhookEvent{ID: uuid.New(), Event: EventPing, Date: time.Now().Unix(), Data: data}, 1)
+}
+
+// pruneDeliveries removes all but the latest maxWebhookDeliveries attempts of the webhook from its delivery log.
+func (webhook Webhook) pruneDeliveries() error {
+	var deliveries []WebhookDelivery
+	query := db.Order("id desc").Offset(maxWebhookDeliveries).Limit(1).Where("webhook = ?", webhook.ID).Find(&deliveries)
+	if query.Error != nil && query.Error != gorm.RecordNotFound {
+		return query.Error
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return db.Where("webhook = ? AND id <= ?", webhook.ID, deliveries[0].ID).Delete(WebhookDelivery{}).Error
+}
+
+// Deliveries or webhook.Deliveries returns the latest limit attempts of the delivery log of the webhook, newest first.
+func (webhook Webhook) Deliveries(limit int) ([]WebhookDelivery, error) {
+	deliveries := make([]WebhookDelivery, 0)
+	query := db.Order("id desc").Limit(limit).Where("webhook = ?", webhook.ID).Find(&deliveries)
+	if query.Error != nil && query.Error != gorm.RecordNotFound {
+		return deliveries, query.Error
+	}
+	return deliveries, nil
+}
+
+// AllWebhooks returns every webhook in the order they were added.
+func AllWebhooks() ([]Webhook, error) {
+	webhooks := make([]Webhook, 0)
+	query := db.Order("id").Find(&webhooks)
+	if query.Error != nil && query.Error != gorm.RecordNotFound {
+		return webhooks, query.Error
+	}
+	return webhooks, nil
+}
+
+// Get or webhook.Get returns the webhook with webhook.ID.
+func (webhook Webhook) Get() (Webhook, error) {
+	query := db.Where(&Webhook{ID: webhook.ID}).First(&webhook)
+	if query.Error != nil {
+		if query.Error == gorm.RecordNotFound {
+			return webhook, errors.New("not found")
+		}
+		return webhook, query.Error
+	}
+	return webhook, nil
+}
+
+// Insert or webhook.Insert adds the webhook to the database. Webhooks without a secret get a random one.
+func (webhook Webhook) Insert() (Webhook, error) {
+	if webhook.Secret == "" {
+		secret, err := randomSecret()
+		if err != nil {
+			return webhook, err
+		}
+		webhook.Secret = secret
+	}
+	webhook.Events = strings.Join(splitList(webhook.Events), ",")
+	webhook.Date = time.Now().Unix()
+	if err := db.Create(&webhook).Error; err != nil {
+		return webhook, err
+	}
+	return webhook, nil
+}
+
+// Update or webhook.Update replaces the URL, events and state of the webhook with the ones of input.
+// The secret is kept unless input has one.
+func (webhook Webhook) Update(input Webhook) (Webhook, error) {
+	webhook.URL = input.URL
+	webhook.Events = strings.Join(splitList(input.Events), ",")
+	webhook.Disabled = input.Disabled
+	if input.Secret != "" {
+		webhook.Secret = input.Secret
+	}
+	if err := db.Save(&webhook).Error; err != nil {
+		return webhook, err
+	}
+	return webhook, nil
+}
+
+// Delete or webhook.Delete deletes the webhook and its delivery log.
+func (webhook Webhook) Delete() error {
+	query := db.Where("id = ?", webhook.ID).Delete(Webhook{})
+	if query.Error != nil {
+		return query.Error
+	}
+	if query.RowsAffected == 0 {
+		return errors.New("not found")
+	}
+	return db.Where("webhook = ?", webhook.ID).Delete(WebhookDelivery{}).Error
+}
+
+// requestedWebhook returns the webhook of mux parameter "id".
+func requestedWebhook(r *http.Request) (Webhook, error) {
+	var webhook Webhook
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		return webhook, errors.New("invalid id")
+	}
+	webhook.ID = id
+	return webhook.Get()
+}
+
+// webhookError writes the response for an error returned by requestedWebhook or webhook methods.
+func webhookError(w http.ResponseWriter, err error) {
+	switch err.Error() {
+	case "not found":
+		rend.JSON(w, http.StatusNotFound, NotFound())
+	case "invalid id":
+		rend.JSON(w, http.StatusBadRequest, BadRequest("invalid_id", "The webhook ID could not be parsed from the request URL."))
+	default:
+		log.Println("webhook: ", err)
+		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
+	}
+}
+
+// webhooksPage renders the webhooks page with the latest deliveries of each webhook, form filling
+// the form to add a webhook and errs the errors of the form, if any.
+func webhooksPage(w http.ResponseWriter, status int, form Webhook, errs binding.Errors) {
+	webhooks, err := AllWebhooks()
+	if err != nil {
+		webhookError(w, err)
+		return
+	}
+	logs := make([]WebhookLog, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		deliveries, err := webhook.Deliveries(10)
+		if err != nil {
+			webhookError(w, err)
+			return
+		}
+		logs = append(logs, WebhookLog{Webhook: webhook, Deliveries: deliveries})
+	}
+	data := map[string]interface{}{"Webhooks": logs, "Form": form, "Events": webhookEvents}
+	rend.HTML(w, status, "user/webhooks", Page{Data: data, Errors: fieldErrors(errs)})
+}
+
+// ReadWebhooks is a route which lists all webhooks. Requires session cookie of an admin.
+// JSON request returns the webhooks, frontend call displays them with their latest deliveries
+// and a form to add more.
+func ReadWebhooks(w http.ResponseWriter, r *http.Request) {
+	switch root(r) {
+	case "api":
+		webhooks, err := AllWebhooks()
+		if err != nil {
+			webhookError(w, err)
+			return
+		}
+		rend.JSON(w, http.StatusOK, webhooks)
+		return
+	case "user":
+		webhooksPage(w, http.StatusOK, Webhook{}, nil)
+		return
+	}
+}
+
+// ReadWebhook is a route which returns the webhook "id". Requires session cookie of an admin.
+// Only available on the JSON API.
+func ReadWebhook(w http.ResponseWriter, r *http.Request) {
+	webhook, err := requestedWebhook(r)
+	if err != nil {
+		webhookError(w, err)
+		return
+	}
+	rend.JSON(w, http.StatusOK, webhook)
+}
+
+// CreateWebhook is a route which adds a webhook. URL is required, and a random secret is generated
+// unless one is given. Requires session cookie of an admin.
+// JSON request returns the created webhook, frontend call redirects back to the webhooks page.
+func CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	input := new(Webhook)
+	errs := binding.Bind(r, input)
+	errs = requireField(errs, "url", input.URL, "URL is required.")
+	if len(errs) > 0 {
+		switch root(r) {
+		case "api":
+			rend.JSON(w, validationStatus(errs), ValidationError(errs))
+		case "user":
+			webhooksPage(w, validationStatus(errs), *input, errs)
+		}
+		return
+	}
+	webhook, err := Webhook{URL: input.URL, Secret: input.Secret, Events: input.Events, Disabled: input.Disabled}.Insert()
+	if err != nil {
+		webhookError(w, err)
+		return
+	}
+	switch root(r) {
+	case "api":
+		rend.JSON(w, http.StatusOK, webhook)
+		return
+	case "user":
+		http.Redirect(w, r, "/user/webhooks", http.StatusFound)
+		return
+	}
+}
+
+// UpdateWebhook is a route which replaces the URL, events and state of the webhook "id".
+// The secret is kept unless a new one is given. Requires session cookie of an admin.
+// Only available on the JSON API, where it returns the updated webhook.
+func UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	webhook, err := requestedWebhook(r)
+	if err != nil {
+		webhookError(w, err)
+		return
+	}
+	input := new(Webhook)
+	errs := binding.Bind(r, input)
+	errs = requireField(errs, "url", input.URL, "URL is required.")
+	if len(errs) > 0 {
+		rend.JSON(w, validationStatus(errs), ValidationError(errs))
+		return
+	}
+	webhook, err = webhook.Update(*input)
+	if err != nil {
+		webhookError(w, err)
+		return
+	}
+	rend.JSON(w, http.StatusOK, webhook)
+}
+
+// DeleteWebhook is a route which deletes the webhook "id" with its delivery log. Requires session cookie of an admin.
+// JSON request returns `HTTP 200 {"success": "Webhook deleted"}`, frontend call redirects back to the webhooks page.
+func DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	webhook, err := requestedWebhook(r)
+	if err != nil {
+		webhookError(w, err)
+		return
+	}
+	if err := webhook.Delete(); err != nil {
+		webhookError(w, err)
+		return
+	}
+	switch root(r) {
+	case "api":
+		rend.JSON(w, http.StatusOK, map[string]interface{}{"success": "Webhook deleted"})
+		return
+	case "user":
+		http.Redirect(w, r, "/user/webhooks", http.StatusFound)
+		return
+	}
+}
+
+// ReadWebhookDeliveries is a route which returns the delivery log of the webhook "id", newest first.
+// Requires session cookie of an admin. Only available on the JSON API.
+func ReadWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	webhook, err := requestedWebhook(r)
+	if err != nil {
+		webhookError(w, err)
+		return
+	}
+	deliveries, err := webhook.Deliveries(maxWebhookDeliveries)
+	if err != nil {
+		webhookError(w, err)
+		return
+	}
+	rend.JSON(w, http.StatusOK, deliveries)
+}
+
+// TestWebhook is a route which sends a ping event to the webhook "id" and waits for the response.
+// The ping is sent even if the webhook is disabled, and is not retried. Requires session cookie of an admin.
+// JSON request returns the delivery, frontend call redirects back to the webhooks page which shows it in the log.
+func TestWebhook(w http.ResponseWriter, r *http.Request) {
+	webhook, err := requestedWebhook(r)
+	if err != nil {
+		webhookError(w, err)
+		return
+	}
+	delivery, err := webhook.Ping()
+	if err != nil {
+		webhookError(w, err)
+		return
+	}
+	switch root(r) {
+	case "api":
+		rend.JSON(w, http.StatusOK, delivery)
+		return
+	case "user":
+		http.Redirect(w, r, "/user/webhooks", http.StatusFound)
+		return
+	}
+}
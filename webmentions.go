@@ -0,0 +1,594 @@
+// Webmentions.go implements Webmention, which lets blogs tell each other when they link to one another.
+// When a post is published or updated, the pages it links to are notified at the webmention endpoints they
+// advertise. Other sites notify the blog at /webmention with the URL of their page (source) and of the post
+// (target). The source is then fetched to verify that it links to the post and to read who wrote it and whether
+// it likes, reposts or replies to the post. Verified webmentions are shown under the post once its author has
+// approved them. Sending and verifying happen in the background. See https://www.w3.org/TR/webmention/.
+package main
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Types of webmentions, told by the microformats class of the link to the post.
+const (
+	MentionLike     = "like"
+	MentionRepost   = "repost"
+	MentionBookmark = "bookmark"
+	MentionReply    = "reply"
+	MentionMention  = "mention"
+)
+
+// Verification states of webmentions.
+const (
+	MentionPending  = "pending"
+	MentionVerified = "verified"
+	MentionInvalid  = "invalid"
+)
+
+// mentionClasses maps the microformats classes of links to the types of webmentions they make.
+var mentionClasses = map[string]string{
+	"u-like-of":     MentionLike,
+	"u-repost-of":   MentionRepost,
+	"u-bookmark-of": MentionBookmark,
+	"u-in-reply-to": MentionReply,
+}
+
+// Pages are fetched with webmentionClient, reading at most maxWebmentionBody bytes of them.
+// Replies are shown with at most maxMentionContent characters of their content.
+var (
+	webmentionClient  = &http.Client{Timeout: 10 * time.Second}
+	maxWebmentionBody = int64(1 << 20)
+	maxMentionContent = 500
+)
+
+// Webmention is a page (Source) which links to a post (Target). Status tells whether the source was
+// verified to link to the post, and Approved whether the author of the post lets it be shown.
+type Webmention struct {
+	ID        int64  `json:"id" gorm:"primary_key:yes"`
+	Post      int64  `json:"post"`
+	Source    string `json:"source"`
+	Target    string `json:"target"`
+	Type      string `json:"type"`
+	Author    string `json:"author"`
+	AuthorURL string `json:"author_url"`
+	Content   string `json:"content" sql:"type:text"`
+	Status    string `json:"status"`
+	Approved  bool   `json:"approved"`
+	Date      int64  `json:"date"`
+	Verified  int64  `json:"verified"`
+}
+
+// Webmentions are the approved webmentions of a post by their type, as they are shown under it.
+type Webmentions struct {
+	Likes     []Webmention
+	Reposts   []Webmention
+	Bookmarks []Webmention
+	Replies   []Webmention
+	Mentions  []Webmention
+}
+
+// Empty or mentions.Empty reports whether there are no webmentions to show.
+// Used in "/post/display.tmpl".
+func (mentions Webmentions) Empty() bool {
+	return len(mentions.Likes)+len(mentions.Reposts)+len(mentions.Bookmarks)+len(mentions.Replies)+len(mentions.Mentions) == 0
+}
+
+// Webmentions or post.Webmentions returns the verified and approved webmentions of the post, oldest first.
+func (post Post) Webmentions() (Webmentions, error) {
+	var mentions Webmentions
+	var all []Webmention
+	query := db.Order("date asc").Where("post = ? AND status = ? AND approved = ?", post.ID, MentionVerified, true).Find(&all)
+	if query.Error != nil && query.Error != gorm.RecordNotFound {
+		return mentions, query.Error
+	}
+	for _, mention := range all {
+		switch mention.Type {
+		case MentionLike:
+			mentions.Likes = append(mentions.Likes, mention)
+		case MentionRepost:
+			mentions.Reposts = append(mentions.Reposts, mention)
+		case MentionBookmark:
+			mentions.Bookmarks = append(mentions.Bookmarks, mention)
+		case MentionReply:
+			mentions.Replies = append(mentions.Replies, mention)
+		default:
+			mentions.Mentions = append(mentions.Mentions, mention)
+		}
+	}
+	return mentions, nil
+}
+
+// deleteWebmentions deletes all webmentions of post with ID id.
+func deleteWebmentions(tx *gorm.DB, id int64) error {
+	return tx.Where("post = ?", id).Delete(Webmention{}).Error
+}
+
+// hasClass reports whether n has class.
+func hasClass(n *html.Node, class string) bool {
+	return containsField(strings.Fields(mdAttr(n, "class")), class)
+}
+
+// findNode returns the first node below n, n included, for which match returns true, or nil if there is none.
+func findNode(n *html.Node, match func(*html.Node) bool) *html.Node {
+	if match(n) {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findNode(c, match); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// resolveURL returns ref resolved against base without its fragment, or an empty string if ref is not a URL.
+func resolveURL(base *url.URL, ref string) string {
+	u, err := url.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return ""
+	}
+	u = base.ResolveReference(u)
+	u.Fragment = ""
+	return u.String()
+}
+
+// fetchPage fetches the page at rawurl. Returns the response with at most maxWebmentionBody bytes of its body,
+// and the document if the page is HTML.
+func fetchPage(rawurl string) (*http.Response, []byte, *html.Node, error) {
+	request, err := http.NewRequest("GET", rawurl, nil)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	request.Header.Set("User-Agent", "Vertigo-Webmention")
+	response, err := webmentionClient.Do(request)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(io.LimitReader(response.Body, maxWebmentionBody))
+	if err != nil {
+		return response, nil, nil, err
+	}
+	mediatype, _, _ := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	if mediatype != "text/html" && mediatype != "application/xhtml+xml" {
+		return response, body, nil, nil
+	}
+	doc, err := html.Parse(strings.NewReader(string(body)))
+	if err != nil {
+		return response, body, nil, err
+	}
+	return response, body, doc, nil
+}
+
+// Verify or mention.Verify fetches the source of the webmention and checks that it links to the target.
+// Verified webmentions get their type, author and, for replies, content from the microformats of the source.
+// Webmentions whose source is gone are deleted, and ones whose source does not link to the target are invalid.
+func (mention Webmention) Verify() error {
+	// Values from an earlier verification must not outlive the link they were found with.
+	mention.Author = ""
+	mention.AuthorURL = ""
+	mention.Content = ""
+	response, body, doc, err := fetchPage(mention.Source)
+	switch {
+	case err != nil && response == nil:
+		mention.Status = MentionInvalid
+	case response.StatusCode == http.StatusGone:
+		return db.Delete(&mention).Error
+	case response.StatusCode < 200 || response.StatusCode > 299:
+		mention.Status = MentionInvalid
+	case doc == nil:
+		// Pages which are not HTML only need to contain the URL of the target.
+		mention.Status = MentionInvalid
+		if strings.Contains(string(body), mention.Target) {
+			mention.Status = MentionVerified
+			mention.Type = MentionMention
+		}
+	default:
+		mention.Status = MentionInvalid
+		base := response.Request.URL
+		link := findNode(doc, func(n *html.Node) bool {
+			if n.Type != html.ElementNode {
+				return false
+			}
+			switch n.DataAtom {
+			case atom.A, atom.Link:
+				return resolveURL(base, mdAttr(n, "href")) == mention.Target
+			case atom.Img, atom.Video, atom.Audio:
+				return resolveURL(base, mdAttr(n, "src")) == mention.Target
+			}
+			return false
+		})
+		if link != nil {
+			mention.Status = MentionVerified
+			mention.Type = MentionMention
+			for class, kind := range mentionClasses {
+				if hasClass(link, class) {
+					mention.Type = kind
+				}
+			}
+			mention.Author, mention.AuthorURL = mentionAuthor(doc, base)
+			mention.Content = ""
+			if mention.Type == MentionReply {
+				mention.Content = mentionContent(doc)
+			}
+		}
+	}
+	if mention.Author == "" {
+		if u, err := url.Parse(mention.Source); err == nil {
+			mention.Author = u.Host
+			mention.AuthorURL = u.Scheme + "://" + u.Host
+		}
+	}
+	if mention.Status == MentionVerified {
+		mention.Verified = time.Now().Unix()
+	}
+	return db.Save(&mention).Error
+}
+
+// mentionAuthor returns the name and URL of the p-author of doc, or empty strings if it has none.
+func mentionAuthor(doc *html.Node, base *url.URL) (string, string) {
+	author := findNode(doc, func(n *html.Node) bool { return n.Type == html.ElementNode && hasClass(n, "p-author") })
+	if author == nil {
+		return "", ""
+	}
+	name := strings.TrimSpace(mdText(author))
+	if n := findNode(author, func(n *html.Node) bool { return n.Type == html.ElementNode && hasClass(n, "p-name") }); n != nil {
+		name = strings.TrimSpace(mdText(n))
+	}
+	link := author
+	if author.DataAtom != atom.A {
+		link = findNode(author, func(n *html.Node) bool { return n.DataAtom == atom.A && hasClass(n, "u-url") })
+	}
+	if link == nil || mdAttr(link, "href") == "" {
+		return strings.Join(strings.Fields(name), " "), ""
+	}
+	return strings.Join(strings.Fields(name), " "), resolveURL(base, mdAttr(link, "href"))
+}
+
+// mentionContent returns the text of the e-content or p-content of doc, shortened to maxMentionContent characters.
+func mentionContent(doc *html.Node) string {
+	content := findNode(doc, func(n *html.Node) bool {
+		return n.Type == html.ElementNode && (hasClass(n, "e-content") || hasClass(n, "p-content"))
+	})
+	if content == nil {
+		return ""
+	}
+	text := []rune(strings.Join(strings.Fields(mdText(content)), " "))
+	if len(text) > maxMentionContent {
+		return string(text[:maxMentionContent]) + "…"
+	}
+	return string(text)
+}
+
+// SendWebmentions notifies the pages linked from the content of post, and from its previous content if
+// it was updated, so that pages which are no longer linked learn of it too. Only published posts send webmentions.
+// Returns immediately, sending them in the background.
+func SendWebmentions(post Post, previous string) {
+	if !post.Published {
+		return
+	}
+	go func() {
+		path, err := post.Path()
+		if err != nil {
+			log.Println("send webmentions: ", err)
+			return
+		}
+		source := urlHost() + path
+		for _, target := range outboundLinks(post.Content + previous) {
+			endpoint, err := discoverEndpoint(target)
+			if err != nil || endpoint == "" {
+				continue
+			}
+			if err := sendWebmention(endpoint, source, target); err != nil {
+				log.Println("send webmention: ", err)
+			}
+		}
+	}()
+}
+
+// outboundLinks returns the absolute http and https links of content to other sites, each once.
+func outboundLinks(content string) []string {
+	links := make([]string, 0)
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return links
+	}
+	own, _ := url.Parse(urlHost())
+	findNode(doc, func(n *html.Node) bool {
+		if n.DataAtom != atom.A {
+			return false
+		}
+		href := mdAttr(n, "href")
+		u, err := url.Parse(href)
+		if err != nil || !validHTTPURL(href) || (own != nil && u.Host == own.Host) || containsField(links, href) {
+			return false
+		}
+		links = append(links, href)
+		return false
+	})
+	return links
+}
+
+// discoverEndpoint returns the webmention endpoint target advertises in header Link or in a link or a element
+// with rel webmention, resolved against the URL of the page. Returns an empty string if it has none.
+func discoverEndpoint(target string) (string, error) {
+	response, _, doc, err := fetchPage(target)
+	if err != nil {
+		return "", err
+	}
+	base := response.Request.URL
+	for _, header := range response.Header["Link"] {
+		for _, link := range strings.Split(header, ",") {
+			parts := strings.Split(link, ";")
+			ref := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(ref, "<") || !strings.HasSuffix(ref, ">") {
+				continue
+			}
+			for _, param := range parts[1:] {
+				param = strings.TrimSpace(param)
+				if !strings.HasPrefix(param, "rel=") {
+					continue
+				}
+				if containsField(strings.Fields(strings.Trim(strings.TrimPrefix(param, "rel="), `"`)), "webmention") {
+					return resolveURL(base, strings.Trim(ref, "<>")), nil
+				}
+			}
+		}
+	}
+	if doc == nil {
+		return "", nil
+	}
+	link := findNode(doc, func(n *html.Node) bool {
+		if n.DataAtom != atom.A && n.DataAtom != atom.Link {
+			return false
+		}
+		_, hasHref := hrefOf(n)
+		return hasHref && containsField(strings.Fields(mdAttr(n, "rel")), "webmention")
+	})
+	if link == nil {
+		return "", nil
+	}
+	// An empty href refers to the page itself.
+	href, _ := hrefOf(link)
+	return resolveURL(base, href), nil
+}
+
+// hrefOf returns the href of n and whether n has one, as an empty href is a link too.
+func hrefOf(n *html.Node) (string, bool) {
+	for _, attr := range n.Attr {
+		if attr.Key == "href" {
+			return attr.Val, true
+		}
+	}
+	return "", false
+}
+
+// sendWebmention tells endpoint that source links to target.
+func sendWebmention(endpoint, source, target string) error {
+	response, err := webmentionClient.PostForm(endpoint, url.Values{"source": {source}, "target": {target}})
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(response.Body, maxWebmentionBody))
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return errors.New(endpoint + " answered " + response.Status)
+	}
+	return nil
+}
+
+// ReceiveWebmention is the webmention endpoint. Accepts form parameters source and target, where target is
+// the URL of a published post on this blog, and answers 202 Accepted before verifying the source in the background.
+// A webmention of the same source and target replaces the earlier one, so sources can tell of their updates.
+// The replaced one awaits approval again, since the source may now say something else.
+func ReceiveWebmention(w http.ResponseWriter, r *http.Request) {
+	source := r.PostFormValue("source")
+	target := r.PostFormValue("target")
+	if !validHTTPURL(source) {
+		rend.JSON(w, http.StatusBadRequest, BadRequest("invalid_source", "Source must be an absolute http or https URL."))
+		return
+	}
+	own, _ := url.Parse(urlHost())
+	u, err := url.Parse(target)
+	if err != nil || !validHTTPURL(target) || own == nil || u.Host != own.Host {
+		rend.JSON(w, http.StatusBadRequest, BadRequest("invalid_target", "Target must be the URL of a post on this blog."))
+		return
+	}
+	if source == target {
+		rend.JSON(w, http.StatusBadRequest, BadRequest("invalid_source", "Source and target must differ."))
+		return
+	}
+	post, err := postAtURL(r, target)
+	if err != nil || !post.Published {
+		rend.JSON(w, http.StatusBadRequest, BadRequest("invalid_target", "Target must be the URL of a post on this blog."))
+		return
+	}
+
+	var mention Webmention
+	query := db.Where("source = ? AND target = ?", source, target).First(&mention)
+	if query.Error != nil && query.Error != gorm.RecordNotFound {
+		log.Println("receive webmention: ", query.Error)
+		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
+		return
+	}
+	mention.Post = post.ID
+	mention.Source = source
+	mention.Target = target
+	mention.Status = MentionPending
+	mention.Approved = false
+	mention.Date = time.Now().Unix()
+	if err := db.Save(&mention).Error; err != nil {
+		log.Println("receive webmention: ", err)
+		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
+		return
+	}
+	go func() {
+		if err := mention.Verify(); err != nil {
+			log.Println("verify webmention: ", err)
+		}
+	}()
+	rend.JSON(w, http.StatusAccepted, map[string]interface{}{"success": "Webmention accepted for verification"})
+}
+
+// userWebmentions returns the webmentions of the posts of user, newest first.
+func userWebmentions(user User) ([]Webmention, error) {
+	mentions := make([]Webmention, 0)
+	ids := make([]int64, 0, len(user.Posts))
+	for _, post := range user.Posts {
+		ids = append(ids, post.ID)
+	}
+	if len(ids) == 0 {
+		return mentions, nil
+	}
+	query := db.Order("id desc").Where("post IN (?)", ids).Find(&mentions)
+	if query.Error != nil && query.Error != gorm.RecordNotFound {
+		return mentions, query.Error
+	}
+	return mentions, nil
+}
+
+// requestedWebmention returns the webmention of mux parameter "id" on a post of the current user.
+// Returns "unauthorized" without session, "not found" if the user has no such webmention and "invalid id".
+func requestedWebmention(r *http.Request) (Webmention, error) {
+	var mention Webmention
+	var user User
+	user, err := user.Session(r)
+	if err != nil {
+		return mention, errors.New("unauthorized")
+	}
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		return mention, errors.New("invalid id")
+	}
+	query := db.Where("id = ?", id).First(&mention)
+	if query.Error != nil {
+		if query.Error == gorm.RecordNotFound {
+			return mention, errors.New("not found")
+		}
+		return mention, query.Error
+	}
+	for _, post := range user.Posts {
+		if post.ID == mention.Post {
+			return mention, nil
+		}
+	}
+	return mention, errors.New("not found")
+}
+
+// webmentionError writes the response for an error returned by requestedWebmention or webmention methods.
+func webmentionError(w http.ResponseWriter, err error) {
+	switch err.Error() {
+	case "unauthorized":
+		rend.JSON(w, http.StatusUnauthorized, Unauthorized())
+	case "not found":
+		rend.JSON(w, http.StatusNotFound, NotFound())
+	case "invalid id":
+		rend.JSON(w, http.StatusBadRequest, BadRequest("invalid_id", "The webmention ID could not be parsed from the request URL."))
+	default:
+		log.Println("webmention: ", err)
+		rend.JSON(w, http.StatusInternalServerError, InternalServerError())
+	}
+}
+
+// ReadWebmentions is a route which lists the webmentions of the posts of the current user, newest first.
+// Requires session cookie.
+// JSON request returns the webmentions, frontend call displays them for moderation.
+func ReadWebmentions(w http.ResponseWriter, r *http.Request) {
+	var user User
+	user, err := user.Session(r)
+	if err != nil {
+		webmentionError(w, errors.New("unauthorized"))
+		return
+	}
+	mentions, err := userWebmentions(user)
+	if err != nil {
+		webmentionError(w, err)
+		return
+	}
+	switch root(r) {
+	case "api":
+		rend.JSON(w, http.StatusOK, mentions)
+		return
+	case "user":
+		rend.HTML(w, http.StatusOK, "user/webmentions", mentions)
+		return
+	}
+}
+
+// setApproved sets whether the webmention "id" is shown under its post. Verified webmentions only are shown,
+// whether approved or not.
+func setApproved(w http.ResponseWriter, r *http.Request, approved bool) {
+	mention, err := requestedWebmention(r)
+	if err != nil {
+		webmentionError(w, err)
+		return
+	}
+	if err := db.Model(&mention).UpdateColumn("approved", approved).Error; err != nil {
+		webmentionError(w, err)
+		return
+	}
+	switch root(r) {
+	case "api":
+		message := "Webmention approved"
+		if !approved {
+			message = "Webmention hidden"
+		}
+		rend.JSON(w, http.StatusOK, map[string]interface{}{"success": message})
+		return
+	case "user":
+		http.Redirect(w, r, "/user/webmentions", http.StatusFound)
+		return
+	}
+}
+
+// ApproveWebmention is a route which shows the webmention "id" under its post once it is verified.
+// Only the author of the post is allowed.
+// JSON request returns `HTTP 200 {"success": "Webmention approved"}`, frontend call redirects back to the webmentions page.
+func ApproveWebmention(w http.ResponseWriter, r *http.Request) {
+	setApproved(w, r, true)
+}
+
+// HideWebmention is a route which stops showing the webmention "id" under its post.
+// Only the author of the post is allowed.
+// JSON request returns `HTTP 200 {"success": "Webmention hidden"}`, frontend call redirects back to the webmentions page.
+func HideWebmention(w http.ResponseWriter, r *http.Request) {
+	setApproved(w, r, false)
+}
+
+// DeleteWebmention is a route which deletes the webmention "id". Only the author of the post is allowed.
+// The source may send it again, and it then awaits approval anew.
+// JSON request returns `HTTP 200 {"success": "Webmention deleted"}`, frontend call redirects back to the webmentions page.
+func DeleteWebmention(w http.ResponseWriter, r *http.Request) {
+	mention, err := requestedWebmention(r)
+	if err != nil {
+		webmentionError(w, err)
+		return
+	}
+	if err := db.Delete(&mention).Error; err != nil {
+		webmentionError(w, err)
+		return
+	}
+	switch root(r) {
+	case "api":
+		rend.JSON(w, http.StatusOK, map[string]interface{}{"success": "Webmention deleted"})
+		return
+	case "user":
+		http.Redirect(w, r, "/user/webmentions", http.StatusFound)
+		return
+	}
+}
@@ -0,0 +1,281 @@
+// Wordpress.go imports WordPress eXtended RSS (WXR) export files, which WordPress produces
+// under Tools > Export. Authors become users, posts keep their slugs, dates, tags and comments,
+// pages keep their parents and menu order, and links to files in wp-content/uploads are rewritten to point to /uploads/.
+package main
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gosimple/slug"
+	"github.com/jinzhu/gorm"
+)
+
+// Element names are matched without namespaces, so that exports of every WXR version
+// (1.0, 1.1 and 1.2 use different namespace URLs) can be read. The one exception is
+// content:encoded, which would otherwise clash with excerpt:encoded.
+type wxrChannel struct {
+	Authors []wxrAuthor `xml:"channel>author"`
+	Items   []wxrItem   `xml:"channel>item"`
+}
+
+type wxrAuthor struct {
+	Login       string `xml:"author_login"`
+	Email       string `xml:"author_email"`
+	DisplayName string `xml:"author_display_name"`
+}
+
+type wxrItem struct {
+	Title         string        `xml:"title"`
+	PostID        int64         `xml:"post_id"`
+	PostParent    int64         `xml:"post_parent"`
+	MenuOrder     int           `xml:"menu_order"`
+	Creator       string        `xml:"creator"`
+	Content       string        `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	PostDate      string        `xml:"post_date"`
+	PostDateGMT   string        `xml:"post_date_gmt"`
+	PostName      string        `xml:"post_name"`
+	Status        string        `xml:"status"`
+	PostType      string        `xml:"post_type"`
+	AttachmentURL string        `xml:"attachment_url"`
+	Categories    []wxrCategory `xml:"category"`
+	Comments      []wxrComment  `xml:"comment"`
+}
+
+type wxrCategory struct {
+	Domain string `xml:"domain,attr"`
+	Name   string `xml:",chardata"`
+}
+
+type wxrComment struct {
+	ID          int64  `xml:"comment_id"`
+	Author      string `xml:"comment_author"`
+	AuthorEmail string `xml:"comment_author_email"`
+	AuthorURL   string `xml:"comment_author_url"`
+	DateGMT     string `xml:"comment_date_gmt"`
+	Content     string `xml:"comment_content"`
+	Approved    string `xml:"comment_approved"`
+	Type        string `xml:"comment_type"`
+	Parent      int64  `xml:"comment_parent"`
+}
+
+// wpUploads matches absolute URLs to the uploads directory of a WordPress site.
+var wpUploads = regexp.MustCompile(`https?://[^\s"'<>]+?/wp-content/uploads/`)
+
+// wpDate parses dates of WXR files, which are in the format "2006-01-02 15:04:05".
+// Drafts have their GMT date set to all zeroes, in which case the local date is tried instead.
+func wpDate(dates ...string) int64 {
+	for _, date := range dates {
+		t, err := time.Parse("2006-01-02 15:04:05", date)
+		if err == nil && t.Year() > 1 {
+			return t.Unix()
+		}
+	}
+	return time.Now().Unix()
+}
+
+// wpAutoP turns the double line breaks WordPress uses as paragraph separators into <p> elements.
+// Content which already has paragraphs, such as posts written in the block editor, is left alone.
+func wpAutoP(s string) string {
+	if strings.Contains(s, "<p>") || strings.Contains(s, "<!-- wp:") {
+		return s
+	}
+	s = strings.Replace(s, "\r\n", "\n", -1)
+	var out []string
+	for _, paragraph := range strings.Split(s, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		out = append(out, "<p>"+strings.Replace(paragraph, "\n", "<br>", -1)+"</p>")
+	}
+	return strings.Join(out, "\n")
+}
+
+// ImportWordPress imports the WXR file read from r. Posts whose author is not in the file are
+// assigned to user fallback. Returns a summary of every item in the file.
+// The import is made in a single transaction, so a file which fails halfway leaves nothing behind.
+func ImportWordPress(r io.Reader, fallback int64) (ImportSummary, error) {
+	var summary ImportSummary
+	var channel wxrChannel
+	if err := xml.NewDecoder(r).Decode(&channel); err != nil {
+		return summary, errors.New("not a WordPress export file: " + err.Error())
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		return summary, tx.Error
+	}
+	if err := importWordPressChannel(tx, channel, fallback, &summary); err != nil {
+		tx.Rollback()
+		return summary, err
+	}
+	return summary, tx.Commit().Error
+}
+
+func importWordPressChannel(tx *gorm.DB, channel wxrChannel, fallback int64, summary *ImportSummary) error {
+	authors, err := importWordPressAuthors(tx, channel.Authors, summary)
+	if err != nil {
+		return err
+	}
+
+	// WordPress post IDs are mapped to the new ones so that pages keep their parents.
+	ids := make(map[int64]int64)
+	for _, item := range channel.Items {
+		switch item.PostType {
+		case "post", "page":
+			if err := importWordPressPost(tx, item, authors, fallback, ids, summary); err != nil {
+				return err
+			}
+		case "attachment":
+			path := wpUploads.ReplaceAllString(item.AttachmentURL, "/uploads/")
+			summary.add(ImportItem{Type: "attachment", Title: item.Title, Slug: path, Status: ImportSkipped, Reason: "links were rewritten to " + path + ", copy the file into the uploads directory"})
+		default:
+			summary.skipped(item.PostType, item.Title, "unsupported post type")
+		}
+	}
+
+	// Parents are set once every page is imported, since a page may come before its parent.
+	for _, item := range channel.Items {
+		if item.PostType != "page" || item.PostParent == 0 || ids[item.PostID] == 0 {
+			continue
+		}
+		page := Post{ID: ids[item.PostID], Kind: KindPage, Parent: ids[item.PostParent]}
+		if page.Parent == 0 || page.checkParent(tx) != nil {
+			summary.add(ImportItem{Type: KindPage, Title: item.Title, Slug: item.PostName, Status: ImportSkipped, Reason: "parent page was not imported, the page was imported without it"})
+			continue
+		}
+		if err := tx.Model(&page).Update("parent", page.Parent).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importWordPressAuthors makes sure every author of the export exists as a user.
+// Returns user IDs keyed by WordPress login.
+func importWordPressAuthors(tx *gorm.DB, authors []wxrAuthor, summary *ImportSummary) (map[string]int64, error) {
+	ids := make(map[string]int64)
+	for _, author := range authors {
+		if author.Email == "" {
+			summary.skipped("author", author.Login, "author has no email address")
+			continue
+		}
+		var user User
+		query := tx.Where(&User{Email: author.Email}).First(&user)
+		if query.Error == nil {
+			ids[author.Login] = user.ID
+			summary.skipped("author", author.Email, "user already exists, posts were assigned to them")
+			continue
+		}
+		if query.Error != gorm.RecordNotFound {
+			return ids, query.Error
+		}
+		// Imported authors get a random password and have to recover their account to log in.
+		password, err := randomPassword()
+		if err != nil {
+			return ids, err
+		}
+		user = User{Email: author.Email, Name: author.DisplayName, Role: RoleAuthor}
+		if user.Digest, err = GenerateHash(password); err != nil {
+			return ids, err
+		}
+		if err := tx.Create(&user).Error; err != nil {
+			return ids, err
+		}
+		ids[author.Login] = user.ID
+		summary.imported("author", author.Email, "")
+	}
+	return ids, nil
+}
+
+func importWordPressPost(tx *gorm.DB, item wxrItem, authors map[string]int64, fallback int64, ids map[int64]int64, summary *ImportSummary) error {
+	var post Post
+	post.Kind = KindPost
+	if item.PostType == "page" {
+		post.Kind = KindPage
+		post.MenuOrder = item.MenuOrder
+	}
+	switch item.Status {
+	case "publish":
+		post.Published = true
+	case "draft", "pending", "private", "future":
+		post.Published = false
+	default:
+		summary.skipped(post.Kind, item.Title, post.Kind+" status is "+item.Status)
+		return nil
+	}
+
+	post.Title = item.Title
+	post.Slug = item.PostName
+	if post.Slug == "" {
+		post.Slug = slug.Make(item.Title)
+	}
+	var existing Post
+	query := tx.Where(&Post{Slug: post.Slug}).First(&existing)
+	if query.Error == nil {
+		summary.skipped(post.Kind, item.Title, "a post with slug "+post.Slug+" already exists")
+		return nil
+	}
+	if query.Error != gorm.RecordNotFound {
+		return query.Error
+	}
+
+	post.Author = fallback
+	if id, exists := authors[item.Creator]; exists {
+		post.Author = id
+	}
+	post.Format = FormatHTML
+	// authorRole reads outside the transaction, which is fine since the authors it cannot see yet
+	// were created by this import with the author role.
+	post.Content = sanitizeContent(wpUploads.ReplaceAllString(wpAutoP(item.Content), "/uploads/"), post.authorRole())
+	post.Excerpt = Excerpt(post.Content)
+	post.ReadingTime = readingTime(post.Content)
+	post.Date = wpDate(item.PostDateGMT, item.PostDate)
+	var tags []string
+	for _, category := range item.Categories {
+		if category.Domain == "category" || category.Domain == "post_tag" {
+			tags = append(tags, strings.TrimSpace(category.Name))
+		}
+	}
+	post.Tags = strings.Join(tags, ", ")
+
+	if err := tx.Create(&post).Error; err != nil {
+		return err
+	}
+	summary.imported(post.Kind, post.Title, post.Slug)
+	ids[item.PostID] = post.ID
+
+	// WordPress comment IDs are mapped to the new ones so that replies keep their parents.
+	comments := make(map[int64]int64)
+	for _, c := range item.Comments {
+		if c.Type == "pingback" || c.Type == "trackback" {
+			summary.skipped("comment", c.Author+" on "+post.Title, c.Type+"s are not supported")
+			continue
+		}
+		if c.Approved == "spam" || c.Approved == "trash" {
+			summary.skipped("comment", c.Author+" on "+post.Title, "comment is marked as "+c.Approved)
+			continue
+		}
+		comment := Comment{
+			Post:     post.ID,
+			Parent:   comments[c.Parent],
+			Author:   c.Author,
+			Email:    c.AuthorEmail,
+			URL:      c.AuthorURL,
+			Content:  c.Content,
+			Date:     wpDate(c.DateGMT),
+			Approved: c.Approved == "1",
+		}
+		if err := tx.Create(&comment).Error; err != nil {
+			return err
+		}
+		comments[c.ID] = comment.ID
+		summary.imported("comment", c.Author+" on "+post.Title, post.Slug)
+	}
+	return nil
+}